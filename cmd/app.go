@@ -1,50 +1,267 @@
 package main
 
 import (
+	"companies/cmd/internal/auth"
 	configparser "companies/cmd/internal/configParser"
 	"companies/cmd/internal/consts"
 	"companies/cmd/internal/database"
 	eventsender "companies/cmd/internal/eventSender"
+	"companies/cmd/internal/health"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/metrics"
+	"companies/cmd/internal/outbox"
 	"companies/cmd/internal/server"
+	"companies/cmd/internal/tracing"
+	"companies/cmd/internal/webhooks"
+	"context"
 	"errors"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultMaxOutboxBacklog         = 1000
+	defaultDrainDelayMs             = 5000
+	defaultShutdownTimeoutMs        = 30000
+	defaultRevocationRecheckSeconds = 5
 )
 
 type app struct {
 	db          io.Closer
 	restServer  server.RESTServer
 	eventSender io.Closer
+	relay       io.Closer
+	dispatcher  io.Closer
+	tracing     io.Closer
+	logger      *slog.Logger
+	health      *health.Checker
+
+	drainDelay      time.Duration
+	shutdownTimeout time.Duration
 }
 
-func NewApp(configPath string) *app {
-	log.Println(consts.ApplicationPrefix, "Starting app")
+// NewApp wires the app up. It fails when tokens couldn't be signed or
+// verified safely, the other components retry or degrade on their own.
+func NewApp(configPath string) (*app, error) {
+	// The level is shared with the admin endpoint, which can change it while
+	// the app runs.
+	level := new(slog.LevelVar)
+	logger := logging.New(os.Stdout, level).With(slog.String("service", consts.ApplicationPrefix))
+	slog.SetDefault(logger)
+
+	logger.Info("Starting app")
 
 	config, err := configparser.LoadConfig(configPath)
 
 	if err != nil {
-		log.Println(consts.ApplicationPrefix, "Failed to load config", err.Error())
+		logger.Error("Failed to load config", logging.Err(err))
 	}
 
-	db := database.NewMySQLDB(config.DB)
+	if name := configparser.GetCfgValue("LOG_LEVEL", config.Logging.Level); name != "" {
+		if parsed, err := logging.ParseLevel(name); err != nil {
+			logger.Warn("Unknown log level, keeping info", slog.String("level", name))
+		} else {
+			level.Set(parsed)
+		}
+	}
 
-	eventSender := eventsender.NewEventSender(config.Kafka)
+	logging.RegisterSecret(configparser.GetCfgValue("JWT_SECRET", auth.DefaultSecret))
 
-	restServer := server.NewRESTfulServer(config.HTTP, db, eventSender)
+	keys, err := auth.LoadKeySet(config.Auth, configparser.GetCfgValue("AUTH_DEV_MODE", config.Auth.DevMode))
+	if err != nil {
+		logger.Error("Refusing to start, token signing isn't configured safely", logging.Err(err))
+		return nil, fmt.Errorf("load signing keys: %w", err)
+	}
+	auth.SetKeySet(keys)
 
-	return &app{db, restServer, nil}
+	oidcConfig := config.Auth.OIDC
+	oidcConfig.Issuer = configparser.GetCfgValue("AUTH_OIDC_ISSUER", oidcConfig.Issuer)
+	oidcConfig.Audience = configparser.GetCfgValue("AUTH_OIDC_AUDIENCE", oidcConfig.Audience)
+	if oidcConfig.Issuer != "" {
+		verifier, err := auth.NewOIDCVerifier(oidcConfig, logger)
+		if err != nil {
+			logger.Error("Refusing to start, OIDC isn't configured correctly", logging.Err(err))
+			return nil, fmt.Errorf("configure OIDC: %w", err)
+		}
+		auth.SetOIDCVerifier(verifier)
+		logger.Info("Accepting OIDC tokens", slog.String("issuer", oidcConfig.Issuer))
+	}
+
+	tracer, err := tracing.Init(config.Tracing, logger)
+	if err != nil {
+		logger.Error("Failed to set up tracing", logging.Err(err))
+	}
+
+	// Served at /metrics, other components may add their collectors.
+	registry := prometheus.NewRegistry()
+	appMetrics, err := metrics.New(registry)
+	if err != nil {
+		logger.Error("Refusing to start, metrics can't be registered", logging.Err(err))
+		return nil, fmt.Errorf("register metrics: %w", err)
+	}
+
+	eventSender, err := eventsender.NewEventSender(*config, appMetrics, logger)
+	if err != nil {
+		logger.Error("Refusing to start, events can't be published", logging.Err(err))
+		return nil, err
+	}
+
+	db := database.NewMySQLDB(config.DB, appMetrics, logger)
+
+	dispatcher := webhooks.NewDispatcher(config.Webhooks, db, logger)
+	dispatcher.Start()
+
+	// Webhooks are fed from the outbox too, so partners only hear about
+	// committed changes. The relay marks events sent, so it waits for the
+	// broker even when kafka.async is on.
+	relay := outbox.NewRelay(config.Outbox, db, map[string]eventsender.EventSender{
+		database.SinkEvents:   eventsender.Confirmed(eventSender),
+		database.SinkWebhooks: dispatcher,
+	}, logger)
+	relay.Start()
+
+	checker := newHealthChecker(config.Health, db, eventSender)
+
+	recheckSeconds := configparser.GetCfgValue("AUTH_REVOCATION_RECHECK_SECONDS", config.Auth.RevocationRecheckSeconds)
+	if recheckSeconds <= 0 {
+		recheckSeconds = defaultRevocationRecheckSeconds
+	}
+	revocations := auth.NewRevocationList(db, time.Duration(recheckSeconds)*time.Second)
+	auth.SetRevocationList(revocations)
+
+	restServer := server.NewRESTfulServer(config.HTTP, config.Auth, db, db, db, revocations, eventSender, checker, appMetrics, registry, logger, level)
+
+	drainDelayMs := configparser.GetCfgValue("HEALTH_DRAIN_DELAY_MS", config.Health.DrainDelayMs)
+	if drainDelayMs <= 0 {
+		drainDelayMs = defaultDrainDelayMs
+	}
+
+	shutdownTimeoutMs := configparser.GetCfgValue("SHUTDOWN_TIMEOUT_MS", config.Shutdown.TimeoutMs)
+	if shutdownTimeoutMs <= 0 {
+		shutdownTimeoutMs = defaultShutdownTimeoutMs
+	}
+
+	return &app{
+		db:              db,
+		restServer:      restServer,
+		eventSender:     eventSender,
+		relay:           relay,
+		dispatcher:      dispatcher,
+		tracing:         tracer,
+		logger:          logger,
+		health:          checker,
+		drainDelay:      time.Duration(drainDelayMs) * time.Millisecond,
+		shutdownTimeout: time.Duration(shutdownTimeoutMs) * time.Millisecond,
+	}, nil
+}
+
+// newHealthChecker checks MySQL, the outbox backlog and, when the transport
+// can be probed, the event broker.
+func newHealthChecker(config configparser.Health, db database.Storage, eventSender eventsender.Transport) *health.Checker {
+	checker := health.NewChecker(time.Duration(configparser.GetCfgValue("HEALTH_TIMEOUT_MS", config.TimeoutMs)) * time.Millisecond)
+
+	checker.Add("mysql", db.Ping)
+
+	maxBacklog := configparser.GetCfgValue("HEALTH_MAX_OUTBOX_BACKLOG", config.MaxOutboxBacklog)
+	if maxBacklog <= 0 {
+		maxBacklog = defaultMaxOutboxBacklog
+	}
+	checker.Add("outbox", health.MaxBacklog(db.OutboxBacklog, int64(maxBacklog)))
+
+	if hc, ok := eventSender.(eventsender.HealthChecker); ok {
+		checker.Add("kafka", hc.CheckHealth)
+	}
+
+	return checker
+}
+
+// Run serves until SIGINT or SIGTERM arrives or the server fails, then shuts
+// the app down within the shutdown timeout. The returned error joins every
+// failure, main exits non-zero on it.
+func (a *app) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() { served <- a.restServer.Serve() }()
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		a.logger.Info("Received shutdown signal")
+	case serveErr = <-served:
+		a.logger.Error("RESTful server stopped", logging.Err(serveErr))
+	}
+
+	// A second signal kills the process instead of waiting for the teardown.
+	stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+
+	return errors.Join(serveErr, a.Shutdown(ctx))
 }
 
-func (a *app) Run() {
-	a.restServer.Serve()
+// Shutdown tears the app down in dependency order: whatever produces work
+// stops before what consumes it, so accepted requests and queued events are
+// finished rather than lost. Components still closing when ctx ends are
+// abandoned and reported.
+func (a *app) Shutdown(ctx context.Context) error {
+	a.logger.Info("Shutting down application")
+
+	// Fail readiness first and keep serving for a while, so load balancers
+	// stop sending requests before the server stops accepting them.
+	a.health.Drain()
+	select {
+	case <-time.After(a.drainDelay):
+	case <-ctx.Done():
+	}
+
+	var serverErr error
+	if err := a.restServer.Shutdown(ctx); err != nil {
+		serverErr = fmt.Errorf("http server: %w", err)
+	}
+
+	err := errors.Join(
+		serverErr,
+		closeWithin(ctx, "outbox relay", a.relay),
+		closeWithin(ctx, "webhook dispatcher", a.dispatcher),
+		closeWithin(ctx, "event sender", a.eventSender),
+		closeWithin(ctx, "database", a.db),
+		closeWithin(ctx, "tracing", a.tracing),
+	)
+	if err != nil {
+		a.logger.Error("Shutdown failed", logging.Err(err))
+		return err
+	}
+
+	a.logger.Info("Shutdown complete")
+	return nil
 }
 
-func (a *app) Close() error {
-	log.Println(consts.ApplicationPrefix, "Shutting down application")
-	errorString := ""
-	errorString += a.db.Close().Error()
-	errorString += a.restServer.Shutdown().Error()
-	errorString += a.eventSender.Close().Error()
+// closeWithin closes closer unless ctx ends first, nil closers are skipped.
+func closeWithin(ctx context.Context, name string, closer io.Closer) error {
+	if closer == nil {
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- closer.Close() }()
 
-	return errors.New(errorString)
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", name, ctx.Err())
+	}
 }
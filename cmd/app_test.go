@@ -0,0 +1,84 @@
+package main
+
+import (
+	"companies/cmd/internal/health"
+	"companies/cmd/tests/mocks"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+func TestApp_ShutdownOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	var order []string
+	record := func(name string, err error) closerFunc {
+		return func() error {
+			order = append(order, name)
+			return err
+		}
+	}
+
+	checker := health.NewChecker(0)
+	restServer := mocks.NewMockRESTServer(ctrl)
+	restServer.EXPECT().Shutdown(gomock.Any()).DoAndReturn(func(context.Context) error {
+		assert.True(t, checker.Draining(), "readiness must fail before the server stops")
+		order = append(order, "http")
+		return nil
+	})
+
+	a := &app{
+		db:          record("db", errors.New("pool busy")),
+		restServer:  restServer,
+		eventSender: record("events", errors.New("2 messages not delivered before close")),
+		relay:       record("relay", nil),
+		dispatcher:  record("dispatcher", nil),
+		tracing:     record("tracing", nil),
+		logger:      slog.New(slog.DiscardHandler),
+		health:      checker,
+	}
+
+	err := a.Shutdown(context.Background())
+
+	assert.Equal(t, []string{"http", "relay", "dispatcher", "events", "db", "tracing"}, order)
+	require.Error(t, err)
+	assert.EqualError(t, err, "event sender: 2 messages not delivered before close\ndatabase: pool busy")
+}
+
+func TestApp_ShutdownDeadline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	restServer := mocks.NewMockRESTServer(ctrl)
+	restServer.EXPECT().Shutdown(gomock.Any()).Return(nil)
+
+	stuck := make(chan struct{})
+	defer close(stuck)
+
+	a := &app{
+		db:         closerFunc(func() error { <-stuck; return nil }),
+		restServer: restServer,
+		logger:     slog.New(slog.DiscardHandler),
+		health:     health.NewChecker(0),
+		drainDelay: time.Hour,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := a.Shutdown(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "database")
+}
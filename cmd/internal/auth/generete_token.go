@@ -1,34 +1,57 @@
 package auth
 
 import (
-	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func GenerateToken(username string) (string, error) {
-	expirationTime := time.Now().Add(1 * time.Hour)
+// AccessTokenTTL is how long an access token is valid, refresh tokens are
+// exchanged for new ones.
+const AccessTokenTTL = time.Hour
+
+// GenerateToken issues an access token for username with roles and the
+// scopes they grant, signed with the current signing key. Its jti identifies
+// it for revocation.
+func GenerateToken(username string, roles []string) (string, error) {
+	now := time.Now()
 	claims := &Claims{
 		Username: username,
+		Roles:    roles,
+		Scopes:   ScopesFor(roles),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	jwtKey := configparser.GetCfgValue("JWT_SECRET", "very-secret-key")
-	return token.SignedString([]byte(jwtKey))
+	key, err := currentKeySet().signingKey(now)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+
+	return token.SignedString(key.private)
 }
 
+// HandleFunc issues an admin token without asking for credentials, the
+// server only routes it in dev mode.
 func HandleFunc(w http.ResponseWriter, r *http.Request) {
-	token, err := GenerateToken("admin")
+	token, err := GenerateToken(AdminUsername, []string{RoleAdmin})
 	if err != nil {
-		log.Println("TOKEN ERROR", err)
-		http.Error(w, "Could not generate token", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Could not generate token", logging.Err(err))
+		problem.Write(w, r, problem.New(http.StatusInternalServerError, problem.TypeBlank, "could not generate token"))
 		return
 	}
 
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWK is a public key in the JSON Web Key format of RFC 7517.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	ID        string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public keys still verifying tokens at now, keys that start
+// signing later included. HMAC keys are secret and left out.
+func (s *KeySet) JWKS(now time.Time) JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		if !key.verifiesAt(now) {
+			continue
+		}
+
+		jwk := JWK{ID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = encodeBase64(public.N.Bytes())
+			jwk.E = encodeBase64(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.KeyType = "EC"
+			jwk.Curve = public.Curve.Params().Name
+			jwk.X = encodeBase64(public.X.FillBytes(make([]byte, size)))
+			jwk.Y = encodeBase64(public.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = encodeBase64(public)
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+// PublicJWKS lists the public keys of the key set in use.
+func PublicJWKS(now time.Time) JWKS {
+	return currentKeySet().JWKS(now)
+}
+
+func encodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// verificationKey turns a published key into one that verifies tokens. The
+// algorithm follows from the key type when alg is missing.
+func (k JWK) verificationKey() (Key, error) {
+	key := Key{ID: k.ID}
+
+	switch {
+	case k.KeyType == "RSA" && (k.Algorithm == "" || k.Algorithm == jwt.SigningMethodRS256.Alg()):
+		n, err := decodeBase64(k.N)
+		if err != nil {
+			return Key{}, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBase64(k.E)
+		if err != nil {
+			return Key{}, fmt.Errorf("e: %w", err)
+		}
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if public.N.BitLen() < minRSABits || public.E < 3 {
+			return Key{}, errors.New("RSA key is too weak")
+		}
+		key.Method, key.public = jwt.SigningMethodRS256, public
+
+	case k.KeyType == "EC" && k.Curve == "P-256" && (k.Algorithm == "" || k.Algorithm == jwt.SigningMethodES256.Alg()):
+		x, err := decodeBase64(k.X)
+		if err != nil {
+			return Key{}, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBase64(k.Y)
+		if err != nil {
+			return Key{}, fmt.Errorf("y: %w", err)
+		}
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if _, err := public.ECDH(); err != nil {
+			return Key{}, err
+		}
+		key.Method, key.public = jwt.SigningMethodES256, public
+
+	case k.KeyType == "OKP" && k.Curve == "Ed25519" && (k.Algorithm == "" || k.Algorithm == jwt.SigningMethodEdDSA.Alg()):
+		x, err := decodeBase64(k.X)
+		if err != nil {
+			return Key{}, fmt.Errorf("x: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return Key{}, errors.New("Ed25519 key has the wrong size")
+		}
+		key.Method, key.public = jwt.SigningMethodEdDSA, ed25519.PublicKey(x)
+
+	default:
+		return Key{}, fmt.Errorf("unsupported key type %q with algorithm %q", k.KeyType, k.Algorithm)
+	}
+
+	return key, nil
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -1,30 +1,109 @@
 package auth
 
 import (
-	configparser "companies/cmd/internal/configParser"
-	"companies/cmd/internal/consts"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// AdminUsername is the user the dev mode token endpoint issues tokens for.
+const AdminUsername = "admin"
+
+// Claims are what a token says about its holder. Handlers read them with
+// ClaimsFromContext.
 type Claims struct {
-	Username string `json:"username"`
+	Username string   `json:"username"`
+	Roles    []string `json:"roles,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func validateToken(tokenStr string) (*Claims, error) {
-	claims := &Claims{}
-	jwtKey := configparser.GetCfgValue("JWT_SECRET", "very-secret-key")
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(jwtKey), nil
-	})
+type claimsKey struct{}
+
+// IsAdmin reports whether the holder may run destructive maintenance such as
+// purging soft-deleted companies.
+func (c *Claims) IsAdmin() bool {
+	return c.HasScope(ScopeAdmin)
+}
+
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// Authenticate returns the caller's claims on routes that don't require a
+// token, it prefers the claims stored by JWTMiddleware.
+func Authenticate(r *http.Request) (*Claims, bool) {
+	if claims, ok := ClaimsFromContext(r.Context()); ok {
+		return claims, true
+	}
 
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, false
+	}
+
+	claims, err := validateToken(r.Context(), strings.TrimPrefix(authHeader, "Bearer "))
+	if err != nil {
+		return nil, false
+	}
+
+	logging.AddAttrs(r.Context(), slog.String(logging.KeyUser, claims.Username))
+	return claims, true
+}
+
+var (
+	errInvalidToken = errors.New("invalid or expired token")
+	errRevokedToken = errors.New("token has been revoked")
+)
+
+// validateToken checks the signature of tokenStr against the key named by its
+// kid, its expiry and, when a revocation list is set, that it wasn't revoked.
+// Tokens of the OIDC issuer, when one is set, are checked against its keys.
+// Errors other than errInvalidToken and errRevokedToken mean the revocation
+// list or the issuer's keys couldn't be consulted.
+func validateToken(ctx context.Context, tokenStr string) (*Claims, error) {
+	claims, err := verifyToken(ctx, tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens issued before revocation existed have no id, they expire soon.
+	if list := revocations.Load(); list != nil && claims.ID != "" {
+		var expiresAt time.Time
+		if claims.ExpiresAt != nil {
+			expiresAt = claims.ExpiresAt.Time
+		}
+
+		revoked, err := list.IsRevoked(ctx, claims.ID, expiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("check token revocation: %w", err)
+		}
+		if revoked {
+			return nil, errRevokedToken
+		}
+	}
+
+	return claims, nil
+}
+
+func verifyToken(ctx context.Context, tokenStr string) (*Claims, error) {
+	if verifier := oidcVerifier.Load(); verifier != nil && verifier.issued(tokenStr) {
+		return verifier.Verify(ctx, tokenStr)
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, currentKeySet().keyFunc(time.Now()))
 	if err != nil || !token.Valid {
-		return nil, errors.New("invalid or expired token")
+		return nil, errInvalidToken
 	}
 
 	return claims, nil
@@ -32,21 +111,26 @@ func validateToken(tokenStr string) (*Claims, error) {
 
 func JWTMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Println(consts.ApplicationPrefix, "JWT middleware")
-
 		authHeader := r.Header.Get("Authorization")
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, "Missing or malformed token", http.StatusUnauthorized)
+			problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.TypeUnauthorized, "missing or malformed token"))
 			return
 		}
 
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-		_, err := validateToken(tokenStr)
-		if err != nil {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+		claims, err := validateToken(r.Context(), tokenStr)
+		switch {
+		case errors.Is(err, errInvalidToken), errors.Is(err, errRevokedToken):
+			problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.TypeUnauthorized, err.Error()))
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "Could not validate token", logging.Err(err))
+			w.Header().Set("Retry-After", "1")
+			problem.Write(w, r, problem.New(http.StatusServiceUnavailable, problem.TypeUnavailable, "the token can't be checked, try again later"))
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyUser, claims.Username))
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
 	})
 }
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultSecret signs tokens when neither signing keys nor JWT_SECRET are
+// configured. Anyone can forge tokens with it, so it is refused outside dev
+// mode.
+const DefaultSecret = "very-secret-key"
+
+// minRSABits is the smallest RSA key accepted for RS256.
+const minRSABits = 2048
+
+var errNoSigningKey = errors.New("no signing key is active")
+
+// Key signs and verifies tokens. Tokens carry its ID in the kid header, keys
+// without an ID only verify tokens without one.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// ActiveFrom is when the key starts signing, it is published before so
+	// verifiers have it by then.
+	ActiveFrom time.Time
+	// RetireAt is when the key stops signing, zero means never. It verifies
+	// the tokens it signed until they expire.
+	RetireAt time.Time
+
+	private any
+	public  crypto.PublicKey
+}
+
+func (k Key) signsAt(now time.Time) bool {
+	return !now.Before(k.ActiveFrom) && (k.RetireAt.IsZero() || now.Before(k.RetireAt))
+}
+
+func (k Key) verifiesAt(now time.Time) bool {
+	return k.RetireAt.IsZero() || now.Before(k.RetireAt.Add(AccessTokenTTL))
+}
+
+// KeySet holds the keys tokens are signed with and verified against.
+type KeySet struct {
+	keys []Key
+}
+
+// NewKeySet checks that the key IDs are unique. Of the keys active at a time
+// the one activated last signs.
+func NewKeySet(keys ...Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key.ID] {
+			return nil, fmt.Errorf("signing key id %q is used twice", key.ID)
+		}
+		seen[key.ID] = true
+	}
+
+	sorted := slices.Clone(keys)
+	slices.SortStableFunc(sorted, func(a, b Key) int { return a.ActiveFrom.Compare(b.ActiveFrom) })
+
+	return &KeySet{keys: sorted}, nil
+}
+
+// NewHMACKeySet signs HS256 tokens without a kid with secret, every verifier
+// needs the secret.
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{keys: []Key{{Method: jwt.SigningMethodHS256, private: []byte(secret), public: []byte(secret)}}}
+}
+
+// signingKey returns the key activated last among those active at now.
+func (s *KeySet) signingKey(now time.Time) (Key, error) {
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if s.keys[i].signsAt(now) {
+			return s.keys[i], nil
+		}
+	}
+
+	return Key{}, errNoSigningKey
+}
+
+// keyFunc finds the verification key by the kid header and refuses tokens
+// signed with another algorithm than the key's.
+func (s *KeySet) keyFunc(now time.Time) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		for _, key := range s.keys {
+			if key.ID != kid || !key.verifiesAt(now) {
+				continue
+			}
+
+			if token.Method.Alg() != key.Method.Alg() {
+				return nil, fmt.Errorf("key %q doesn't sign %s tokens", kid, token.Method.Alg())
+			}
+			return key.public, nil
+		}
+
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+}
+
+var keySet atomic.Pointer[KeySet]
+
+// SetKeySet makes tokens signed and verified with s, nil falls back to HS256
+// with JWT_SECRET.
+func SetKeySet(s *KeySet) {
+	keySet.Store(s)
+}
+
+func currentKeySet() *KeySet {
+	if s := keySet.Load(); s != nil {
+		return s
+	}
+
+	return NewHMACKeySet(configparser.GetCfgValue("JWT_SECRET", DefaultSecret))
+}
+
+// LoadKeySet loads the configured signing keys. Without any, tokens are
+// signed with JWT_SECRET, which has to be set unless devMode is on.
+func LoadKeySet(config configparser.Auth, devMode bool) (*KeySet, error) {
+	if len(config.SigningKeys) == 0 {
+		secret := configparser.GetCfgValue("JWT_SECRET", DefaultSecret)
+		if secret == DefaultSecret && !devMode {
+			return nil, errors.New("JWT_SECRET is the public default, set it or configure signing keys")
+		}
+		return NewHMACKeySet(secret), nil
+	}
+
+	keys := make([]Key, 0, len(config.SigningKeys))
+	for _, keyConfig := range config.SigningKeys {
+		key, err := LoadKey(keyConfig)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %q: %w", keyConfig.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeySet(keys...)
+}
+
+// LoadKey reads the PEM encoded private key of config. RS256 takes RSA keys
+// of at least 2048 bits, ES256 P-256 keys and EdDSA Ed25519 keys.
+func LoadKey(config configparser.SigningKey) (Key, error) {
+	if config.ID == "" {
+		return Key{}, errors.New("id is required")
+	}
+
+	key := Key{ID: config.ID}
+
+	var err error
+	if key.ActiveFrom, err = parseKeyTime(config.ActiveFrom); err != nil {
+		return Key{}, fmt.Errorf("active_from: %w", err)
+	}
+	if key.RetireAt, err = parseKeyTime(config.RetireAt); err != nil {
+		return Key{}, fmt.Errorf("retire_at: %w", err)
+	}
+	if !key.RetireAt.IsZero() && !key.RetireAt.After(key.ActiveFrom) {
+		return Key{}, errors.New("retire_at must be after active_from")
+	}
+
+	pemBytes, err := os.ReadFile(config.PrivateKeyFile)
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch config.Algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return Key{}, err
+		}
+		if private.N.BitLen() < minRSABits {
+			return Key{}, fmt.Errorf("RSA key has %d bits, at least %d are required", private.N.BitLen(), minRSABits)
+		}
+		key.Method, key.private, key.public = jwt.SigningMethodRS256, private, &private.PublicKey
+
+	case jwt.SigningMethodES256.Alg():
+		private, err := jwt.ParseECPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return Key{}, err
+		}
+		if private.Curve != elliptic.P256() {
+			return Key{}, errors.New("ES256 requires a P-256 key")
+		}
+		key.Method, key.private, key.public = jwt.SigningMethodES256, private, &private.PublicKey
+
+	case jwt.SigningMethodEdDSA.Alg():
+		parsed, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return Key{}, err
+		}
+		private, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return Key{}, errors.New("EdDSA requires an Ed25519 key")
+		}
+		key.Method, key.private, key.public = jwt.SigningMethodEdDSA, private, private.Public()
+
+	default:
+		return Key{}, fmt.Errorf("unsupported algorithm %q, use RS256, ES256 or EdDSA", config.Algorithm)
+	}
+
+	return key, nil
+}
+
+// parseKeyTime parses an RFC 3339 time, empty is the zero time.
+func parseKeyTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
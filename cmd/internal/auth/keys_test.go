@@ -0,0 +1,230 @@
+package auth
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyFile stores private as a PKCS #8 PEM file and returns its path.
+func writeKeyFile(t *testing.T, private any) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	return path
+}
+
+func loadTestKey(t *testing.T, id, algorithm string, private any) Key {
+	t.Helper()
+
+	key, err := LoadKey(configparser.SigningKey{ID: id, Algorithm: algorithm, PrivateKeyFile: writeKeyFile(t, private)})
+	require.NoError(t, err)
+	return key
+}
+
+func useKeySet(t *testing.T, keys ...Key) {
+	t.Helper()
+
+	set, err := NewKeySet(keys...)
+	require.NoError(t, err)
+
+	SetKeySet(set)
+	t.Cleanup(func() { SetKeySet(nil) })
+}
+
+func TestLoadKey_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		algorithm string
+		private   any
+	}{
+		{"RS256", rsaKey},
+		{"ES256", ecKey},
+		{"EdDSA", edKey},
+	} {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			useKeySet(t, loadTestKey(t, "k-"+tc.algorithm, tc.algorithm, tc.private))
+
+			token, err := GenerateToken("jane", []string{RoleViewer})
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.algorithm, parsed.Method.Alg())
+			assert.Equal(t, "k-"+tc.algorithm, parsed.Header["kid"])
+
+			claims, err := validateToken(context.Background(), token)
+			require.NoError(t, err)
+			assert.Equal(t, "jane", claims.Username)
+		})
+	}
+}
+
+func TestLoadKey_Invalid(t *testing.T) {
+	smallRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		config configparser.SigningKey
+		err    string
+	}{
+		"missing id":       {configparser.SigningKey{Algorithm: "ES256"}, "id is required"},
+		"small RSA key":    {configparser.SigningKey{ID: "a", Algorithm: "RS256", PrivateKeyFile: writeKeyFile(t, smallRSA)}, "at least 2048"},
+		"wrong curve":      {configparser.SigningKey{ID: "a", Algorithm: "ES256", PrivateKeyFile: writeKeyFile(t, p384)}, "P-256"},
+		"wrong key type":   {configparser.SigningKey{ID: "a", Algorithm: "EdDSA", PrivateKeyFile: writeKeyFile(t, p384)}, "Ed25519"},
+		"HMAC":             {configparser.SigningKey{ID: "a", Algorithm: "HS256", PrivateKeyFile: writeKeyFile(t, p384)}, "unsupported algorithm"},
+		"missing file":     {configparser.SigningKey{ID: "a", Algorithm: "ES256", PrivateKeyFile: filepath.Join(t.TempDir(), "none.pem")}, "no such file"},
+		"bad time":         {configparser.SigningKey{ID: "a", Algorithm: "ES256", ActiveFrom: "tomorrow"}, "active_from"},
+		"retired at start": {configparser.SigningKey{ID: "a", Algorithm: "ES256", ActiveFrom: "2026-01-01T00:00:00Z", RetireAt: "2026-01-01T00:00:00Z"}, "retire_at must be after"},
+	} {
+		_, err := LoadKey(tc.config)
+		assert.ErrorContains(t, err, tc.err, name)
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rotation := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	old := loadTestKey(t, "old", "EdDSA", oldKey)
+	old.RetireAt = rotation
+	next := loadTestKey(t, "new", "EdDSA", newKey)
+	next.ActiveFrom = rotation
+
+	set, err := NewKeySet(next, old)
+	require.NoError(t, err)
+
+	key, err := set.signingKey(rotation.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "old", key.ID)
+
+	key, err = set.signingKey(rotation)
+	require.NoError(t, err)
+	assert.Equal(t, "new", key.ID)
+
+	// The new key is published before it signs, the old one until the
+	// tokens it signed expire.
+	ids := func(now time.Time) []string {
+		var ids []string
+		for _, jwk := range set.JWKS(now).Keys {
+			ids = append(ids, jwk.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"old", "new"}, ids(rotation.Add(-time.Hour)))
+	assert.Equal(t, []string{"old", "new"}, ids(rotation.Add(AccessTokenTTL-time.Second)))
+	assert.Equal(t, []string{"new"}, ids(rotation.Add(AccessTokenTTL)))
+}
+
+func TestKeySet_NoActiveKey(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	key := loadTestKey(t, "later", "EdDSA", private)
+	key.ActiveFrom = time.Now().Add(time.Hour)
+	useKeySet(t, key)
+
+	_, err = GenerateToken("jane", nil)
+	assert.ErrorIs(t, err, errNoSigningKey)
+}
+
+func TestKeySet_DuplicateID(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	key := loadTestKey(t, "same", "EdDSA", private)
+	_, err = NewKeySet(key, key)
+	assert.ErrorContains(t, err, "used twice")
+}
+
+func TestValidateToken_RefusesOtherKeys(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	useKeySet(t, loadTestKey(t, "ec", "ES256", ecKey))
+
+	claims := &Claims{Username: "mallory", RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}
+
+	// An HS256 token naming the EC key, signed with the default secret.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "ec"
+	token, err := forged.SignedString([]byte(DefaultSecret))
+	require.NoError(t, err)
+
+	_, err = validateToken(context.Background(), token)
+	assert.ErrorIs(t, err, errInvalidToken)
+
+	// A token without kid isn't checked against the shared secret anymore.
+	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(DefaultSecret))
+	require.NoError(t, err)
+
+	_, err = validateToken(context.Background(), token)
+	assert.ErrorIs(t, err, errInvalidToken)
+}
+
+func TestLoadKeySet_DefaultSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+
+	_, err := LoadKeySet(configparser.Auth{}, false)
+	assert.ErrorContains(t, err, "public default")
+
+	set, err := LoadKeySet(configparser.Auth{}, true)
+	require.NoError(t, err)
+	assert.Empty(t, set.JWKS(time.Now()).Keys)
+
+	t.Setenv("JWT_SECRET", "a-long-random-production-secret")
+	_, err = LoadKeySet(configparser.Auth{}, false)
+	assert.NoError(t, err)
+}
+
+func TestJWKS_Encoding(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	set, err := NewKeySet(loadTestKey(t, "rsa", "RS256", rsaKey), loadTestKey(t, "ec", "ES256", ecKey), loadTestKey(t, "ed", "EdDSA", edKey))
+	require.NoError(t, err)
+
+	keys := set.JWKS(time.Now()).Keys
+	require.Len(t, keys, 3)
+
+	assert.Equal(t, JWK{KeyType: "RSA", ID: "rsa", Use: "sig", Algorithm: "RS256", N: encodeBase64(rsaKey.N.Bytes()), E: "AQAB"}, keys[0])
+
+	assert.Equal(t, "EC", keys[1].KeyType)
+	assert.Equal(t, "P-256", keys[1].Curve)
+	assert.Len(t, keys[1].X, 43)
+	assert.Len(t, keys[1].Y, 43)
+
+	assert.Equal(t, JWK{KeyType: "OKP", ID: "ed", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: encodeBase64(edPublic)}, keys[2])
+}
@@ -0,0 +1,341 @@
+package auth
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/logging"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultUsernameClaim         = "preferred_username"
+	defaultJWKSRefreshSeconds    = 900
+	defaultClockSkewSeconds      = 30
+	defaultRequestTimeoutSeconds = 10
+
+	// minJWKSRefetch bounds how often an unknown kid makes the keys be
+	// fetched again, so forged tokens can't flood the issuer.
+	minJWKSRefetch = 10 * time.Second
+	// maxOIDCResponseBytes bounds the discovery document and the JWKS.
+	maxOIDCResponseBytes = 1 << 20
+)
+
+// errKeysUnavailable means the issuer's keys couldn't be fetched, tokens are
+// neither accepted nor refused then.
+var errKeysUnavailable = errors.New("issuer keys are unavailable")
+
+// OIDCVerifier validates tokens of an external OpenID Connect issuer. The
+// issuer's keys are found through its discovery document and cached, they
+// are fetched again periodically and when a token names an unknown key.
+type OIDCVerifier struct {
+	issuer        string
+	audience      string
+	usernameClaim string
+	rolesClaim    string
+	roleMapping   map[string]string
+	refresh       time.Duration
+	clockSkew     time.Duration
+	client        *http.Client
+	logger        *slog.Logger
+
+	// mu serializes fetches, concurrent requests wait for the one running.
+	mu          sync.Mutex
+	jwksURI     string
+	keys        *KeySet
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	fetchErr    error
+}
+
+// NewOIDCVerifier checks config, the issuer is first contacted when a token
+// of it arrives.
+func NewOIDCVerifier(config configparser.OIDC, logger *slog.Logger) (*OIDCVerifier, error) {
+	if config.Issuer == "" {
+		return nil, errors.New("issuer is required")
+	}
+	if config.Audience == "" {
+		return nil, errors.New("audience is required, otherwise tokens for any client of the issuer are accepted")
+	}
+
+	roles := make([]string, 0, len(config.RoleMapping))
+	for _, role := range config.RoleMapping {
+		roles = append(roles, role)
+	}
+	if err := ValidateRoles(roles); err != nil {
+		return nil, fmt.Errorf("role_mapping: %w", err)
+	}
+
+	usernameClaim := config.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = defaultUsernameClaim
+	}
+
+	return &OIDCVerifier{
+		issuer:        config.Issuer,
+		audience:      config.Audience,
+		usernameClaim: usernameClaim,
+		rolesClaim:    config.RolesClaim,
+		roleMapping:   config.RoleMapping,
+		refresh:       secondsOr(config.JWKSRefreshSeconds, defaultJWKSRefreshSeconds),
+		clockSkew:     secondsOr(config.ClockSkewSeconds, defaultClockSkewSeconds),
+		client:        &http.Client{Timeout: secondsOr(config.RequestTimeoutSeconds, defaultRequestTimeoutSeconds)},
+		logger:        logger,
+	}, nil
+}
+
+func secondsOr(value, fallback int) time.Duration {
+	if value <= 0 {
+		value = fallback
+	}
+	return time.Duration(value) * time.Second
+}
+
+// issued reports whether tokenStr claims to come from the issuer, the
+// signature isn't checked yet.
+func (v *OIDCVerifier) issued(tokenStr string) bool {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenStr, claims); err != nil {
+		return false
+	}
+
+	issuer, _ := claims.GetIssuer()
+	return issuer == v.issuer
+}
+
+// Verify checks the signature, iss, aud, exp and nbf of tokenStr and maps
+// its claims. Invalid tokens are errInvalidToken, other errors mean the
+// issuer's keys couldn't be fetched.
+func (v *OIDCVerifier) Verify(ctx context.Context, tokenStr string) (*Claims, error) {
+	mapClaims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, mapClaims, v.keyFunc(ctx),
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.clockSkew),
+	)
+	switch {
+	case errors.Is(err, errKeysUnavailable):
+		return nil, err
+	case err != nil:
+		v.logger.InfoContext(ctx, "Invalid OIDC token", logging.Err(err))
+		return nil, errInvalidToken
+	}
+
+	claims, err := v.mapClaims(mapClaims)
+	if err != nil {
+		v.logger.InfoContext(ctx, "Unusable OIDC token", logging.Err(err))
+		return nil, errInvalidToken
+	}
+
+	return claims, nil
+}
+
+// mapClaims turns the issuer's claims into the service's. Permissions only
+// come from the mapped roles, the issuer's scope claim is ignored: it names
+// what the client asked for, not what role_mapping grants.
+func (v *OIDCVerifier) mapClaims(mapClaims jwt.MapClaims) (*Claims, error) {
+	username, _ := mapClaims[v.usernameClaim].(string)
+	if username == "" && v.usernameClaim == defaultUsernameClaim {
+		username, _ = mapClaims["sub"].(string)
+	}
+	if username == "" {
+		return nil, fmt.Errorf("the %s claim is missing", v.usernameClaim)
+	}
+
+	claims := &Claims{Username: username}
+
+	if v.rolesClaim != "" {
+		for _, value := range claimStrings(lookupClaim(mapClaims, v.rolesClaim)) {
+			if role, ok := v.roleMapping[value]; ok && !slices.Contains(claims.Roles, role) {
+				claims.Roles = append(claims.Roles, role)
+			}
+		}
+	}
+
+	claims.ID, _ = mapClaims["jti"].(string)
+	claims.Subject, _ = mapClaims.GetSubject()
+	claims.Issuer, _ = mapClaims.GetIssuer()
+	claims.Audience, _ = mapClaims.GetAudience()
+	claims.ExpiresAt, _ = mapClaims.GetExpirationTime()
+	claims.NotBefore, _ = mapClaims.GetNotBefore()
+	claims.IssuedAt, _ = mapClaims.GetIssuedAt()
+
+	return claims, nil
+}
+
+// lookupClaim follows a dotted path such as realm_access.roles.
+func lookupClaim(claims map[string]any, path string) any {
+	var value any = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+// claimStrings reads a claim that is a list of strings or a single one.
+func claimStrings(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func (v *OIDCVerifier) keyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		keys, err := v.keySet(ctx, false)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := keys.keyFunc(time.Now())(token)
+		if err == nil {
+			return key, nil
+		}
+
+		// The issuer may have rotated in a key after the last fetch.
+		keys, err = v.keySet(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+
+		return keys.keyFunc(time.Now())(token)
+	}
+}
+
+// keySet returns the cached keys, fetching them when they are older than the
+// refresh interval or when refetch is set. Fetches are at least
+// minJWKSRefetch apart and failed ones keep the cached keys in use.
+func (v *OIDCVerifier) keySet(ctx context.Context, refetch bool) (*KeySet, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.keys != nil && !refetch && time.Since(v.fetchedAt) < v.refresh {
+		return v.keys, nil
+	}
+
+	if time.Since(v.attemptedAt) < minJWKSRefetch {
+		if v.keys != nil {
+			return v.keys, nil
+		}
+		return nil, fmt.Errorf("%w: %w", errKeysUnavailable, v.fetchErr)
+	}
+
+	v.attemptedAt = time.Now()
+	keys, err := v.fetchKeys(ctx)
+	v.fetchErr = err
+	if err != nil {
+		if v.keys != nil {
+			v.logger.WarnContext(ctx, "Failed to refresh OIDC keys, keeping the cached ones", slog.String("issuer", v.issuer), logging.Err(err))
+			return v.keys, nil
+		}
+		return nil, fmt.Errorf("%w: %w", errKeysUnavailable, err)
+	}
+
+	v.keys, v.fetchedAt = keys, v.attemptedAt
+	v.logger.InfoContext(ctx, "Fetched OIDC keys", slog.String("issuer", v.issuer), slog.Int("keys", len(keys.keys)))
+	return keys, nil
+}
+
+type discoveryDocument struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// fetchKeys reads the discovery document once, then the JWKS it points to.
+func (v *OIDCVerifier) fetchKeys(ctx context.Context) (*KeySet, error) {
+	if v.jwksURI == "" {
+		var doc discoveryDocument
+		if err := v.getJSON(ctx, strings.TrimSuffix(v.issuer, "/")+"/.well-known/openid-configuration", &doc); err != nil {
+			return nil, fmt.Errorf("discovery: %w", err)
+		}
+		if doc.Issuer != v.issuer {
+			return nil, fmt.Errorf("discovery: the document is for issuer %q", doc.Issuer)
+		}
+		if doc.JWKSURI == "" {
+			return nil, errors.New("discovery: jwks_uri is missing")
+		}
+		v.jwksURI = doc.JWKSURI
+	}
+
+	var set JWKS
+	if err := v.getJSON(ctx, v.jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	keys := make([]Key, 0, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.verificationKey()
+		if err != nil {
+			v.logger.WarnContext(ctx, "Skipping OIDC key", slog.String("kid", jwk.ID), logging.Err(err))
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks: no usable signing keys")
+	}
+
+	return NewKeySet(keys...)
+}
+
+func (v *OIDCVerifier) getJSON(ctx context.Context, url string, target any) error {
+	// The fetch serves every waiting request, so the caller's cancellation
+	// doesn't abort it, the client timeout bounds it.
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxOIDCResponseBytes)).Decode(target)
+}
+
+var oidcVerifier atomic.Pointer[OIDCVerifier]
+
+// SetOIDCVerifier makes tokens of the verifier's issuer valid, nil accepts
+// only the service's own tokens.
+func SetOIDCVerifier(v *OIDCVerifier) {
+	oidcVerifier.Store(v)
+}
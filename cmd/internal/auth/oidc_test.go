@@ -0,0 +1,325 @@
+package auth
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAudience = "companies-api"
+
+// testIssuer is a stand-in OpenID Connect provider serving discovery and a
+// JWKS of the keys it signs with.
+type testIssuer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	keys        map[string]*ecdsa.PrivateKey
+	jwksCalls   int
+	unavailable bool
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	issuer := &testIssuer{keys: map[string]*ecdsa.PrivateKey{}}
+	issuer.addKey(t, "key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discoveryDocument{Issuer: issuer.URL, JWKSURI: issuer.URL + "/keys"})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		defer issuer.mu.Unlock()
+
+		issuer.jwksCalls++
+		if issuer.unavailable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var set JWKS
+		for kid, private := range issuer.keys {
+			set.Keys = append(set.Keys, JWK{
+				KeyType: "EC", ID: kid, Use: "sig", Algorithm: "ES256", Curve: "P-256",
+				X: encodeBase64(private.X.FillBytes(make([]byte, 32))),
+				Y: encodeBase64(private.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+		json.NewEncoder(w).Encode(set)
+	})
+
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func (i *testIssuer) addKey(t *testing.T, kid string) {
+	t.Helper()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.keys[kid] = private
+}
+
+func (i *testIssuer) calls() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.jwksCalls
+}
+
+func (i *testIssuer) setUnavailable(unavailable bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.unavailable = unavailable
+}
+
+// token signs claims with the key kid, iss, aud and exp are filled in
+// unless claims set them.
+func (i *testIssuer) token(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	all := jwt.MapClaims{
+		"iss":                i.URL,
+		"aud":                testAudience,
+		"sub":                "7f3c2a",
+		"preferred_username": "jane",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		all[name] = value
+	}
+
+	i.mu.Lock()
+	private := i.keys[kid]
+	i.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, all)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(private)
+	require.NoError(t, err)
+	return signed
+}
+
+func newTestVerifier(t *testing.T, issuer *testIssuer) *OIDCVerifier {
+	t.Helper()
+
+	verifier, err := NewOIDCVerifier(configparser.OIDC{
+		Issuer:      issuer.URL,
+		Audience:    testAudience,
+		RolesClaim:  "realm_access.roles",
+		RoleMapping: map[string]string{"companies-readers": RoleViewer, "companies-admins": RoleAdmin},
+	}, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	return verifier
+}
+
+func TestOIDCVerifier_MapsClaims(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t, issuer)
+
+	token := issuer.token(t, "key-1", jwt.MapClaims{
+		"jti":          "abc",
+		"realm_access": map[string]any{"roles": []string{"companies-readers", "offline_access"}},
+		"scope":        "openid companies:write",
+	})
+
+	claims, err := verifier.Verify(context.Background(), token)
+	require.NoError(t, err)
+
+	assert.Equal(t, "jane", claims.Username)
+	assert.Equal(t, []string{RoleViewer}, claims.Roles)
+	assert.Empty(t, claims.Scopes)
+	assert.Equal(t, "abc", claims.ID)
+	assert.True(t, claims.HasScope(ScopeCompaniesRead))
+	assert.False(t, claims.HasScope(ScopeCompaniesWrite))
+	assert.False(t, claims.HasScope(ScopeCompaniesDelete))
+}
+
+func TestOIDCVerifier_IgnoresUnmappedScopes(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t, issuer)
+
+	// Any client of the issuer may ask for a scope named admin.
+	token := issuer.token(t, "key-1", jwt.MapClaims{"scope": "openid admin companies:delete"})
+
+	claims, err := verifier.Verify(context.Background(), token)
+	require.NoError(t, err)
+
+	assert.Empty(t, claims.Roles)
+	assert.Empty(t, claims.Scopes)
+	assert.False(t, claims.HasScope(ScopeAdmin))
+	assert.False(t, claims.HasScope(ScopeCompaniesDelete))
+}
+
+func TestOIDCVerifier_UsernameFallsBackToSubject(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t, issuer)
+
+	claims, err := verifier.Verify(context.Background(), issuer.token(t, "key-1", jwt.MapClaims{"preferred_username": nil}))
+	require.NoError(t, err)
+	assert.Equal(t, "7f3c2a", claims.Username)
+}
+
+func TestOIDCVerifier_RefusesInvalidClaims(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t, issuer)
+
+	for name, claims := range map[string]jwt.MapClaims{
+		"other audience": {"aud": "another-client"},
+		"expired":        {"exp": time.Now().Add(-time.Hour).Unix()},
+		"no expiry":      {"exp": nil},
+		"not yet valid":  {"nbf": time.Now().Add(time.Hour).Unix()},
+	} {
+		_, err := verifier.Verify(context.Background(), issuer.token(t, "key-1", claims))
+		assert.ErrorIs(t, err, errInvalidToken, name)
+	}
+
+	// Within the tolerated clock skew.
+	_, err := verifier.Verify(context.Background(), issuer.token(t, "key-1", jwt.MapClaims{"nbf": time.Now().Add(10 * time.Second).Unix()}))
+	assert.NoError(t, err)
+}
+
+func TestOIDCVerifier_RefusesForeignSignature(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t, issuer)
+
+	// Another provider signing with the same kid.
+	impostor := newTestIssuer(t)
+	impostor.URL = issuer.URL
+
+	_, err := verifier.Verify(context.Background(), impostor.token(t, "key-1", nil))
+	assert.ErrorIs(t, err, errInvalidToken)
+}
+
+func TestOIDCVerifier_CachesKeys(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t, issuer)
+
+	for range 3 {
+		_, err := verifier.Verify(context.Background(), issuer.token(t, "key-1", nil))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, issuer.calls())
+}
+
+func TestOIDCVerifier_FetchesRotatedKey(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t, issuer)
+
+	_, err := verifier.Verify(context.Background(), issuer.token(t, "key-1", nil))
+	require.NoError(t, err)
+
+	// Fetches of unknown keys are rate limited, pretend the last one is old.
+	issuer.addKey(t, "key-2")
+	verifier.attemptedAt = time.Now().Add(-minJWKSRefetch)
+
+	_, err = verifier.Verify(context.Background(), issuer.token(t, "key-2", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 2, issuer.calls())
+
+	// Right after, unknown keys don't reach the issuer.
+	issuer.addKey(t, "key-3")
+	_, err = verifier.Verify(context.Background(), issuer.token(t, "key-3", nil))
+	assert.ErrorIs(t, err, errInvalidToken)
+	assert.Equal(t, 2, issuer.calls())
+}
+
+func TestOIDCVerifier_IssuerUnavailable(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t, issuer)
+
+	issuer.setUnavailable(true)
+	_, err := verifier.Verify(context.Background(), issuer.token(t, "key-1", nil))
+	assert.ErrorIs(t, err, errKeysUnavailable)
+
+	// Once fetched, keys outlive an outage of the issuer.
+	issuer.setUnavailable(false)
+	verifier.attemptedAt = time.Time{}
+	_, err = verifier.Verify(context.Background(), issuer.token(t, "key-1", nil))
+	require.NoError(t, err)
+
+	issuer.setUnavailable(true)
+	verifier.fetchedAt, verifier.attemptedAt = time.Time{}, time.Time{}
+	_, err = verifier.Verify(context.Background(), issuer.token(t, "key-1", nil))
+	assert.NoError(t, err)
+}
+
+func TestOIDCVerifier_DiscoveryIssuerMismatch(t *testing.T) {
+	issuer := newTestIssuer(t)
+
+	verifier, err := NewOIDCVerifier(configparser.OIDC{Issuer: issuer.URL + "/", Audience: testAudience}, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(context.Background(), issuer.token(t, "key-1", jwt.MapClaims{"iss": issuer.URL + "/"}))
+	assert.ErrorIs(t, err, errKeysUnavailable)
+	assert.ErrorContains(t, err, "the document is for issuer")
+}
+
+func TestNewOIDCVerifier_Invalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config configparser.OIDC
+		err    string
+	}{
+		"no issuer":    {configparser.OIDC{Audience: testAudience}, "issuer is required"},
+		"no audience":  {configparser.OIDC{Issuer: "https://sso.example.com"}, "audience is required"},
+		"unknown role": {configparser.OIDC{Issuer: "https://sso.example.com", Audience: testAudience, RoleMapping: map[string]string{"owners": "owner"}}, "unknown role owner"},
+	} {
+		_, err := NewOIDCVerifier(tc.config, slog.New(slog.DiscardHandler))
+		assert.ErrorContains(t, err, tc.err, name)
+	}
+}
+
+func TestJWTMiddleware_OIDC(t *testing.T) {
+	issuer := newTestIssuer(t)
+	SetOIDCVerifier(newTestVerifier(t, issuer))
+	t.Cleanup(func() { SetOIDCVerifier(nil) })
+
+	var got *Claims
+	handler := JWTMiddleware(RequireScope(ScopeAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ClaimsFromContext(r.Context())
+	})))
+
+	serve := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	admin := issuer.token(t, "key-1", jwt.MapClaims{"realm_access": map[string]any{"roles": []string{"companies-admins"}}})
+	assert.Equal(t, http.StatusOK, serve(admin))
+	assert.Equal(t, "jane", got.Username)
+
+	reader := issuer.token(t, "key-1", jwt.MapClaims{"realm_access": map[string]any{"roles": []string{"companies-readers"}}})
+	assert.Equal(t, http.StatusForbidden, serve(reader))
+
+	// The service's own tokens stay valid next to the issuer's.
+	own, err := GenerateToken("admin", []string{RoleAdmin})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, serve(own))
+
+	// Unreachable keys can't decide, the client should retry.
+	issuer.Close()
+	SetOIDCVerifier(newTestVerifier(t, issuer))
+	assert.Equal(t, http.StatusServiceUnavailable, serve(admin))
+}
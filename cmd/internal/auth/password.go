@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id parameters, the OWASP minimum for a 19 MiB memory budget. They are
+// stored in every hash, so raising them keeps the existing hashes valid.
+const (
+	argonTime    = 2
+	argonMemory  = 19 * 1024
+	argonThreads = 1
+	argonKeyLen  = 32
+	argonSaltLen = 16
+)
+
+var errMalformedHash = errors.New("malformed password hash")
+
+// HashPassword returns the argon2id hash of password in the PHC string
+// format, $argon2id$v=19$m=...,t=...,p=...$salt$key.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argonMemory, argonTime, argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// VerifyPassword reports whether password matches hash. The error is only set
+// when hash can't be parsed.
+func VerifyPassword(hash, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, errMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, errMalformedHash
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, errMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, errMalformedHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, errMalformedHash
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, actual) == 1, nil
+}
+
+// dummyHash is verified against when the user doesn't exist, so unknown
+// usernames take as long to reject as wrong passwords.
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("not a password")
+	return hash
+})
+
+// VerifyNoUser spends the time of a VerifyPassword call and fails.
+func VerifyNoUser(password string) bool {
+	_, _ = VerifyPassword(dummyHash(), password)
+	return false
+}
+
+const (
+	MinPasswordLength = 12
+	// MaxPasswordLength is in bytes, it bounds the work a single login can
+	// cause.
+	MaxPasswordLength = 128
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{3,64}$`)
+
+func ValidateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return errors.New("username must be 3 to 64 letters, digits, dots, dashes or underscores")
+	}
+	return nil
+}
+
+func ValidatePassword(password string) error {
+	if n := utf8.RuneCountInString(password); n < MinPasswordLength || len(password) > MaxPasswordLength {
+		return fmt.Errorf("password must be at least %d characters and at most %d bytes long", MinPasswordLength, MaxPasswordLength)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashPassword_Verifies(t *testing.T) {
+	hash, err := HashPassword("correct horse battery staple")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$"))
+
+	ok, err := VerifyPassword(hash, "correct horse battery staple")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = VerifyPassword(hash, "correct horse battery stapler")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestHashPassword_Salted(t *testing.T) {
+	first, err := HashPassword("password123")
+	require.NoError(t, err)
+	second, err := HashPassword("password123")
+	require.NoError(t, err)
+
+	require.NotEqual(t, first, second)
+}
+
+func TestVerifyPassword_UsesStoredParameters(t *testing.T) {
+	// Hashed with m=8,t=1,p=1 and a 16 byte key.
+	hash := "$argon2id$v=19$m=8,t=1,p=1$c29tZXNhbHRzb21lc2FsdA$iTkvM16WpsgqnDci1zhnHg"
+
+	ok, err := VerifyPassword(hash, "password")
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestVerifyPassword_Malformed(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"plain",
+		"$2a$10$abcdefghijklmnopqrstuv",
+		"$argon2id$v=16$m=8,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=8,t=1,p=1$!!$a2V5",
+		"$argon2id$v=19$m=8,t=1,p=1$c2FsdA$",
+	} {
+		ok, err := VerifyPassword(hash, "password")
+		require.ErrorIs(t, err, errMalformedHash, hash)
+		require.False(t, ok)
+	}
+}
+
+func TestVerifyNoUser(t *testing.T) {
+	require.False(t, VerifyNoUser("password"))
+}
+
+func TestValidateUsername(t *testing.T) {
+	for _, valid := range []string{"admin", "jane.doe", "ops_bot-2"} {
+		require.NoError(t, ValidateUsername(valid), valid)
+	}
+
+	for _, invalid := range []string{"", "ab", "jane doe", "jane@example.com", strings.Repeat("a", 65)} {
+		require.Error(t, ValidateUsername(invalid), invalid)
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	require.NoError(t, ValidatePassword("twelve chars"))
+	require.NoError(t, ValidatePassword(strings.Repeat("x", MaxPasswordLength)))
+
+	require.Error(t, ValidatePassword("short"))
+	require.Error(t, ValidatePassword(strings.Repeat("x", MaxPasswordLength+1)))
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sweepInterval is how often expired entries are dropped from the cache.
+const sweepInterval = time.Minute
+
+// RevocationStore keeps revoked token ids until the tokens expire.
+type RevocationStore interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// RevocationList tells revoked access tokens apart by their jti. Revoked ids
+// are cached until their token expires. Ids found valid are cached for the
+// recheck interval, so a token revoked by another instance is refused after
+// at most that long.
+type RevocationList struct {
+	store   RevocationStore
+	recheck time.Duration
+
+	mu sync.Mutex
+	// revoked maps a revoked jti to its token's expiry.
+	revoked map[string]time.Time
+	// valid maps a jti to when the store has to be asked again.
+	valid     map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewRevocationList(store RevocationStore, recheck time.Duration) *RevocationList {
+	return &RevocationList{
+		store:   store,
+		recheck: recheck,
+		revoked: map[string]time.Time{},
+		valid:   map[string]time.Time{},
+	}
+}
+
+// Revoke refuses the token with id jti from now on. until is the token's
+// expiry, the revocation is forgotten after it.
+func (l *RevocationList) Revoke(ctx context.Context, jti string, until time.Time) error {
+	if err := l.store.RevokeToken(ctx, jti, until); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.revoked[jti] = until
+	delete(l.valid, jti)
+
+	return nil
+}
+
+// IsRevoked reports whether the token with id jti, expiring at expiresAt, was
+// revoked.
+func (l *RevocationList) IsRevoked(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	l.sweep(now)
+	if _, ok := l.revoked[jti]; ok {
+		l.mu.Unlock()
+		return true, nil
+	}
+	if next, ok := l.valid[jti]; ok && now.Before(next) {
+		l.mu.Unlock()
+		return false, nil
+	}
+	l.mu.Unlock()
+
+	revoked, err := l.store.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if revoked {
+		l.revoked[jti] = expiresAt
+		return true, nil
+	}
+
+	next := now.Add(l.recheck)
+	if expiresAt.Before(next) {
+		next = expiresAt
+	}
+	l.valid[jti] = next
+
+	return false, nil
+}
+
+// sweep drops the entries of expired tokens, l.mu must be held.
+func (l *RevocationList) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for jti, expiresAt := range l.revoked {
+		if !now.Before(expiresAt) {
+			delete(l.revoked, jti)
+		}
+	}
+
+	for jti, next := range l.valid {
+		if !now.Before(next) {
+			delete(l.valid, jti)
+		}
+	}
+}
+
+var revocations atomic.Pointer[RevocationList]
+
+// SetRevocationList makes token validation consult l, nil turns revocation
+// checks off.
+func SetRevocationList(l *RevocationList) {
+	revocations.Store(l)
+}
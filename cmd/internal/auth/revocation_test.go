@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRevocationStore struct {
+	revoked map[string]time.Time
+	lookups int
+	err     error
+}
+
+func newFakeRevocationStore() *fakeRevocationStore {
+	return &fakeRevocationStore{revoked: map[string]time.Time{}}
+}
+
+func (s *fakeRevocationStore) RevokeToken(_ context.Context, jti string, expiresAt time.Time) error {
+	s.revoked[jti] = expiresAt
+	return s.err
+}
+
+func (s *fakeRevocationStore) IsTokenRevoked(_ context.Context, jti string) (bool, error) {
+	s.lookups++
+	_, ok := s.revoked[jti]
+	return ok, s.err
+}
+
+func TestRevocationList_Revoke(t *testing.T) {
+	store := newFakeRevocationStore()
+	list := NewRevocationList(store, time.Minute)
+	ctx := context.Background()
+	exp := time.Now().Add(time.Hour)
+
+	revoked, err := list.IsRevoked(ctx, "a", exp)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, list.Revoke(ctx, "a", exp))
+	assert.Contains(t, store.revoked, "a")
+
+	revoked, err = list.IsRevoked(ctx, "a", exp)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Equal(t, 1, store.lookups, "a revocation made here must not need a lookup")
+}
+
+func TestRevocationList_CachesValidUntilRecheck(t *testing.T) {
+	store := newFakeRevocationStore()
+	list := NewRevocationList(store, time.Hour)
+	ctx := context.Background()
+	exp := time.Now().Add(time.Hour)
+
+	for range 3 {
+		revoked, err := list.IsRevoked(ctx, "a", exp)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	}
+	assert.Equal(t, 1, store.lookups)
+
+	// Revoked elsewhere, seen once the recheck interval has passed.
+	store.revoked["a"] = exp
+	list.valid["a"] = time.Now().Add(-time.Second)
+
+	revoked, err := list.IsRevoked(ctx, "a", exp)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Equal(t, 2, store.lookups)
+}
+
+func TestRevocationList_StoreError(t *testing.T) {
+	store := newFakeRevocationStore()
+	store.err = errors.New("database is unavailable")
+	list := NewRevocationList(store, time.Minute)
+
+	_, err := list.IsRevoked(context.Background(), "a", time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, store.err)
+
+	assert.ErrorIs(t, list.Revoke(context.Background(), "a", time.Now().Add(time.Hour)), store.err)
+	assert.NotContains(t, list.revoked, "a")
+}
+
+func TestRevocationList_Sweep(t *testing.T) {
+	list := NewRevocationList(newFakeRevocationStore(), time.Minute)
+	now := time.Now()
+
+	list.revoked["expired"] = now.Add(-time.Second)
+	list.revoked["live"] = now.Add(time.Hour)
+	list.valid["stale"] = now.Add(-time.Second)
+
+	list.sweep(now)
+
+	assert.Equal(t, []string{"live"}, keys(list.revoked))
+	assert.Empty(t, list.valid)
+}
+
+func keys(m map[string]time.Time) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func TestJWTMiddleware_RevokedToken(t *testing.T) {
+	store := newFakeRevocationStore()
+	SetRevocationList(NewRevocationList(store, time.Minute))
+	t.Cleanup(func() { SetRevocationList(nil) })
+
+	token, err := GenerateToken("jane", []string{RoleViewer})
+	require.NoError(t, err)
+
+	claims := &Claims{}
+	_, _, err = jwt.NewParser().ParseUnverified(token, claims)
+	require.NoError(t, err)
+	require.NotEmpty(t, claims.ID)
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		JWTMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusOK, serve().Code)
+
+	require.NoError(t, revocations.Load().Revoke(context.Background(), claims.ID, claims.ExpiresAt.Time))
+
+	rr := serve()
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "token has been revoked")
+}
+
+func TestJWTMiddleware_RevocationUnavailable(t *testing.T) {
+	store := newFakeRevocationStore()
+	store.err = errors.New("database is unavailable")
+	SetRevocationList(NewRevocationList(store, time.Minute))
+	t.Cleanup(func() { SetRevocationList(nil) })
+
+	token, err := GenerateToken("jane", []string{RoleViewer})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	JWTMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestNewRefreshToken(t *testing.T) {
+	token, hash, err := NewRefreshToken()
+	require.NoError(t, err)
+
+	assert.Len(t, token, 43)
+	assert.Equal(t, HashRefreshToken(token), hash)
+	assert.Len(t, hash, 64)
+
+	other, _, err := NewRefreshToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
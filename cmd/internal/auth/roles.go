@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"companies/cmd/internal/problem"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Scopes a token can carry, routes require one of them.
+const (
+	ScopeCompaniesRead   = "companies:read"
+	ScopeCompaniesWrite  = "companies:write"
+	ScopeCompaniesDelete = "companies:delete"
+	ScopeAdmin           = "admin"
+)
+
+// Roles are given to users, each grants a fixed set of scopes.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var allScopes = []string{ScopeCompaniesRead, ScopeCompaniesWrite, ScopeCompaniesDelete, ScopeAdmin}
+
+var roleScopes = map[string][]string{
+	RoleViewer: {ScopeCompaniesRead},
+	RoleEditor: {ScopeCompaniesRead, ScopeCompaniesWrite, ScopeCompaniesDelete},
+	RoleAdmin:  allScopes,
+}
+
+// Roles lists the known roles from least to most privileged.
+func Roles() []string {
+	return []string{RoleViewer, RoleEditor, RoleAdmin}
+}
+
+func ValidateRoles(roles []string) error {
+	for _, role := range roles {
+		if _, ok := roleScopes[role]; !ok {
+			return fmt.Errorf("unknown role %v, roles must be one of %v", role, strings.Join(Roles(), ", "))
+		}
+	}
+	return nil
+}
+
+// ScopesFor returns the scopes granted by roles, sorted and without
+// duplicates. Unknown roles grant nothing.
+func ScopesFor(roles []string) []string {
+	var scopes []string
+	for _, role := range roles {
+		scopes = append(scopes, roleScopes[role]...)
+	}
+
+	slices.Sort(scopes)
+	return slices.Compact(scopes)
+}
+
+// HasScope reports whether the token grants scope, directly or through one
+// of its roles.
+func (c *Claims) HasScope(scope string) bool {
+	if c == nil {
+		return false
+	}
+
+	if slices.Contains(c.Scopes, scope) {
+		return true
+	}
+
+	for _, role := range c.Roles {
+		if slices.Contains(roleScopes[role], scope) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequireScope refuses requests whose token lacks scope. It reads the claims
+// JWTMiddleware stored, so it has to run after it.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.TypeUnauthorized, "missing or malformed token"))
+				return
+			}
+
+			if !claims.HasScope(scope) {
+				slog.InfoContext(r.Context(), "Scope missing", slog.String("scope", scope))
+				problem.Write(w, r, problem.New(http.StatusForbidden, problem.TypeForbidden, "the token lacks the "+scope+" scope"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopesFor(t *testing.T) {
+	assert.Equal(t, []string{ScopeCompaniesRead}, ScopesFor([]string{RoleViewer}))
+	assert.Equal(t, []string{ScopeAdmin, ScopeCompaniesDelete, ScopeCompaniesRead, ScopeCompaniesWrite}, ScopesFor([]string{RoleViewer, RoleAdmin}))
+	assert.Empty(t, ScopesFor([]string{"owner"}))
+	assert.Empty(t, ScopesFor(nil))
+}
+
+func TestValidateRoles(t *testing.T) {
+	assert.NoError(t, ValidateRoles(nil))
+	assert.NoError(t, ValidateRoles([]string{RoleViewer, RoleEditor, RoleAdmin}))
+	assert.ErrorContains(t, ValidateRoles([]string{RoleViewer, "owner"}), "unknown role owner")
+}
+
+func TestClaims_HasScope(t *testing.T) {
+	viewer := &Claims{Roles: []string{RoleViewer}}
+	assert.True(t, viewer.HasScope(ScopeCompaniesRead))
+	assert.False(t, viewer.HasScope(ScopeCompaniesDelete))
+
+	// Scopes granted directly count even without a role.
+	service := &Claims{Scopes: []string{ScopeCompaniesRead, ScopeCompaniesWrite}}
+	assert.True(t, service.HasScope(ScopeCompaniesWrite))
+	assert.False(t, service.HasScope(ScopeCompaniesDelete))
+
+	admin := &Claims{Roles: []string{RoleAdmin}}
+	assert.True(t, admin.IsAdmin())
+	assert.False(t, viewer.IsAdmin())
+
+	var none *Claims
+	assert.False(t, none.HasScope(ScopeCompaniesRead))
+}
+
+func TestRequireScope(t *testing.T) {
+	handler := JWTMiddleware(RequireScope(ScopeCompaniesDelete)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	serve := func(roles ...string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/", nil)
+		if roles != nil {
+			token, err := GenerateToken("jane", roles)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve())
+	assert.Equal(t, http.StatusForbidden, serve(RoleViewer))
+	assert.Equal(t, http.StatusNoContent, serve(RoleEditor))
+	assert.Equal(t, http.StatusNoContent, serve(RoleAdmin))
+}
+
+func TestRequireScope_WithoutClaims(t *testing.T) {
+	handler := RequireScope(ScopeCompaniesRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -13,28 +13,174 @@ type Rediness struct {
 }
 
 type DB struct {
-	Host     string `yaml:"host"`
-	Port     string `yaml:"port"`
-	Name     string `yaml:"name"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
+	Host           string `yaml:"host"`
+	Port           string `yaml:"port"`
+	Name           string `yaml:"name"`
+	User           string `yaml:"user"`
+	Password       string `yaml:"password"`
+	ReadTimeoutMs  int    `yaml:"read_timeout_ms"`
+	WriteTimeoutMs int    `yaml:"write_timeout_ms"`
 }
 
 type Kafka struct {
-	Broker string `yaml:"broker"`
+	Broker            string `yaml:"broker"`
+	Async             bool   `yaml:"async"`
+	QueueSize         int    `yaml:"queue_size"`
+	BatchSize         int    `yaml:"batch_size"`
+	LingerMs          int    `yaml:"linger_ms"`
+	Acks              string `yaml:"acks"`
+	Retries           int    `yaml:"retries"`
+	RetryBackoffMs    int    `yaml:"retry_backoff_ms"`
+	MaxRetryBackoffMs int    `yaml:"max_retry_backoff_ms"`
+	OnQueueFull       string `yaml:"on_queue_full"`
+	SpillDir          string `yaml:"spill_dir"`
+	PublishTimeoutMs  int    `yaml:"publish_timeout_ms"`
+}
+
+type FileTransport struct {
+	Path string `yaml:"path"`
+}
+
+type WebhookTransport struct {
+	URL       string `yaml:"url"`
+	TimeoutMs int    `yaml:"timeout_ms"`
+}
+
+type MemoryTransport struct {
+	Buffer int `yaml:"buffer"`
+}
+
+type NATSTransport struct {
+	URL       string `yaml:"url"`
+	TimeoutMs int    `yaml:"timeout_ms"`
+}
+
+type Events struct {
+	Transport string           `yaml:"transport"`
+	File      FileTransport    `yaml:"file"`
+	Webhook   WebhookTransport `yaml:"webhook"`
+	Memory    MemoryTransport  `yaml:"memory"`
+	NATS      NATSTransport    `yaml:"nats"`
+}
+
+type Webhooks struct {
+	Workers              int `yaml:"workers"`
+	QueueSize            int `yaml:"queue_size"`
+	TimeoutMs            int `yaml:"timeout_ms"`
+	MaxAttempts          int `yaml:"max_attempts"`
+	RetryBackoffMs       int `yaml:"retry_backoff_ms"`
+	MaxRetryBackoffMs    int `yaml:"max_retry_backoff_ms"`
+	DisableAfterFailures int `yaml:"disable_after_failures"`
+}
+
+type TLS struct {
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file"`
+	ClientAuth   string `yaml:"client_auth"`
+	// ReloadIntervalSeconds is how often the certificate files are checked
+	// for rotation.
+	ReloadIntervalSeconds int `yaml:"reload_interval_seconds"`
 }
 
 type HTTP struct {
-	Addr                string `yaml:"addr"`
-	Port                string `yaml:"port"`
-	ReadTimeoutSeconds  int    `yaml:"read_timeout_seconds"`
-	WriteTimeoutSeconds int    `yaml:"write_timeout_seconds"`
+	Addr                     string `yaml:"addr"`
+	Port                     string `yaml:"port"`
+	ReadTimeoutSeconds       int    `yaml:"read_timeout_seconds"`
+	WriteTimeoutSeconds      int    `yaml:"write_timeout_seconds"`
+	IdleTimeoutSeconds       int    `yaml:"idle_timeout_seconds"`
+	ReadHeaderTimeoutSeconds int    `yaml:"read_header_timeout_seconds"`
+	MaxHeaderBytes           int    `yaml:"max_header_bytes"`
+	MaxBodyBytes             int    `yaml:"max_body_bytes"`
+	TLS                      TLS    `yaml:"tls"`
+}
+
+type Outbox struct {
+	PollIntervalMs int `yaml:"poll_interval_ms"`
+	BatchSize      int `yaml:"batch_size"`
+	RetentionHours int `yaml:"retention_hours"`
+	MaxAttempts    int `yaml:"max_attempts"`
+}
+
+type Tracing struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	Path        string  `yaml:"path"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type Logging struct {
+	Level string `yaml:"level"`
+}
+
+type Health struct {
+	TimeoutMs        int `yaml:"timeout_ms"`
+	MaxOutboxBacklog int `yaml:"max_outbox_backlog"`
+	DrainDelayMs     int `yaml:"drain_delay_ms"`
+}
+
+// SigningKey is a PEM encoded private key access tokens are signed with.
+// ActiveFrom and RetireAt are RFC 3339 times, empty means always.
+type SigningKey struct {
+	ID             string `yaml:"id"`
+	Algorithm      string `yaml:"algorithm"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+	ActiveFrom     string `yaml:"active_from"`
+	RetireAt       string `yaml:"retire_at"`
+}
+
+// OIDC makes tokens of an external OpenID Connect issuer valid. Claims are
+// mapped to the username and roles the service's own tokens carry.
+type OIDC struct {
+	// Issuer enables OIDC, it must match the iss claim exactly.
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// UsernameClaim defaults to preferred_username, falling back to sub.
+	UsernameClaim string `yaml:"username_claim"`
+	// RolesClaim names a claim listing groups or roles, nested claims are
+	// addressed with dots such as realm_access.roles.
+	RolesClaim string `yaml:"roles_claim"`
+	// RoleMapping maps values of RolesClaim to roles, unmapped values grant
+	// nothing.
+	RoleMapping           map[string]string `yaml:"role_mapping"`
+	JWKSRefreshSeconds    int               `yaml:"jwks_refresh_seconds"`
+	ClockSkewSeconds      int               `yaml:"clock_skew_seconds"`
+	RequestTimeoutSeconds int               `yaml:"request_timeout_seconds"`
+}
+
+type Auth struct {
+	// DevMode serves the unauthenticated token endpoint, never enable it
+	// outside development.
+	DevMode                bool `yaml:"dev_mode"`
+	MaxFailedLogins        int  `yaml:"max_failed_logins"`
+	LockoutSeconds         int  `yaml:"lockout_seconds"`
+	RefreshTokenTTLSeconds int  `yaml:"refresh_token_ttl_seconds"`
+	// RevocationRecheckSeconds is how long a token found valid isn't looked
+	// up again, revocations by other instances take up to that long.
+	RevocationRecheckSeconds int `yaml:"revocation_recheck_seconds"`
+	// SigningKeys replace the JWT_SECRET HS256 secret when set.
+	SigningKeys []SigningKey `yaml:"signing_keys"`
+	OIDC        OIDC         `yaml:"oidc"`
+}
+
+type Shutdown struct {
+	TimeoutMs int `yaml:"timeout_ms"`
 }
 
 type Config struct {
-	DB    DB    `yaml:"db"`
-	Kafka Kafka `yaml:"kafka"`
-	HTTP  HTTP  `yaml:"http"`
+	DB       DB       `yaml:"db"`
+	Kafka    Kafka    `yaml:"kafka"`
+	HTTP     HTTP     `yaml:"http"`
+	Outbox   Outbox   `yaml:"outbox"`
+	Events   Events   `yaml:"events"`
+	Webhooks Webhooks `yaml:"webhooks"`
+	Tracing  Tracing  `yaml:"tracing"`
+	Logging  Logging  `yaml:"logging"`
+	Health   Health   `yaml:"health"`
+	Auth     Auth     `yaml:"auth"`
+	Shutdown Shutdown `yaml:"shutdown"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -53,7 +199,7 @@ func LoadConfig(path string) (*Config, error) {
 	return &cfg, nil
 }
 
-func GetCfgValue[T int | string](key string, fallbackValue T) T {
+func GetCfgValue[T int | float64 | string | bool](key string, fallbackValue T) T {
 	valStr := os.Getenv(key)
 	if valStr == "" {
 		return fallbackValue
@@ -68,8 +214,20 @@ func GetCfgValue[T int | string](key string, fallbackValue T) T {
 			return fallbackValue
 		}
 		return any(parsed).(T)
+	case float64:
+		parsed, err := strconv.ParseFloat(valStr, 64)
+		if err != nil {
+			return fallbackValue
+		}
+		return any(parsed).(T)
 	case string:
 		return any(valStr).(T)
+	case bool:
+		parsed, err := strconv.ParseBool(valStr)
+		if err != nil {
+			return fallbackValue
+		}
+		return any(parsed).(T)
 	default:
 		return fallbackValue
 	}
@@ -37,6 +37,30 @@ func TestGetCfgValue_Int_NotExists(t *testing.T) {
 	assert.Equal(t, 99, val)
 }
 
+func TestGetCfgValue_Float_Valid(t *testing.T) {
+	os.Setenv("FLOAT_KEY", "0.25")
+	val := GetCfgValue("FLOAT_KEY", 1.0)
+	assert.Equal(t, 0.25, val)
+}
+
+func TestGetCfgValue_Float_Invalid(t *testing.T) {
+	os.Setenv("FLOAT_KEY", "notafloat")
+	val := GetCfgValue("FLOAT_KEY", 1.0)
+	assert.Equal(t, 1.0, val)
+}
+
+func TestGetCfgValue_Bool_Valid(t *testing.T) {
+	os.Setenv("BOOL_KEY", "true")
+	val := GetCfgValue("BOOL_KEY", false)
+	assert.Equal(t, true, val)
+}
+
+func TestGetCfgValue_Bool_Invalid(t *testing.T) {
+	os.Setenv("BOOL_KEY", "notabool")
+	val := GetCfgValue("BOOL_KEY", true)
+	assert.Equal(t, true, val)
+}
+
 func TestLoadConfig_Valid(t *testing.T) {
 	yaml := `
 db:
@@ -2,4 +2,6 @@ package consts
 
 const (
 	ApplicationPrefix = "CompaniesService"
+	CompaniesPath     = "/api/v1/companies"
+	DataChangedTopic  = "data-changed"
 )
@@ -2,40 +2,118 @@ package database
 
 import (
 	configparser "companies/cmd/internal/configParser"
-	"companies/cmd/internal/consts"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/metrics"
+	"companies/cmd/internal/structs"
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"os"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultReadTimeoutMs  = 5000
+	defaultWriteTimeoutMs = 10000
 )
 
 //go:generate mockgen -source=database.go -destination=../../tests/mocks/mock_database.go -package=mocks
 type MySQLDB struct {
-	db *gorm.DB
+	db           *gorm.DB
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+type Searcher interface {
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
 }
 
+// Database stores companies. When the cause of a failure is known its error
+// matches ErrNotFound, ErrConflict, ErrValidation or ErrUnavailable.
 type Database interface {
-	CreateRecord(CompanyInfo) (uuid.UUID, error)
-	UpdateRecord(CompanyInfo, uuid.UUID) error
-	DeleteRecord(uuid.UUID) error
-	GetRecord(uuid.UUID) (CompanyInfo, error)
-	IsRecordExists(string) bool
+	Searcher
+	CreateRecord(context.Context, CompanyInfo) (uuid.UUID, error)
+	UpdateRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64, update UpdateFunc) (CompanyInfo, error)
+	DeleteRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error
+	RestoreRecord(context.Context, uuid.UUID) (CompanyInfo, error)
+	PurgeRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error
+	GetRecord(ctx context.Context, id uuid.UUID, includeDeleted bool) (CompanyInfo, error)
+	ListRecords(context.Context, ListQuery) (ListPage, error)
+	IsRecordExists(context.Context, string) bool
+	CountCompanies(context.Context) ([]metrics.CompanyCount, error)
+}
+
+type OutboxStore interface {
+	ClaimOutbox(ctx context.Context, sink string, limit int, lease time.Duration) ([]OutboxMessage, error)
+	ReleaseOutbox(ctx context.Context, ids []uint64) error
+	MarkOutboxSent(ctx context.Context, id uint64) error
+	MarkOutboxFailed(ctx context.Context, id uint64, cause error) error
+	ParkOutbox(ctx context.Context, id uint64, cause error) error
+	OutboxBacklog(context.Context) (int64, error)
+	PruneOutbox(ctx context.Context, sentBefore time.Time, limit int) (int64, error)
+}
+
+type WebhookStore interface {
+	CreateWebhook(context.Context, WebhookSubscription) (WebhookSubscription, error)
+	GetWebhook(context.Context, uuid.UUID) (WebhookSubscription, error)
+	ListWebhooks(context.Context) ([]WebhookSubscription, error)
+	UpdateWebhook(context.Context, uuid.UUID, WebhookUpdate) (WebhookSubscription, error)
+	DeleteWebhook(context.Context, uuid.UUID) error
+	ListEnabledWebhooks(context.Context) ([]WebhookSubscription, error)
+	ListWebhookDeliveries(ctx context.Context, id uuid.UUID, limit int) ([]WebhookDelivery, error)
+	RecordWebhookDelivery(context.Context, WebhookDelivery) error
+	MarkWebhookSucceeded(context.Context, uuid.UUID) error
+	MarkWebhookFailed(ctx context.Context, id uuid.UUID, disableAfter int) error
+}
+
+// UserStore keeps the accounts that can log in, users are looked up by
+// username.
+type UserStore interface {
+	CreateUser(context.Context, User) (User, error)
+	GetUser(ctx context.Context, username string) (User, error)
+	ListUsers(context.Context) ([]User, error)
+	SetUserRoles(ctx context.Context, username string, roles []string) (User, error)
+	SetUserDisabled(ctx context.Context, username string, disabled bool) (User, error)
+	SetUserPassword(ctx context.Context, username, passwordHash string) (User, error)
+	RecordLoginFailure(ctx context.Context, username string, maxFailures int, lockout time.Duration) (User, error)
+	RecordLoginSuccess(ctx context.Context, username string) error
+}
+
+// TokenStore keeps refresh tokens and revoked access tokens.
+type TokenStore interface {
+	CreateRefreshToken(context.Context, RefreshToken) error
+	RotateRefreshToken(ctx context.Context, hash, nextHash string, expiresAt time.Time) (RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, hash string) error
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// AccountStore is everything login and token handling needs.
+type AccountStore interface {
+	UserStore
+	TokenStore
 }
 
 type Storage interface {
 	Database
+	OutboxStore
+	WebhookStore
+	AccountStore
+	Ping(context.Context) error
 	io.Closer
 }
 
-func NewMySQLDB(config configparser.DB) Storage {
-	log.Println(consts.ApplicationPrefix, "Create connection to MySQL DB")
+// NewMySQLDB connects to the database and migrates it. Statements are timed
+// on m, unless it is nil.
+func NewMySQLDB(config configparser.DB, m *metrics.Metrics, logger *slog.Logger) Storage {
+	logger.Info("Create connection to MySQL DB")
 
 	user := configparser.GetCfgValue("DB_USER", config.User)
 	pswd := configparser.GetCfgValue("DB_PASSWORD", config.Password)
@@ -43,27 +121,79 @@ func NewMySQLDB(config configparser.DB) Storage {
 	port := configparser.GetCfgValue("DB_PORT", config.Port)
 	dbName := configparser.GetCfgValue("DB_NAME", config.Name)
 
-	initDB(user, pswd, host, port, dbName)
+	logging.RegisterSecret(pswd)
+
+	initDB(logger, user, pswd, host, port, dbName)
 
 	dsn := fmt.Sprintf("%v:%v@tcp(%v:%v)/%v?charset=utf8mb4&parseTime=True&loc=Local", user, pswd, host, port, dbName)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: newGormLogger(logger)})
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		logger.Error("Failed to connect to database", logging.Err(err))
+		os.Exit(1)
+	}
+
+	if err := db.Use(tracingPlugin{}); err != nil {
+		logger.Warn("Failed to enable query tracing", logging.Err(err))
+	}
+
+	if m != nil {
+		if err := db.Use(metricsPlugin{metrics: m}); err != nil {
+			logger.Warn("Failed to enable query metrics", logging.Err(err))
+		}
+	}
+
+	db.AutoMigrate(&CompanyInfo{}, &OutboxMessage{}, &WebhookSubscription{}, &WebhookDelivery{}, &User{}, &RefreshToken{}, &RevokedToken{})
+
+	if err := migrateActiveNameIndex(db); err != nil {
+		logger.Warn("Failed to migrate the company name index", logging.Err(err))
+	}
+
+	if err := migrateUserRoles(db); err != nil {
+		logger.Warn("Failed to migrate user roles", logging.Err(err))
+	}
+
+	if err := createFullTextIndexes(db); err != nil {
+		logger.Warn("Failed to create full-text indexes", logging.Err(err))
+	}
+
+	readTimeoutMs := configparser.GetCfgValue("DB_READ_TIMEOUT_MS", config.ReadTimeoutMs)
+	if readTimeoutMs <= 0 {
+		readTimeoutMs = defaultReadTimeoutMs
+	}
+
+	writeTimeoutMs := configparser.GetCfgValue("DB_WRITE_TIMEOUT_MS", config.WriteTimeoutMs)
+	if writeTimeoutMs <= 0 {
+		writeTimeoutMs = defaultWriteTimeoutMs
+	}
+
+	return &MySQLDB{
+		db:           db,
+		readTimeout:  time.Duration(readTimeoutMs) * time.Millisecond,
+		writeTimeout: time.Duration(writeTimeoutMs) * time.Millisecond,
 	}
+}
 
-	db.AutoMigrate(&CompanyInfo{})
+// reader binds a query to ctx and bounds it by the read timeout, whichever
+// ends first cancels the query.
+func (msql *MySQLDB) reader(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, msql.readTimeout)
+	return msql.db.WithContext(ctx), cancel
+}
 
-	return &MySQLDB{db}
+// writer is reader for writes, the whole transaction shares the deadline.
+func (msql *MySQLDB) writer(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, msql.writeTimeout)
+	return msql.db.WithContext(ctx), cancel
 }
 
-func waitForRediness(dsn string) {
+func waitForRediness(logger *slog.Logger, dsn string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println(consts.ApplicationPrefix, "Timeout waiting for MySQL: ", ctx.Err())
+			logger.Warn("Timeout waiting for MySQL", logging.Err(ctx.Err()))
 			return
 
 		default:
@@ -71,18 +201,35 @@ func waitForRediness(dsn string) {
 			if err == nil {
 				if pingErr := db.Ping(); pingErr == nil {
 					db.Close()
-					log.Println(consts.ApplicationPrefix, "MySQL is ready")
+					logger.Info("MySQL is ready")
 					return
 				}
 				db.Close()
 			}
 
-			log.Println(consts.ApplicationPrefix, "Waiting for MySQL...")
+			logger.Info("Waiting for MySQL...")
 			time.Sleep(500 * time.Millisecond)
 		}
 	}
 }
 
+// Ping checks that a connection to MySQL can be used.
+func (msql *MySQLDB) Ping(ctx context.Context) error {
+	sqlDB, err := msql.db.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, msql.readTimeout)
+	defer cancel()
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("Ping error: %w", classify(err))
+	}
+
+	return nil
+}
+
 func (msql *MySQLDB) Close() error {
 	sqlDB, err := msql.db.DB()
 	if err != nil {
@@ -97,65 +244,168 @@ func (msql *MySQLDB) Close() error {
 	return nil
 }
 
-func initDB(user, psswd, addr, port, dbName string) {
-	log.Println(consts.ApplicationPrefix, "InitDB")
+func initDB(logger *slog.Logger, user, psswd, addr, port, dbName string) {
+	logger.Info("InitDB")
 
 	dsn := fmt.Sprintf("%v:%v@tcp(%v:%v)/", user, psswd, addr, port)
 
-	waitForRediness(dsn)
+	waitForRediness(logger, dsn)
 
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("Failed to open MySQL", logging.Err(err))
+		os.Exit(1)
 	}
 	defer db.Close()
 
 	_, err = db.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %v DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci", dbName))
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("Failed to create the database", logging.Err(err))
+		os.Exit(1)
 	}
 }
 
-func (msql *MySQLDB) CreateRecord(data CompanyInfo) (uuid.UUID, error) {
-	err := msql.db.Create(&data).Error
+func (msql *MySQLDB) CreateRecord(ctx context.Context, data CompanyInfo) (uuid.UUID, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&data).Error; err != nil {
+			return err
+		}
+
+		return enqueueChange(tx, structs.CompanyCreated, *data.ID, nil, &data)
+	})
 	if err != nil {
-		return uuid.Nil, errors.New("CreateRecord error: " + err.Error())
+		return uuid.Nil, fmt.Errorf("CreateRecord error: %w", classify(err))
 	}
 	return *data.ID, nil
 }
 
-func (msql *MySQLDB) UpdateRecord(data CompanyInfo, id uuid.UUID) error {
-	if err := msql.db.Where("id = ?", id).First(&data).Error; err != nil {
-		return errors.New("UpdateRecord error: " + err.Error())
+// UpdateFunc derives the new state of a record from the stored one. It must
+// not modify current through its pointer fields.
+type UpdateFunc func(current CompanyInfo) (CompanyInfo, error)
+
+// UpdateRecord applies update to the stored record under a row lock, saves
+// the result and increments the version. ID, Version and DeletedAt are
+// managed here and can't be changed by update. ifMatch makes the update
+// conditional, see checkVersion.
+func (msql *MySQLDB) UpdateRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64, update UpdateFunc) (CompanyInfo, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	var after CompanyInfo
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		before := CompanyInfo{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&before).Error; err != nil {
+			return err
+		}
+
+		if err := checkVersion(before.Version, ifMatch); err != nil {
+			return err
+		}
+
+		var err error
+		if after, err = update(before); err != nil {
+			return err
+		}
+
+		after.ID = before.ID
+		after.Version = before.Version + 1
+		after.DeletedAt = before.DeletedAt
+
+		if err := tx.Save(&after).Error; err != nil {
+			return err
+		}
+
+		return enqueueChange(tx, structs.CompanyUpdated, id, &before, &after)
+	})
+	if err != nil {
+		return after, fmt.Errorf("UpdateRecord error: %w", classify(err))
+	}
+
+	return after, nil
+}
+
+// lockRecord loads a record for a write, it returns nil if there is none.
+func lockRecord(tx *gorm.DB, id uuid.UUID) (*CompanyInfo, error) {
+	var records []CompanyInfo
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Limit(1).Find(&records).Error; err != nil {
+		return nil, err
 	}
 
-	if err := msql.db.Save(&data).Error; err != nil {
-		return errors.New("UpdateRecord error: " + err.Error())
+	if len(records) == 0 {
+		return nil, nil
 	}
 
-	return nil
+	return &records[0], nil
 }
 
-func (msql *MySQLDB) DeleteRecord(id uuid.UUID) error {
-	if err := msql.db.Where("id = ?", id).Delete(&CompanyInfo{}).Error; err != nil {
-		return errors.New("DeleteRecord error: " + err.Error())
+// DeleteRecord soft-deletes a company, see RestoreRecord and PurgeRecord.
+// A missing record is ErrNotFound, or ErrVersionMismatch with ifMatch.
+func (msql *MySQLDB) DeleteRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		before, err := lockRecord(tx, id)
+		if err != nil {
+			return err
+		}
+
+		var current uint64
+		if before != nil {
+			current = before.Version
+		}
+
+		if err := checkVersion(current, ifMatch); err != nil {
+			return err
+		}
+
+		if before == nil {
+			return ErrNotFound
+		}
+
+		err = tx.Model(&CompanyInfo{}).Where("id = ?", id).Updates(map[string]any{
+			"deleted_at": time.Now(),
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return enqueueChange(tx, structs.CompanyDeleted, id, before, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteRecord error: %w", classify(err))
 	}
 
 	return nil
 }
 
-func (msql *MySQLDB) GetRecord(id uuid.UUID) (CompanyInfo, error) {
+func (msql *MySQLDB) GetRecord(ctx context.Context, id uuid.UUID, includeDeleted bool) (CompanyInfo, error) {
+	tx, cancel := msql.reader(ctx)
+	defer cancel()
+
+	if includeDeleted {
+		tx = tx.Unscoped()
+	}
+
 	record := CompanyInfo{}
-	if err := msql.db.Where("id = ?", id).First(&record).Error; err != nil {
-		return record, errors.New("GetRecord error: " + err.Error())
+	if err := tx.Where("id = ?", id).First(&record).Error; err != nil {
+		return record, fmt.Errorf("GetRecord error: %w", classify(err))
 	}
 
 	return record, nil
 }
 
-func (msql *MySQLDB) IsRecordExists(name string) bool {
+func (msql *MySQLDB) IsRecordExists(ctx context.Context, name string) bool {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
 	var record CompanyInfo
-	err := msql.db.Select("id").Where("name = ?", name).Limit(1).First(&record).Error
+	err := db.Select("id").Where("name = ?", name).Limit(1).First(&record).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return false
@@ -164,3 +414,21 @@ func (msql *MySQLDB) IsRecordExists(name string) bool {
 	}
 	return true
 }
+
+// CountCompanies groups the companies that aren't deleted by type and
+// registration status.
+func (msql *MySQLDB) CountCompanies(ctx context.Context) ([]metrics.CompanyCount, error) {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
+	var counts []metrics.CompanyCount
+	err := db.Model(&CompanyInfo{}).
+		Select("type, is_registered, COUNT(*) AS count").
+		Group("type, is_registered").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("CountCompanies error: %w", classify(err))
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Errors returned through the Database interfaces. Callers match them with
+// errors.Is, the driver error stays in the chain for logging.
+var (
+	ErrNotFound    = errors.New("record not found")
+	ErrConflict    = errors.New("record conflicts with an existing one")
+	ErrValidation  = errors.New("record or query is invalid")
+	ErrUnavailable = errors.New("database is unavailable")
+)
+
+// MySQL server error numbers, see
+// https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html.
+const (
+	mysqlTooManyConnections  = 1040
+	mysqlBadNull             = 1048
+	mysqlServerShutdown      = 1053
+	mysqlDuplicateEntry      = 1062
+	mysqlLockWaitTimeout     = 1205
+	mysqlDeadlock            = 1213
+	mysqlOutOfRange          = 1264
+	mysqlQueryInterrupted    = 1317
+	mysqlNoDefault           = 1364
+	mysqlDataTooLong         = 1406
+	mysqlReadOnlyTransaction = 1792
+	mysqlCheckConstraint     = 3819
+)
+
+// classify tags err with one of the errors above when it can tell what went
+// wrong. Errors it doesn't recognise, including the ones already tagged, are
+// returned unchanged.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, known := range []error{ErrNotFound, ErrConflict, ErrValidation, ErrUnavailable, ErrVersionMismatch} {
+		if errors.Is(err, known) {
+			return err
+		}
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry:
+			return fmt.Errorf("%w: %w", ErrConflict, err)
+		case mysqlBadNull, mysqlNoDefault, mysqlDataTooLong, mysqlOutOfRange, mysqlCheckConstraint:
+			return fmt.Errorf("%w: %w", ErrValidation, err)
+		case mysqlTooManyConnections, mysqlLockWaitTimeout, mysqlDeadlock, mysqlQueryInterrupted,
+			mysqlServerShutdown, mysqlReadOnlyTransaction:
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	return err
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"record not found", gorm.ErrRecordNotFound, ErrNotFound},
+		{"duplicate entry", &mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry 'Acme'"}, ErrConflict},
+		{"data too long", &mysql.MySQLError{Number: mysqlDataTooLong}, ErrValidation},
+		{"deadlock", &mysql.MySQLError{Number: mysqlDeadlock}, ErrUnavailable},
+		{"bad connection", driver.ErrBadConn, ErrUnavailable},
+		{"timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), ErrUnavailable},
+		{"not deleted", ErrNotDeleted, ErrNotFound},
+		{"version mismatch", ErrVersionMismatch, ErrVersionMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classify(tt.err)
+			assert.ErrorIs(t, got, tt.want)
+			assert.ErrorIs(t, got, tt.err)
+		})
+	}
+}
+
+func TestClassify_Unknown(t *testing.T) {
+	err := errors.New("something else")
+	assert.Same(t, err, classify(err))
+
+	syntax := &mysql.MySQLError{Number: 1064}
+	assert.Equal(t, error(syntax), classify(syntax))
+
+	assert.NoError(t, classify(nil))
+}
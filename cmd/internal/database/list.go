@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+var ErrInvalidCursor = fmt.Errorf("%w: invalid cursor", ErrValidation)
+
+// sortColumns maps the JSON field names accepted by the sort parameter
+// to the SQL expression used for ordering and keyset comparison.
+var sortColumns = map[string]string{
+	"id":             "id",
+	"name":           "name",
+	"description":    "COALESCE(description, '')",
+	"employeesCount": "employees_count",
+	"isRegistered":   "is_registered",
+	"type":           "type",
+}
+
+// ListQuery describes a single page request for ListRecords. Soft-deleted
+// records are skipped unless IncludeDeleted is set.
+type ListQuery struct {
+	Type           *int
+	IsRegistered   *bool
+	MinEmployees   *int
+	MaxEmployees   *int
+	NamePrefix     string
+	SortBy         string
+	Descending     bool
+	Limit          int
+	Cursor         string
+	IncludeDeleted bool
+}
+
+// ListPage is a page of records. NextCursor is empty on the last page.
+type ListPage struct {
+	Items      []CompanyInfo `json:"items"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+type cursor struct {
+	SortBy     string    `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	Value      any       `json:"v"`
+	ID         uuid.UUID `json:"id"`
+}
+
+func IsSortable(field string) bool {
+	_, ok := sortColumns[field]
+	return ok
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+func sortValue(record CompanyInfo, field string) any {
+	switch field {
+	case "name":
+		return record.Name
+	case "description":
+		if record.Description == nil {
+			return ""
+		}
+		return *record.Description
+	case "employeesCount":
+		return record.EmployeesCount
+	case "isRegistered":
+		return record.IsRegistered
+	case "type":
+		return record.Type
+	default:
+		return record.ID
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func applyListFilters(tx *gorm.DB, query ListQuery) *gorm.DB {
+	if query.Type != nil {
+		tx = tx.Where("type = ?", *query.Type)
+	}
+
+	if query.IsRegistered != nil {
+		tx = tx.Where("is_registered = ?", *query.IsRegistered)
+	}
+
+	if query.MinEmployees != nil {
+		tx = tx.Where("employees_count >= ?", *query.MinEmployees)
+	}
+
+	if query.MaxEmployees != nil {
+		tx = tx.Where("employees_count <= ?", *query.MaxEmployees)
+	}
+
+	if query.NamePrefix != "" {
+		tx = tx.Where("name LIKE ?", escapeLike(query.NamePrefix)+"%")
+	}
+
+	return tx
+}
+
+func (msql *MySQLDB) ListRecords(ctx context.Context, query ListQuery) (ListPage, error) {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
+	page := ListPage{Items: []CompanyInfo{}}
+
+	if query.SortBy == "" {
+		query.SortBy = "id"
+	}
+
+	column, ok := sortColumns[query.SortBy]
+	if !ok {
+		return page, fmt.Errorf("ListRecords error: %w: unsupported sort field %v", ErrValidation, query.SortBy)
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = DefaultListLimit
+	}
+
+	if query.Limit > MaxListLimit {
+		query.Limit = MaxListLimit
+	}
+
+	direction, compare := "ASC", ">"
+	if query.Descending {
+		direction, compare = "DESC", "<"
+	}
+
+	tx := db.Model(&CompanyInfo{})
+	if query.IncludeDeleted {
+		tx = tx.Unscoped()
+	}
+
+	tx = applyListFilters(tx, query)
+
+	if query.Cursor != "" {
+		c, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return page, err
+		}
+
+		if c.SortBy != query.SortBy || c.Descending != query.Descending {
+			return page, ErrInvalidCursor
+		}
+
+		if column == "id" {
+			tx = tx.Where("id "+compare+" ?", c.ID)
+		} else {
+			tx = tx.Where("(("+column+" "+compare+" ?) OR ("+column+" = ? AND id "+compare+" ?))", c.Value, c.Value, c.ID)
+		}
+	}
+
+	order := "id " + direction
+	if column != "id" {
+		order = column + " " + direction + ", " + order
+	}
+
+	var records []CompanyInfo
+	if err := tx.Order(order).Limit(query.Limit + 1).Find(&records).Error; err != nil {
+		return page, fmt.Errorf("ListRecords error: %w", classify(err))
+	}
+
+	if len(records) > query.Limit {
+		records = records[:query.Limit]
+		last := records[len(records)-1]
+		page.NextCursor = encodeCursor(cursor{
+			SortBy:     query.SortBy,
+			Descending: query.Descending,
+			Value:      sortValue(last, query.SortBy),
+			ID:         *last.ID,
+		})
+	}
+
+	page.Items = records
+
+	return page, nil
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	id := uuid.New()
+	c := cursor{SortBy: "name", Descending: true, Value: "Acme", ID: id}
+
+	got, err := decodeCursor(encodeCursor(c))
+
+	assert.NoError(t, err)
+	assert.Equal(t, c, got)
+}
+
+func TestCursor_Invalid(t *testing.T) {
+	_, err := decodeCursor("not a cursor!")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `50\%\_off\\`, escapeLike(`50%_off\`))
+}
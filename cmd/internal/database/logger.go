@@ -0,0 +1,87 @@
+package database
+
+import (
+	"companies/cmd/internal/logging"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger sends GORM's records to slog: failed and slow statements as
+// warnings, every other statement at debug level. Statements are logged with
+// placeholders, so values such as webhook secrets never reach the log.
+type gormLogger struct {
+	logger *slog.Logger
+	level  gormlogger.LogLevel
+}
+
+func newGormLogger(logger *slog.Logger) gormLogger {
+	return gormLogger{logger: logger, level: gormlogger.Info}
+}
+
+func (l gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	l.level = level
+	return l
+}
+
+func (l gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.logger.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.logger.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.logger.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+
+	level, msg := slog.LevelDebug, "query"
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelWarn, "query failed"
+	case elapsed > slowQueryThreshold:
+		level, msg = slog.LevelWarn, "slow query"
+	}
+
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Duration("duration", elapsed),
+	}
+	if err != nil {
+		attrs = append(attrs, logging.Err(err))
+	}
+
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter drops the statement values before GORM renders the SQL.
+func (gormLogger) ParamsFilter(_ context.Context, sql string, _ ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"companies/cmd/internal/metrics"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// metricsPlugin times every statement and counts the failed ones, by
+// operation and table.
+type metricsPlugin struct {
+	metrics *metrics.Metrics
+}
+
+func (metricsPlugin) Name() string {
+	return "metrics"
+}
+
+func (p metricsPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register("metrics:before_create", startTimer),
+		callbacks.Create().After("gorm:create").Register("metrics:after_create", p.observe("INSERT")),
+		callbacks.Query().Before("gorm:query").Register("metrics:before_query", startTimer),
+		callbacks.Query().After("gorm:query").Register("metrics:after_query", p.observe("SELECT")),
+		callbacks.Update().Before("gorm:update").Register("metrics:before_update", startTimer),
+		callbacks.Update().After("gorm:update").Register("metrics:after_update", p.observe("UPDATE")),
+		callbacks.Delete().Before("gorm:delete").Register("metrics:before_delete", startTimer),
+		callbacks.Delete().After("gorm:delete").Register("metrics:after_delete", p.observe("DELETE")),
+		callbacks.Row().Before("gorm:row").Register("metrics:before_row", startTimer),
+		callbacks.Row().After("gorm:row").Register("metrics:after_row", p.observe("ROW")),
+		callbacks.Raw().Before("gorm:raw").Register("metrics:before_raw", startTimer),
+		callbacks.Raw().After("gorm:raw").Register("metrics:after_raw", p.observe("RAW")),
+	)
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func (p metricsPlugin) observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		p.metrics.DBQueryDuration.WithLabelValues(operation, table).Observe(time.Since(value.(time.Time)).Seconds())
+
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			p.metrics.DBQueryErrorsTotal.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"companies/cmd/internal/metrics"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func sampleCount(t *testing.T, m *metrics.Metrics, operation, table string) uint64 {
+	t.Helper()
+
+	var metric dto.Metric
+	require.NoError(t, m.DBQueryDuration.WithLabelValues(operation, table).(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestMetricsPlugin_ObservesStatements(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:0)/test", SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+
+	m, err := metrics.New(prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, db.Use(metricsPlugin{metrics: m}))
+
+	require.NoError(t, db.WithContext(context.Background()).Where("id = ?", uuid.New()).Find(&[]CompanyInfo{}).Error)
+
+	require.EqualValues(t, 1, sampleCount(t, m, "SELECT", "company_infos"))
+}
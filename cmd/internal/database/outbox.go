@@ -0,0 +1,212 @@
+package database
+
+import (
+	"companies/cmd/internal/consts"
+	"companies/cmd/internal/structs"
+	"companies/cmd/internal/tracing"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxOutboxErrorLength = 1000
+
+// Sinks the relay delivers outbox messages to. Every event is written once per
+// sink, so each sink keeps its own delivery state and a failing one neither
+// holds back nor re-sends the others.
+const (
+	SinkEvents   = "events"
+	SinkWebhooks = "webhooks"
+)
+
+var outboxSinks = []string{SinkEvents, SinkWebhooks}
+
+// OutboxMessage is an event written in the same transaction as the change it
+// describes. The relay publishes pending messages to their sink and sets
+// SentAt. While a relay publishes a message it is claimed until ClaimedUntil,
+// so other replicas skip it. A message failing too often is parked, it keeps
+// its LastError and isn't published anymore.
+type OutboxMessage struct {
+	ID           uint64     `gorm:"primaryKey;autoIncrement"`
+	Sink         string     `gorm:"size:32;not null;default:events;index:idx_outbox_sink_pending"`
+	Topic        string     `gorm:"size:255;not null"`
+	Payload      []byte     `gorm:"type:blob;not null"`
+	CreatedAt    time.Time  `gorm:"not null"`
+	SentAt       *time.Time `gorm:"index;index:idx_outbox_sink_pending"`
+	ParkedAt     *time.Time `gorm:"index:idx_outbox_sink_pending"`
+	Attempts     int        `gorm:"not null;default:0"`
+	LastError    string     `gorm:"size:1000"`
+	ClaimedUntil *time.Time
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
+
+func enqueueEvent(tx *gorm.DB, topic string, event structs.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	messages := make([]OutboxMessage, 0, len(outboxSinks))
+	for _, sink := range outboxSinks {
+		messages = append(messages, OutboxMessage{Sink: sink, Topic: topic, Payload: payload})
+	}
+
+	return tx.Create(&messages).Error
+}
+
+// enqueueChange records the change with the trace context and request ID of
+// the request, so the relay's publish joins the trace that made the change and
+// consumers can correlate it with the request's logs.
+func enqueueChange(tx *gorm.DB, eventType string, id uuid.UUID, before, after *CompanyInfo) error {
+	event, err := structs.NewChangeEvent(eventType, id.String(), before, after)
+	if err != nil {
+		return err
+	}
+
+	event.TraceParent, event.TraceState = tracing.Inject(tx.Statement.Context)
+	event.CorrelationID = middleware.GetReqID(tx.Statement.Context)
+
+	return enqueueEvent(tx, consts.DataChangedTopic, event)
+}
+
+// ClaimOutbox returns up to limit pending messages of sink, oldest first, and
+// claims them for lease. Messages another relay claimed are skipped, rows it is
+// claiming right now too rather than waited for.
+func (msql *MySQLDB) ClaimOutbox(ctx context.Context, sink string, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	var messages []OutboxMessage
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("sink = ? AND sent_at IS NULL AND parked_at IS NULL AND (claimed_until IS NULL OR claimed_until < ?)", sink, now).
+			Order("id").
+			Limit(limit).
+			Find(&messages).Error
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		ids := make([]uint64, 0, len(messages))
+		for _, message := range messages {
+			ids = append(ids, message.ID)
+		}
+
+		return tx.Model(&OutboxMessage{}).Where("id IN ?", ids).Update("claimed_until", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ClaimOutbox error: %w", classify(err))
+	}
+
+	return messages, nil
+}
+
+// ReleaseOutbox gives up the claim on messages that weren't published, so the
+// next relay doesn't wait for the lease to end.
+func (msql *MySQLDB) ReleaseOutbox(ctx context.Context, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	if err := db.Model(&OutboxMessage{}).Where("id IN ? AND sent_at IS NULL", ids).Update("claimed_until", nil).Error; err != nil {
+		return fmt.Errorf("ReleaseOutbox error: %w", classify(err))
+	}
+
+	return nil
+}
+
+// OutboxBacklog counts the messages the relay hasn't published yet, parked
+// ones aren't waiting anymore.
+func (msql *MySQLDB) OutboxBacklog(ctx context.Context) (int64, error) {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
+	var count int64
+	if err := db.Model(&OutboxMessage{}).Where("sent_at IS NULL AND parked_at IS NULL").Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("OutboxBacklog error: %w", classify(err))
+	}
+
+	return count, nil
+}
+
+func (msql *MySQLDB) MarkOutboxSent(ctx context.Context, id uint64) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	err := db.Model(&OutboxMessage{}).Where("id = ?", id).Updates(map[string]any{
+		"sent_at":  time.Now(),
+		"attempts": gorm.Expr("attempts + 1"),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("MarkOutboxSent error: %w", classify(err))
+	}
+
+	return nil
+}
+
+// MarkOutboxFailed records a failed attempt and releases the message, so it is
+// retried.
+func (msql *MySQLDB) MarkOutboxFailed(ctx context.Context, id uint64, cause error) error {
+	if err := msql.recordOutboxFailure(ctx, id, cause, nil); err != nil {
+		return fmt.Errorf("MarkOutboxFailed error: %w", err)
+	}
+
+	return nil
+}
+
+// ParkOutbox records the last failed attempt of a message that is given up on.
+func (msql *MySQLDB) ParkOutbox(ctx context.Context, id uint64, cause error) error {
+	now := time.Now()
+	if err := msql.recordOutboxFailure(ctx, id, cause, &now); err != nil {
+		return fmt.Errorf("ParkOutbox error: %w", err)
+	}
+
+	return nil
+}
+
+func (msql *MySQLDB) recordOutboxFailure(ctx context.Context, id uint64, cause error, parkedAt *time.Time) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	message := cause.Error()
+	if len(message) > maxOutboxErrorLength {
+		message = message[:maxOutboxErrorLength]
+	}
+
+	err := db.Model(&OutboxMessage{}).Where("id = ?", id).Updates(map[string]any{
+		"last_error":    message,
+		"attempts":      gorm.Expr("attempts + 1"),
+		"claimed_until": nil,
+		"parked_at":     parkedAt,
+	}).Error
+
+	return classify(err)
+}
+
+// PruneOutbox deletes up to limit messages sent before sentBefore and returns
+// how many it deleted.
+func (msql *MySQLDB) PruneOutbox(ctx context.Context, sentBefore time.Time, limit int) (int64, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	result := db.Where("sent_at < ?", sentBefore).Order("id").Limit(limit).Delete(&OutboxMessage{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("PruneOutbox error: %w", classify(result.Error))
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"companies/cmd/internal/structs"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// dryRunOutbox returns a database that only builds statements and a function
+// returning the outbox messages created through it last.
+func dryRunOutbox(t *testing.T) (*gorm.DB, func() []OutboxMessage) {
+	t.Helper()
+
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:0)/test", SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	require.NoError(t, err)
+
+	var messages []OutboxMessage
+	require.NoError(t, db.Callback().Create().After("gorm:create").Register("test:capture", func(tx *gorm.DB) {
+		if created, ok := tx.Statement.Dest.(*[]OutboxMessage); ok {
+			messages = *created
+		}
+	}))
+
+	return db, func() []OutboxMessage { return messages }
+}
+
+func TestEnqueueChange_CarriesRequestID(t *testing.T) {
+	db, created := dryRunOutbox(t)
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-42")
+	require.NoError(t, enqueueChange(db.WithContext(ctx), structs.CompanyCreated, uuid.New(), nil, &CompanyInfo{}))
+	require.NotEmpty(t, created())
+
+	var event structs.Event
+	require.NoError(t, json.Unmarshal(created()[0].Payload, &event))
+	require.Equal(t, "req-42", event.CorrelationID)
+}
+
+func TestEnqueueChange_OneMessagePerSink(t *testing.T) {
+	db, created := dryRunOutbox(t)
+
+	require.NoError(t, enqueueChange(db, structs.CompanyDeleted, uuid.New(), &CompanyInfo{}, nil))
+
+	var sinks []string
+	for _, message := range created() {
+		sinks = append(sinks, message.Sink)
+		require.Equal(t, created()[0].Payload, message.Payload)
+	}
+	require.Equal(t, []string{SinkEvents, SinkWebhooks}, sinks)
+}
@@ -5,14 +5,17 @@ import (
 	"gorm.io/gorm"
 )
 
-// CompanyInfo represents a company object
+// CompanyInfo represents a company object. Names are unique among companies
+// that aren't soft-deleted, see migrateActiveNameIndex.
 type CompanyInfo struct {
-	ID             *uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
-	Name           *string    `json:"name" gorm:"size:15;not null;uniqueIndex"`
-	Description    *string    `json:"description,omitempty" gorm:"size:3000"`
-	EmployeesCount *int       `json:"employeesCount" gorm:"not null"`
-	IsRegistered   *bool      `json:"isRegistered" gorm:"not null"`
-	Type           *int       `json:"type" gorm:"not null"`
+	ID             *uuid.UUID     `json:"id" gorm:"type:char(36);primaryKey"`
+	Name           *string        `json:"name" gorm:"size:15;not null;index:idx_company_infos_name_lookup"`
+	Description    *string        `json:"description,omitempty" gorm:"size:3000"`
+	EmployeesCount *int           `json:"employeesCount" gorm:"not null"`
+	IsRegistered   *bool          `json:"isRegistered" gorm:"not null"`
+	Type           *int           `json:"type" gorm:"not null"`
+	DeletedAt      gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index" swaggertype:"string" format:"date-time"`
+	Version        uint64         `json:"version" gorm:"not null;default:1"`
 }
 
 func (r *CompanyInfo) BeforeCreate(tx *gorm.DB) error {
@@ -20,5 +23,6 @@ func (r *CompanyInfo) BeforeCreate(tx *gorm.DB) error {
 		id := uuid.New()
 		r.ID = &id
 	}
+	r.Version = InitialVersion
 	return nil
 }
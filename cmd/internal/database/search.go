@@ -0,0 +1,319 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	nameWeight          = 2.0
+	fuzzyCandidateLimit = 200
+)
+
+// SearchResult is a company matched by a Searcher with its relevance score,
+// higher is better.
+type SearchResult struct {
+	Company CompanyInfo `json:"company"`
+	Score   float64     `json:"score"`
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// maxEdits is the number of typos tolerated in a name term of the given length.
+func maxEdits(term string) int {
+	switch n := len([]rune(term)); {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// fuzzyMatch reports how close word is to the query term in [0, 1],
+// 1 for an exact match and 0 when it is too far to count as a typo.
+func fuzzyMatch(term, word string) float64 {
+	if term == word {
+		return 1
+	}
+
+	dist := levenshtein(term, word)
+	if dist > maxEdits(term) {
+		return 0
+	}
+
+	return 1 - float64(dist)/float64(len([]rune(term))+1)
+}
+
+// nameSimilarity scores how well the company name matches the query terms,
+// tolerating typos.
+func nameSimilarity(terms []string, name string) float64 {
+	words := tokenize(name)
+	score := 0.0
+
+	for _, term := range terms {
+		best := 0.0
+		for _, word := range words {
+			best = math.Max(best, fuzzyMatch(term, word))
+		}
+		score += best
+	}
+
+	return score
+}
+
+func sortResults(results []SearchResult, limit int) []SearchResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return *results[i].Company.Name < *results[j].Company.Name
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+func createFullTextIndexes(db *gorm.DB) error {
+	indexes := map[string]string{
+		"ft_company_infos_name":             "name",
+		"ft_company_infos_name_description": "name, description",
+	}
+
+	for name, columns := range indexes {
+		if db.Migrator().HasIndex(&CompanyInfo{}, name) {
+			continue
+		}
+
+		if err := db.Exec("CREATE FULLTEXT INDEX " + name + " ON company_infos (" + columns + ")").Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type scoredRecord struct {
+	CompanyInfo
+	Score float64
+}
+
+// Search ranks companies by FULLTEXT relevance over name and description,
+// names weighted higher, and adds names that are within a few typos of the
+// query terms.
+func (msql *MySQLDB) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	var matched []scoredRecord
+	err := db.Model(&CompanyInfo{}).
+		Select("*, MATCH(name) AGAINST (?) * ? + MATCH(name, description) AGAINST (?) AS score", query, nameWeight, query).
+		Where("MATCH(name, description) AGAINST (?)", query).
+		Order("score DESC").
+		Limit(limit).
+		Find(&matched).Error
+	if err != nil {
+		return nil, fmt.Errorf("Search error: %w", classify(err))
+	}
+
+	tx := db.Model(&CompanyInfo{})
+	for _, term := range terms {
+		prefix := []rune(term)
+		if len(prefix) > 2 {
+			prefix = prefix[:2]
+		}
+		tx = tx.Or("SOUNDEX(name) = SOUNDEX(?)", term).Or("name LIKE ?", escapeLike(string(prefix))+"%")
+	}
+
+	// Common prefixes match more names than the limit, the ones closest in
+	// length to the query are the likeliest typos. The id makes the cut
+	// deterministic among names of the same length.
+	queryLength := len([]rune(strings.Join(terms, " ")))
+	tx = tx.Order(clause.OrderBy{Expression: clause.Expr{SQL: "ABS(CHAR_LENGTH(name) - ?), id", Vars: []any{queryLength}}})
+
+	var candidates []CompanyInfo
+	if err := tx.Limit(fuzzyCandidateLimit).Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("Search error: %w", classify(err))
+	}
+
+	byID := map[uuid.UUID]*SearchResult{}
+	results := make([]SearchResult, 0, len(matched)+len(candidates))
+
+	for _, record := range matched {
+		results = append(results, SearchResult{Company: record.CompanyInfo, Score: record.Score})
+	}
+
+	for i := range results {
+		byID[*results[i].Company.ID] = &results[i]
+	}
+
+	for _, record := range candidates {
+		similarity := nameSimilarity(terms, *record.Name) * nameWeight
+		if similarity == 0 {
+			continue
+		}
+
+		if result, ok := byID[*record.ID]; ok {
+			result.Score = math.Max(result.Score, similarity)
+			continue
+		}
+
+		results = append(results, SearchResult{Company: record, Score: similarity})
+	}
+
+	return sortResults(results, limit), nil
+}
+
+type posting struct {
+	name        int
+	description int
+}
+
+// InvertedIndex is an in-process Searcher. It ranks with TF-IDF over the same
+// fields as the MySQL implementation and is meant for tests and local runs.
+type InvertedIndex struct {
+	mu       sync.RWMutex
+	docs     map[uuid.UUID]CompanyInfo
+	postings map[string]map[uuid.UUID]*posting
+}
+
+func NewInvertedIndex() *InvertedIndex {
+	return &InvertedIndex{
+		docs:     map[uuid.UUID]CompanyInfo{},
+		postings: map[string]map[uuid.UUID]*posting{},
+	}
+}
+
+func (idx *InvertedIndex) Add(record CompanyInfo) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(*record.ID)
+	idx.docs[*record.ID] = record
+
+	add := func(text string, inc func(*posting)) {
+		for _, token := range tokenize(text) {
+			docs, ok := idx.postings[token]
+			if !ok {
+				docs = map[uuid.UUID]*posting{}
+				idx.postings[token] = docs
+			}
+			if docs[*record.ID] == nil {
+				docs[*record.ID] = &posting{}
+			}
+			inc(docs[*record.ID])
+		}
+	}
+
+	if record.Name != nil {
+		add(*record.Name, func(p *posting) { p.name++ })
+	}
+
+	if record.Description != nil {
+		add(*record.Description, func(p *posting) { p.description++ })
+	}
+}
+
+func (idx *InvertedIndex) Remove(id uuid.UUID) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+}
+
+func (idx *InvertedIndex) remove(id uuid.UUID) {
+	if _, ok := idx.docs[id]; !ok {
+		return
+	}
+
+	delete(idx.docs, id)
+
+	for token, docs := range idx.postings {
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(idx.postings, token)
+		}
+	}
+}
+
+func (idx *InvertedIndex) Search(_ context.Context, query string, limit int) ([]SearchResult, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	scores := map[uuid.UUID]float64{}
+	total := float64(len(idx.docs))
+
+	for _, term := range tokenize(query) {
+		for token, docs := range idx.postings {
+			similarity := fuzzyMatch(term, token)
+			if similarity == 0 {
+				continue
+			}
+
+			idf := math.Log(1 + total/float64(len(docs)))
+			for id, p := range docs {
+				// Typos are only tolerated in names, descriptions need an exact match.
+				score := similarity * float64(p.name) * nameWeight
+				if similarity == 1 {
+					score += float64(p.description)
+				}
+				scores[id] += score * idf
+			}
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for id, score := range scores {
+		if score > 0 {
+			results = append(results, SearchResult{Company: idx.docs[id], Score: score})
+		}
+	}
+
+	return sortResults(results, limit), nil
+}
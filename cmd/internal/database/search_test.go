@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func makeCompany(name, description string) CompanyInfo {
+	id := uuid.New()
+	return CompanyInfo{ID: &id, Name: &name, Description: &description}
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("acme", "acme"))
+	assert.Equal(t, 1, levenshtein("acme", "acne"))
+	assert.Equal(t, 2, levenshtein("globex", "glboex"))
+	assert.Equal(t, 3, levenshtein("", "abc"))
+}
+
+func TestInvertedIndex_RanksNameAboveDescription(t *testing.T) {
+	index := NewInvertedIndex()
+
+	inName := makeCompany("Rocket Labs", "Space launches")
+	inDescription := makeCompany("Acme", "We sell rocket parts")
+	unrelated := makeCompany("Globex", "Consulting")
+
+	index.Add(inDescription)
+	index.Add(inName)
+	index.Add(unrelated)
+
+	results, err := index.Search(context.Background(), "rocket", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, *inName.ID, *results[0].Company.ID)
+	assert.Equal(t, *inDescription.ID, *results[1].Company.ID)
+}
+
+func TestInvertedIndex_TypoToleranceOnNames(t *testing.T) {
+	index := NewInvertedIndex()
+
+	company := makeCompany("Initech", "Software")
+	index.Add(company)
+	index.Add(makeCompany("Umbrella", "Initek only appears in a description"))
+
+	results, err := index.Search(context.Background(), "initec", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, *company.ID, *results[0].Company.ID)
+}
+
+func TestInvertedIndex_RemoveAndLimit(t *testing.T) {
+	index := NewInvertedIndex()
+
+	first := makeCompany("Acme One", "")
+	second := makeCompany("Acme Two", "")
+	index.Add(first)
+	index.Add(second)
+
+	results, err := index.Search(context.Background(), "acme", 1)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	index.Remove(*first.ID)
+
+	results, err = index.Search(context.Background(), "acme", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, *second.ID, *results[0].Company.ID)
+}
+
+func TestSearch_OrdersFuzzyCandidatesBeforeLimit(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:0)/test", SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+
+	var statements []*gorm.Statement
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement)
+	}))
+
+	msql := &MySQLDB{db: db, readTimeout: time.Second}
+	_, err = msql.Search(context.Background(), "acme", 10)
+	require.NoError(t, err)
+
+	// "ac" prefixes more names than the candidate limit, which of them make
+	// the cut must not be up to the server.
+	require.Len(t, statements, 2)
+	candidates := statements[1]
+	assert.Contains(t, candidates.SQL.String(), "ORDER BY ABS(CHAR_LENGTH(name) - ?), id LIMIT ?")
+	assert.Equal(t, []any{"acme", "ac%", len("acme"), fuzzyCandidateLimit}, candidates.Vars)
+}
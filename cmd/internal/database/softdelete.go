@@ -0,0 +1,128 @@
+package database
+
+import (
+	"companies/cmd/internal/structs"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// legacyNameIndex made names unique across deleted companies as well.
+	legacyNameIndex = "idx_company_infos_name"
+	activeNameIndex = "uniq_company_infos_active_name"
+)
+
+var ErrNotDeleted = fmt.Errorf("%w: no deleted record with this id", ErrNotFound)
+
+// migrateActiveNameIndex keeps names unique among live companies only.
+// active_name mirrors name while the row isn't deleted and is NULL afterwards,
+// and a unique index ignores NULLs, so a deleted company frees its name.
+func migrateActiveNameIndex(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	if migrator.HasIndex(&CompanyInfo{}, legacyNameIndex) {
+		if err := migrator.DropIndex(&CompanyInfo{}, legacyNameIndex); err != nil {
+			return err
+		}
+	}
+
+	if !migrator.HasColumn(&CompanyInfo{}, "active_name") {
+		err := db.Exec("ALTER TABLE company_infos ADD COLUMN active_name VARCHAR(15) " +
+			"GENERATED ALWAYS AS (IF(deleted_at IS NULL, name, NULL)) STORED").Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if !migrator.HasIndex(&CompanyInfo{}, activeNameIndex) {
+		if err := db.Exec("CREATE UNIQUE INDEX " + activeNameIndex + " ON company_infos (active_name)").Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RestoreRecord undoes a soft delete. It fails if another company took the
+// name in the meantime.
+func (msql *MySQLDB) RestoreRecord(ctx context.Context, id uuid.UUID) (CompanyInfo, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	record := CompanyInfo{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).First(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotDeleted
+		}
+		if err != nil {
+			return err
+		}
+
+		before := record
+
+		err = tx.Unscoped().Model(&CompanyInfo{}).Where("id = ?", id).Updates(map[string]any{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		record.DeletedAt = gorm.DeletedAt{}
+		record.Version++
+
+		return enqueueChange(tx, structs.CompanyRestored, id, &before, &record)
+	})
+	if err != nil {
+		return record, fmt.Errorf("RestoreRecord error: %w", classify(err))
+	}
+
+	return record, nil
+}
+
+// PurgeRecord permanently removes a company, deleted or not. Like
+// DeleteRecord it fails with ErrNotFound or ErrVersionMismatch if there is
+// nothing to purge.
+func (msql *MySQLDB) PurgeRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		before, err := lockRecord(tx.Unscoped(), id)
+		if err != nil {
+			return err
+		}
+
+		var current uint64
+		if before != nil {
+			current = before.Version
+		}
+
+		if err := checkVersion(current, ifMatch); err != nil {
+			return err
+		}
+
+		if before == nil {
+			return ErrNotFound
+		}
+
+		if err := tx.Unscoped().Where("id = ?", id).Delete(&CompanyInfo{}).Error; err != nil {
+			return err
+		}
+
+		return enqueueChange(tx, structs.CompanyPurged, id, before, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("PurgeRecord error: %w", classify(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// expiredTokensBatch bounds the expired rows deleted along with a write.
+const expiredTokensBatch = 100
+
+// ErrTokenReused is returned when a refresh token that was already exchanged
+// is presented again. Its whole family is revoked by then, since either the
+// client or someone who stole the token holds a newer one.
+var ErrTokenReused = errors.New("refresh token was already used")
+
+// RefreshToken is stored under the hash of the token. Every exchange marks
+// the token used and adds its successor to the same family, a family stands
+// for one login.
+type RefreshToken struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	FamilyID  uuid.UUID `gorm:"type:char(36);not null;index"`
+	Username  string    `gorm:"size:64;not null;index"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// RevokedToken is an access token refused before its expiry.
+type RevokedToken struct {
+	JTI       string    `gorm:"column:jti;size:64;primaryKey"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+func (msql *MySQLDB) CreateRefreshToken(ctx context.Context, token RefreshToken) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("username = ? AND expires_at < ?", token.Username, time.Now()).Limit(expiredTokensBatch).Delete(&RefreshToken{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&token).Error
+	})
+	if err != nil {
+		return fmt.Errorf("CreateRefreshToken error: %w", classify(err))
+	}
+
+	return nil
+}
+
+// RotateRefreshToken exchanges the token stored under hash for a new one
+// stored under nextHash and returns the new one. Unknown, expired and revoked
+// tokens are ErrNotFound, a token used before is ErrTokenReused.
+func (msql *MySQLDB) RotateRefreshToken(ctx context.Context, hash, nextHash string, expiresAt time.Time) (RefreshToken, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	var next RefreshToken
+	reused := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", hash).First(&current).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if current.RevokedAt != nil || !now.Before(current.ExpiresAt) {
+			return ErrNotFound
+		}
+
+		// The revocation has to commit, so it isn't returned as an error.
+		if current.UsedAt != nil {
+			reused = true
+			return revokeFamily(tx, current.FamilyID, now)
+		}
+
+		if err := tx.Model(&current).Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		next = RefreshToken{TokenHash: nextHash, FamilyID: current.FamilyID, Username: current.Username, ExpiresAt: expiresAt}
+		return tx.Create(&next).Error
+	})
+	if err == nil && reused {
+		err = ErrTokenReused
+	}
+	if err != nil {
+		return next, fmt.Errorf("RotateRefreshToken error: %w", classify(err))
+	}
+
+	return next, nil
+}
+
+// RevokeRefreshToken revokes the family of the token stored under hash, so
+// the login it belongs to ends.
+func (msql *MySQLDB) RevokeRefreshToken(ctx context.Context, hash string) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var token RefreshToken
+		if err := tx.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+			return err
+		}
+
+		return revokeFamily(tx, token.FamilyID, time.Now())
+	})
+	if err != nil {
+		return fmt.Errorf("RevokeRefreshToken error: %w", classify(err))
+	}
+
+	return nil
+}
+
+func revokeFamily(tx *gorm.DB, familyID uuid.UUID, now time.Time) error {
+	return tx.Model(&RefreshToken{}).Where("family_id = ? AND revoked_at IS NULL", familyID).Update("revoked_at", now).Error
+}
+
+// revokeUserTokens ends every login of username.
+func revokeUserTokens(tx *gorm.DB, username string) error {
+	return tx.Model(&RefreshToken{}).Where("username = ? AND revoked_at IS NULL", username).Update("revoked_at", time.Now()).Error
+}
+
+// RevokeToken records the access token jti as revoked until it expires.
+// Revocations of expired tokens are deleted along the way.
+func (msql *MySQLDB) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", time.Now()).Limit(expiredTokensBatch).Delete(&RevokedToken{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("RevokeToken error: %w", classify(err))
+	}
+
+	return nil
+}
+
+func (msql *MySQLDB) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
+	var count int64
+	if err := db.Model(&RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("IsTokenRevoked error: %w", classify(err))
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestTracingPlugin_RecordsStatementSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	// A dry run builds the SQL without a server, the callbacks still run.
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:0)/test", SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(tracingPlugin{}))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	require.NoError(t, db.WithContext(ctx).Where("id = ?", uuid.New()).Find(&[]CompanyInfo{}).Error)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	span := spans[0]
+	require.Equal(t, "SELECT company_infos", span.Name())
+	require.Equal(t, trace.SpanKindClient, span.SpanKind())
+	require.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	require.Contains(t, span.Attributes(), semconv.DBSystemMySQL)
+	require.Contains(t, span.Attributes(), semconv.DBCollectionName("company_infos"))
+	require.Contains(t, span.Attributes(), semconv.DBQueryText("SELECT * FROM `company_infos` WHERE id = ? AND `company_infos`.`deleted_at` IS NULL"))
+}
@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// User is an account that can log in. Only the password hash is stored.
+type User struct {
+	ID           *uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
+	Username     string     `json:"username" gorm:"size:64;not null;uniqueIndex"`
+	PasswordHash string     `json:"-" gorm:"size:255;not null"`
+	Roles        []string   `json:"roles" gorm:"serializer:json;type:text"`
+	Disabled     bool       `json:"disabled" gorm:"not null;default:false"`
+	// FailedLogins counts the failed logins since the last successful one or
+	// the last lockout.
+	FailedLogins int        `json:"failedLogins" gorm:"not null;default:0"`
+	LockedUntil  *time.Time `json:"lockedUntil,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == nil {
+		id := uuid.New()
+		u.ID = &id
+	}
+
+	// NULL roles mark the users migrateUserRoles hasn't seen.
+	if u.Roles == nil {
+		u.Roles = []string{}
+	}
+	return nil
+}
+
+// migrateUserRoles gives roles to the users created before there were any,
+// keeping what they were allowed to do: the admin user stays admin and the
+// others can still change and delete companies.
+func migrateUserRoles(db *gorm.DB) error {
+	if err := db.Exec(`UPDATE users SET roles = '["admin"]' WHERE roles IS NULL AND username = 'admin'`).Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`UPDATE users SET roles = '["editor"]' WHERE roles IS NULL`).Error
+}
+
+// Locked reports whether logins are refused at now because of failed ones.
+func (u *User) Locked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+func (msql *MySQLDB) CreateUser(ctx context.Context, user User) (User, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	if err := db.Create(&user).Error; err != nil {
+		return user, fmt.Errorf("CreateUser error: %w", classify(err))
+	}
+
+	return user, nil
+}
+
+func (msql *MySQLDB) GetUser(ctx context.Context, username string) (User, error) {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
+	var user User
+	if err := db.Where("username = ?", username).First(&user).Error; err != nil {
+		return user, fmt.Errorf("GetUser error: %w", classify(err))
+	}
+
+	return user, nil
+}
+
+func (msql *MySQLDB) ListUsers(ctx context.Context) ([]User, error) {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
+	users := []User{}
+	if err := db.Order("username").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("ListUsers error: %w", classify(err))
+	}
+
+	return users, nil
+}
+
+// SetUserRoles replaces the roles of a user, they apply from the user's next
+// login or token refresh.
+func (msql *MySQLDB) SetUserRoles(ctx context.Context, username string, roles []string) (User, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	var user User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("username = ?", username).First(&user).Error; err != nil {
+			return err
+		}
+
+		user.Roles = roles
+		if user.Roles == nil {
+			user.Roles = []string{}
+		}
+		return tx.Model(&user).Select("roles").Updates(&user).Error
+	})
+	if err != nil {
+		return user, fmt.Errorf("SetUserRoles error: %w", classify(err))
+	}
+
+	return user, nil
+}
+
+// SetUserDisabled disables or enables a user. Disabling ends the user's
+// logins, enabling lifts a lockout.
+func (msql *MySQLDB) SetUserDisabled(ctx context.Context, username string, disabled bool) (User, error) {
+	changes := map[string]any{"disabled": disabled}
+	if !disabled {
+		changes["failed_logins"] = 0
+		changes["locked_until"] = nil
+	}
+
+	user, err := msql.updateUser(ctx, username, changes, disabled)
+	if err != nil {
+		return user, fmt.Errorf("SetUserDisabled error: %w", err)
+	}
+
+	return user, nil
+}
+
+// SetUserPassword replaces the password hash, ends the user's logins and
+// lifts a lockout.
+func (msql *MySQLDB) SetUserPassword(ctx context.Context, username, passwordHash string) (User, error) {
+	user, err := msql.updateUser(ctx, username, map[string]any{
+		"password_hash": passwordHash,
+		"failed_logins": 0,
+		"locked_until":  nil,
+	}, true)
+	if err != nil {
+		return user, fmt.Errorf("SetUserPassword error: %w", err)
+	}
+
+	return user, nil
+}
+
+// RecordLoginFailure counts a failed login. The maxFailures-th one locks the
+// user until lockout has passed and starts the count over.
+func (msql *MySQLDB) RecordLoginFailure(ctx context.Context, username string, maxFailures int, lockout time.Duration) (User, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	var user User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("username = ?", username).First(&user).Error; err != nil {
+			return err
+		}
+
+		user.FailedLogins++
+		if user.FailedLogins >= maxFailures {
+			lockedUntil := time.Now().Add(lockout)
+			user.LockedUntil = &lockedUntil
+			user.FailedLogins = 0
+		}
+
+		return tx.Model(&user).Select("failed_logins", "locked_until").Updates(&user).Error
+	})
+	if err != nil {
+		return user, fmt.Errorf("RecordLoginFailure error: %w", classify(err))
+	}
+
+	return user, nil
+}
+
+// RecordLoginSuccess resets the failed login count.
+func (msql *MySQLDB) RecordLoginSuccess(ctx context.Context, username string) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	err := db.Model(&User{}).Where("username = ?", username).
+		Updates(map[string]any{"failed_logins": 0, "locked_until": nil}).Error
+	if err != nil {
+		return fmt.Errorf("RecordLoginSuccess error: %w", classify(err))
+	}
+
+	return nil
+}
+
+// updateUser applies changes to the user and, with endLogins, revokes the
+// user's refresh tokens in the same transaction.
+func (msql *MySQLDB) updateUser(ctx context.Context, username string, changes map[string]any, endLogins bool) (User, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	var user User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("username = ?", username).First(&user).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&user).Updates(changes).Error; err != nil {
+			return err
+		}
+
+		if endLogins {
+			return revokeUserTokens(tx, username)
+		}
+		return nil
+	})
+	if err != nil {
+		return user, classify(err)
+	}
+
+	return user, nil
+}
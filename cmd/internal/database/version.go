@@ -0,0 +1,23 @@
+package database
+
+import (
+	"errors"
+	"slices"
+)
+
+// InitialVersion is the version of a newly created record, every change to
+// the record increments it.
+const InitialVersion uint64 = 1
+
+var ErrVersionMismatch = errors.New("record version doesn't match")
+
+// checkVersion implements If-Match: a write goes through only if the stored
+// version is one of the expected ones. No expected versions means the write
+// is unconditional.
+func checkVersion(current uint64, ifMatch []uint64) error {
+	if len(ifMatch) == 0 || slices.Contains(ifMatch, current) {
+		return nil
+	}
+
+	return ErrVersionMismatch
+}
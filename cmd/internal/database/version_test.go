@@ -0,0 +1,14 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckVersion(t *testing.T) {
+	require.NoError(t, checkVersion(3, nil))
+	require.NoError(t, checkVersion(3, []uint64{3}))
+	require.NoError(t, checkVersion(3, []uint64{1, 3}))
+	require.ErrorIs(t, checkVersion(3, []uint64{2}), ErrVersionMismatch)
+}
@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxDeliveryErrorLength = 1000
+
+// WebhookSubscription is a partner endpoint notified about company changes.
+// An empty EventTypes list subscribes to every change event.
+type WebhookSubscription struct {
+	ID                  *uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
+	URL                 string     `json:"url" gorm:"size:2048;not null"`
+	EventTypes          []string   `json:"eventTypes" gorm:"serializer:json;type:text"`
+	Secret              string     `json:"-" gorm:"size:128;not null"`
+	Enabled             bool       `json:"enabled" gorm:"not null"`
+	ConsecutiveFailures int        `json:"consecutiveFailures" gorm:"not null;default:0"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
+func (w *WebhookSubscription) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == nil {
+		id := uuid.New()
+		w.ID = &id
+	}
+	return nil
+}
+
+func (w *WebhookSubscription) Accepts(eventType string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery records a single delivery attempt.
+type WebhookDelivery struct {
+	ID             uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	SubscriptionID uuid.UUID `json:"subscriptionId" gorm:"type:char(36);not null;index"`
+	EventID        string    `json:"eventId" gorm:"size:64;not null"`
+	EventType      string    `json:"eventType" gorm:"size:255;not null"`
+	Attempt        int       `json:"attempt" gorm:"not null"`
+	StatusCode     int       `json:"statusCode"`
+	Success        bool      `json:"success" gorm:"not null"`
+	Error          string    `json:"error,omitempty" gorm:"size:1000"`
+	DurationMs     int64     `json:"durationMs"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// WebhookUpdate holds the fields changed by UpdateWebhook, nil fields are
+// left untouched.
+type WebhookUpdate struct {
+	URL        *string   `json:"url"`
+	EventTypes *[]string `json:"eventTypes"`
+	Enabled    *bool     `json:"enabled"`
+}
+
+func (msql *MySQLDB) CreateWebhook(ctx context.Context, subscription WebhookSubscription) (WebhookSubscription, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	if err := db.Create(&subscription).Error; err != nil {
+		return subscription, fmt.Errorf("CreateWebhook error: %w", classify(err))
+	}
+
+	return subscription, nil
+}
+
+func (msql *MySQLDB) GetWebhook(ctx context.Context, id uuid.UUID) (WebhookSubscription, error) {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
+	subscription := WebhookSubscription{}
+	if err := db.Where("id = ?", id).First(&subscription).Error; err != nil {
+		return subscription, fmt.Errorf("GetWebhook error: %w", classify(err))
+	}
+
+	return subscription, nil
+}
+
+func (msql *MySQLDB) ListWebhooks(ctx context.Context) ([]WebhookSubscription, error) {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
+	subscriptions := []WebhookSubscription{}
+	if err := db.Order("created_at").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("ListWebhooks error: %w", classify(err))
+	}
+
+	return subscriptions, nil
+}
+
+func (msql *MySQLDB) UpdateWebhook(ctx context.Context, id uuid.UUID, update WebhookUpdate) (WebhookSubscription, error) {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	subscription := WebhookSubscription{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&subscription).Error; err != nil {
+			return err
+		}
+
+		if update.URL != nil {
+			subscription.URL = *update.URL
+		}
+
+		if update.EventTypes != nil {
+			subscription.EventTypes = *update.EventTypes
+		}
+
+		if update.Enabled != nil {
+			if *update.Enabled && !subscription.Enabled {
+				subscription.ConsecutiveFailures = 0
+			}
+			subscription.Enabled = *update.Enabled
+		}
+
+		return tx.Save(&subscription).Error
+	})
+	if err != nil {
+		return subscription, fmt.Errorf("UpdateWebhook error: %w", classify(err))
+	}
+
+	return subscription, nil
+}
+
+func (msql *MySQLDB) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("id = ?", id).Delete(&WebhookSubscription{})
+		if result.Error == nil && result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return result.Error
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteWebhook error: %w", classify(err))
+	}
+
+	return nil
+}
+
+func (msql *MySQLDB) ListEnabledWebhooks(ctx context.Context) ([]WebhookSubscription, error) {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
+	subscriptions := []WebhookSubscription{}
+	if err := db.Where("enabled = ?", true).Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("ListEnabledWebhooks error: %w", classify(err))
+	}
+
+	return subscriptions, nil
+}
+
+func (msql *MySQLDB) ListWebhookDeliveries(ctx context.Context, id uuid.UUID, limit int) ([]WebhookDelivery, error) {
+	db, cancel := msql.reader(ctx)
+	defer cancel()
+
+	deliveries := []WebhookDelivery{}
+	if err := db.Where("subscription_id = ?", id).Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("ListWebhookDeliveries error: %w", classify(err))
+	}
+
+	return deliveries, nil
+}
+
+func (msql *MySQLDB) RecordWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	if len(delivery.Error) > maxDeliveryErrorLength {
+		delivery.Error = delivery.Error[:maxDeliveryErrorLength]
+	}
+
+	if err := db.Create(&delivery).Error; err != nil {
+		return fmt.Errorf("RecordWebhookDelivery error: %w", classify(err))
+	}
+
+	return nil
+}
+
+func (msql *MySQLDB) MarkWebhookSucceeded(ctx context.Context, id uuid.UUID) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	err := db.Model(&WebhookSubscription{}).Where("id = ?", id).Update("consecutive_failures", 0).Error
+	if err != nil {
+		return fmt.Errorf("MarkWebhookSucceeded error: %w", classify(err))
+	}
+
+	return nil
+}
+
+// MarkWebhookFailed counts a delivery that exhausted its retries and disables
+// the subscription once disableAfter deliveries in a row have failed.
+func (msql *MySQLDB) MarkWebhookFailed(ctx context.Context, id uuid.UUID, disableAfter int) error {
+	db, cancel := msql.writer(ctx)
+	defer cancel()
+
+	// MySQL evaluates SET assignments left to right, so enabled sees the
+	// incremented counter.
+	err := db.Exec(
+		"UPDATE webhook_subscriptions SET consecutive_failures = consecutive_failures + 1, "+
+			"enabled = enabled AND consecutive_failures < ?, updated_at = ? WHERE id = ?",
+		disableAfter, time.Now(), id,
+	).Error
+	if err != nil {
+		return fmt.Errorf("MarkWebhookFailed error: %w", classify(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,391 @@
+package eventsender
+
+import (
+	"bufio"
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/metrics"
+	"companies/cmd/internal/structs"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+const (
+	OnQueueFullBlock = "block"
+	OnQueueFullDrop  = "drop"
+	OnQueueFullSpill = "spill"
+
+	defaultQueueSize         = 10000
+	defaultBatchSize         = 100
+	defaultLingerMs          = 5
+	defaultRetryBackoffMs    = 100
+	defaultMaxRetryBackoffMs = 5000
+	flushTimeoutMs           = 10000
+	spillFileName            = "spill.ndjson"
+)
+
+var (
+	ErrQueueFull    = errors.New("event queue is full")
+	ErrSenderClosed = errors.New("event sender is closed")
+)
+
+// DeliveryConfirmer is implemented by senders whose PublishEvent returns
+// before the broker confirmed the event.
+type DeliveryConfirmer interface {
+	// Confirmed publishes over the same connection, but waits for the
+	// delivery report.
+	Confirmed() EventSender
+}
+
+// Confirmed returns a sender that only succeeds once the event is delivered,
+// as the outbox relay needs before marking it sent.
+func Confirmed(s EventSender) EventSender {
+	if c, ok := s.(DeliveryConfirmer); ok {
+		return c.Confirmed()
+	}
+	return s
+}
+
+type envelope struct {
+	Topic   string         `json:"topic"`
+	Key     []byte         `json:"key,omitempty"`
+	Headers []kafka.Header `json:"headers,omitempty"`
+	Value   []byte         `json:"value"`
+}
+
+// asyncSender queues events in memory and publishes them from a background
+// worker in batches, so PublishEvent doesn't wait for the broker. Events the
+// worker gives up on are lost, callers that must know use Confirmed.
+type asyncSender struct {
+	producer Producer
+	metrics  *metrics.Metrics
+	logger   *slog.Logger
+	queue    chan envelope
+	spill    *spillFile
+	// confirmed publishes synchronously over the same producer.
+	confirmed *sender
+
+	batchSize    int
+	linger       time.Duration
+	retries      int
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+	onQueueFull  string
+
+	mu        sync.RWMutex
+	closed    bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func positiveOr(value, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func newAsyncSender(producer Producer, config configparser.Kafka, m *metrics.Metrics, logger *slog.Logger) *asyncSender {
+	s := &asyncSender{
+		producer:     producer,
+		metrics:      m,
+		logger:       logger,
+		queue:        make(chan envelope, positiveOr(configparser.GetCfgValue("KAFKA_QUEUE_SIZE", config.QueueSize), defaultQueueSize)),
+		batchSize:    positiveOr(configparser.GetCfgValue("KAFKA_BATCH_SIZE", config.BatchSize), defaultBatchSize),
+		linger:       time.Duration(positiveOr(configparser.GetCfgValue("KAFKA_LINGER_MS", config.LingerMs), defaultLingerMs)) * time.Millisecond,
+		retries:      max(configparser.GetCfgValue("KAFKA_RETRIES", config.Retries), 0),
+		retryBackoff: time.Duration(positiveOr(configparser.GetCfgValue("KAFKA_RETRY_BACKOFF_MS", config.RetryBackoffMs), defaultRetryBackoffMs)) * time.Millisecond,
+		maxBackoff:   time.Duration(positiveOr(configparser.GetCfgValue("KAFKA_MAX_RETRY_BACKOFF_MS", config.MaxRetryBackoffMs), defaultMaxRetryBackoffMs)) * time.Millisecond,
+		onQueueFull:  configparser.GetCfgValue("KAFKA_ON_QUEUE_FULL", config.OnQueueFull),
+		done:         make(chan struct{}),
+	}
+
+	if s.onQueueFull == "" {
+		s.onQueueFull = OnQueueFullBlock
+	}
+
+	if s.onQueueFull == OnQueueFullSpill {
+		spillDir := configparser.GetCfgValue("KAFKA_SPILL_DIR", config.SpillDir)
+		if spillDir == "" {
+			spillDir = os.TempDir()
+		}
+		s.spill = &spillFile{path: filepath.Join(spillDir, spillFileName), logger: logger}
+	}
+
+	go s.run()
+
+	return s
+}
+
+// PublishEvent only queues the event, ctx bounds the wait for queue space when
+// the queue is full and blocking. Delivery happens in the background and
+// isn't cancelled with ctx, so the publish span only covers queueing.
+func (s *asyncSender) PublishEvent(ctx context.Context, topic string, event structs.Event) (err error) {
+	ctx, span := startPublishSpan(ctx, topic, &event)
+	defer func() { endPublishSpan(span, err) }()
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return ErrSenderClosed
+	}
+
+	env := envelope{Topic: topic, Key: messageKey(event), Headers: messageHeaders(event), Value: value}
+
+	select {
+	case s.queue <- env:
+		s.metrics.EventsQueueDepth.Set(float64(len(s.queue)))
+		return nil
+	default:
+	}
+
+	switch s.onQueueFull {
+	case OnQueueFullDrop:
+		s.metrics.EventsDroppedTotal.WithLabelValues(topic, OnQueueFullDrop).Inc()
+		return ErrQueueFull
+	case OnQueueFullSpill:
+		if err := s.spill.push(env); err != nil {
+			s.metrics.EventsFailedTotal.WithLabelValues(topic).Inc()
+			return err
+		}
+		s.metrics.EventsSpilledTotal.WithLabelValues(topic).Inc()
+		return nil
+	default:
+		select {
+		case s.queue <- env:
+			s.metrics.EventsQueueDepth.Set(float64(len(s.queue)))
+			return nil
+		case <-ctx.Done():
+			s.metrics.EventsFailedTotal.WithLabelValues(topic).Inc()
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *asyncSender) Confirmed() EventSender {
+	return s.confirmed
+}
+
+func (s *asyncSender) run() {
+	defer close(s.done)
+
+	batch := make([]envelope, 0, s.batchSize)
+	timer := time.NewTimer(s.linger)
+	defer timer.Stop()
+
+	for {
+		select {
+		case env, ok := <-s.queue:
+			s.metrics.EventsQueueDepth.Set(float64(len(s.queue)))
+			if !ok {
+				s.publishBatch(batch)
+				s.replaySpill()
+				return
+			}
+
+			batch = append(batch, env)
+			if len(batch) >= s.batchSize {
+				s.publishBatch(batch)
+				batch = batch[:0]
+			}
+
+		case <-timer.C:
+			if len(batch) > 0 {
+				s.publishBatch(batch)
+				batch = batch[:0]
+			} else {
+				s.replaySpill()
+			}
+			timer.Reset(s.linger)
+		}
+	}
+}
+
+func (s *asyncSender) replaySpill() {
+	if s.spill == nil {
+		return
+	}
+
+	spilled, err := s.spill.popAll()
+	if err != nil {
+		s.logger.Error("Failed to read spilled events", logging.Err(err))
+		return
+	}
+
+	for start := 0; start < len(spilled); start += s.batchSize {
+		s.publishBatch(spilled[start:min(start+s.batchSize, len(spilled))])
+	}
+}
+
+func (s *asyncSender) backoff(attempt int) time.Duration {
+	delay := s.retryBackoff << attempt
+	if delay <= 0 || delay > s.maxBackoff {
+		return s.maxBackoff
+	}
+	return delay
+}
+
+// publishBatch produces the whole batch, waits for the delivery reports and
+// retries the failed messages with exponential backoff.
+func (s *asyncSender) publishBatch(batch []envelope) {
+	pending := batch
+
+	for attempt := 0; len(pending) > 0; attempt++ {
+		pending = s.produce(pending)
+
+		if len(pending) == 0 {
+			return
+		}
+
+		if attempt >= s.retries {
+			break
+		}
+
+		time.Sleep(s.backoff(attempt))
+	}
+
+	for _, env := range pending {
+		s.logger.Error("Giving up on event", slog.String("topic", env.Topic))
+		s.metrics.EventsFailedTotal.WithLabelValues(env.Topic).Inc()
+	}
+}
+
+func (s *asyncSender) produce(batch []envelope) []envelope {
+	deliveryChan := make(chan kafka.Event, len(batch))
+	start := time.Now()
+	var failed []envelope
+	inFlight := 0
+
+	for _, env := range batch {
+		topic := env.Topic
+		msg := &kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+			Key:            env.Key,
+			Headers:        env.Headers,
+			Value:          env.Value,
+			Opaque:         env,
+		}
+
+		if err := s.producer.Produce(msg, deliveryChan); err != nil {
+			s.logger.Warn("Produce failed", slog.String("topic", env.Topic), logging.Err(err))
+			failed = append(failed, env)
+			continue
+		}
+
+		inFlight++
+	}
+
+	for range inFlight {
+		e := <-deliveryChan
+		m, ok := e.(*kafka.Message)
+		if !ok {
+			continue
+		}
+
+		env, _ := m.Opaque.(envelope)
+
+		if m.TopicPartition.Error != nil {
+			s.logger.Warn("Delivery failed", slog.String("topic", env.Topic), logging.Err(m.TopicPartition.Error))
+			failed = append(failed, env)
+			continue
+		}
+
+		s.metrics.EventsPublishedTotal.WithLabelValues(env.Topic).Inc()
+		s.metrics.EventsPublishDuration.WithLabelValues(env.Topic).Observe(time.Since(start).Seconds())
+	}
+
+	return failed
+}
+
+// Close stops accepting events, publishes everything still queued and
+// flushes the producer.
+func (s *asyncSender) Close() (err error) {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		close(s.queue)
+		s.mu.Unlock()
+
+		<-s.done
+
+		if remaining := s.producer.Flush(flushTimeoutMs); remaining > 0 {
+			err = fmt.Errorf("%d messages not delivered before close", remaining)
+		}
+		s.producer.Close()
+	})
+
+	return err
+}
+
+// spillFile keeps events that didn't fit in the queue as NDJSON on disk until
+// the worker has time to publish them.
+type spillFile struct {
+	mu     sync.Mutex
+	path   string
+	logger *slog.Logger
+}
+
+func (f *spillFile) push(env envelope) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(env)
+}
+
+func (f *spillFile) popAll() ([]envelope, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.Open(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var envs []envelope
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var env envelope
+		if err := json.Unmarshal(scanner.Bytes(), &env); err != nil {
+			f.logger.Warn("Skipping malformed spilled event", logging.Err(err))
+			continue
+		}
+		envs = append(envs, env)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return envs, os.Remove(f.path)
+}
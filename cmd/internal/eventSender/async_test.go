@@ -0,0 +1,141 @@
+package eventsender
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/tests/mocks"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func deliver(err error) func(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+	return func(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+		deliveryChan <- &kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: msg.TopicPartition.Topic, Error: err},
+			Value:          msg.Value,
+			Opaque:         msg.Opaque,
+		}
+		return nil
+	}
+}
+
+func TestAsyncSender_PublishesInBackground(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockProducer := mocks.NewMockProducer(ctrl)
+	produced := make(chan struct{}, 2)
+
+	mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+			produced <- struct{}{}
+			return deliver(nil)(msg, deliveryChan)
+		}).Times(2)
+	mockProducer.EXPECT().Flush(gomock.Any()).Return(0)
+	mockProducer.EXPECT().Close()
+
+	s := newAsyncSender(mockProducer, configparser.Kafka{BatchSize: 2, LingerMs: 1}, newTestMetrics(t), testLogger)
+
+	require.NoError(t, s.PublishEvent(context.Background(), "test-topic", dummyEvent))
+	require.NoError(t, s.PublishEvent(context.Background(), "test-topic", dummyEvent))
+
+	for range 2 {
+		select {
+		case <-produced:
+		case <-time.After(time.Second):
+			t.Fatal("event was not produced")
+		}
+	}
+
+	require.NoError(t, s.Close())
+	require.ErrorIs(t, s.PublishEvent(context.Background(), "test-topic", dummyEvent), ErrSenderClosed)
+}
+
+func TestAsyncSender_RetriesFailedDeliveries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockProducer := mocks.NewMockProducer(ctrl)
+
+	gomock.InOrder(
+		mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).Return(errors.New("local queue full")),
+		mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).DoAndReturn(deliver(errors.New("broker down"))),
+		mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).DoAndReturn(deliver(nil)),
+	)
+
+	s := &asyncSender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger, retries: 2, retryBackoff: time.Millisecond, maxBackoff: time.Millisecond}
+
+	s.publishBatch([]envelope{{Topic: "test-topic", Value: []byte("{}")}})
+}
+
+func TestAsyncSender_GivesUpAfterRetries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockProducer := mocks.NewMockProducer(ctrl)
+	mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).DoAndReturn(deliver(errors.New("broker down"))).Times(2)
+
+	s := &asyncSender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger, retries: 1, retryBackoff: time.Millisecond, maxBackoff: time.Millisecond}
+
+	s.publishBatch([]envelope{{Topic: "test-topic", Value: []byte("{}")}})
+}
+
+func TestAsyncSender_ConfirmedWaitsForDelivery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockProducer := mocks.NewMockProducer(ctrl)
+	mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).DoAndReturn(deliver(errors.New("broker down")))
+
+	s := &asyncSender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger, queue: make(chan envelope, 1)}
+	s.confirmed = &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger}
+
+	// A failed delivery reaches the caller instead of the background worker.
+	require.Error(t, Confirmed(s).PublishEvent(context.Background(), "test-topic", dummyEvent))
+	require.Empty(t, s.queue)
+
+	synchronous := &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger}
+	require.Same(t, synchronous, Confirmed(synchronous))
+}
+
+func TestAsyncSender_Backoff(t *testing.T) {
+	s := &asyncSender{retryBackoff: 100 * time.Millisecond, maxBackoff: time.Second}
+
+	require.Equal(t, 100*time.Millisecond, s.backoff(0))
+	require.Equal(t, 400*time.Millisecond, s.backoff(2))
+	require.Equal(t, time.Second, s.backoff(5))
+}
+
+func TestAsyncSender_DropWhenQueueFull(t *testing.T) {
+	s := &asyncSender{metrics: newTestMetrics(t), queue: make(chan envelope, 1), onQueueFull: OnQueueFullDrop}
+
+	require.NoError(t, s.PublishEvent(context.Background(), "test-topic", dummyEvent))
+	require.ErrorIs(t, s.PublishEvent(context.Background(), "test-topic", dummyEvent), ErrQueueFull)
+	require.Equal(t, 1.0, testutil.ToFloat64(s.metrics.EventsDroppedTotal.WithLabelValues("test-topic", OnQueueFullDrop)))
+}
+
+func TestAsyncSender_SpillWhenQueueFull(t *testing.T) {
+	path := filepath.Join(t.TempDir(), spillFileName)
+	s := &asyncSender{metrics: newTestMetrics(t), queue: make(chan envelope, 1), onQueueFull: OnQueueFullSpill, spill: &spillFile{path: path, logger: testLogger}}
+
+	require.NoError(t, s.PublishEvent(context.Background(), "test-topic", dummyEvent))
+	require.NoError(t, s.PublishEvent(context.Background(), "test-topic", dummyEvent))
+	require.NoError(t, s.PublishEvent(context.Background(), "other-topic", dummyEvent))
+
+	spilled, err := s.spill.popAll()
+	require.NoError(t, err)
+	require.Len(t, spilled, 2)
+	require.Equal(t, "other-topic", spilled[1].Topic)
+
+	// Spilled events are published later, they aren't dropped.
+	require.Equal(t, 1.0, testutil.ToFloat64(s.metrics.EventsSpilledTotal.WithLabelValues("other-topic")))
+	require.Zero(t, testutil.CollectAndCount(s.metrics.EventsDroppedTotal))
+
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,33 @@
+package eventsender
+
+import (
+	"companies/cmd/internal/structs"
+	"context"
+	"errors"
+)
+
+type fanout struct {
+	senders []EventSender
+}
+
+// NewFanout publishes every event to all senders, nil senders are skipped.
+// Every sender is tried even if an earlier one fails, the failures are joined.
+func NewFanout(senders ...EventSender) EventSender {
+	f := &fanout{}
+	for _, s := range senders {
+		if s != nil {
+			f.senders = append(f.senders, s)
+		}
+	}
+	return f
+}
+
+func (f *fanout) PublishEvent(ctx context.Context, topic string, event structs.Event) error {
+	var errs []error
+	for _, s := range f.senders {
+		if err := s.PublishEvent(ctx, topic, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,82 @@
+package eventsender
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/metrics"
+	"companies/cmd/internal/structs"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+func init() {
+	RegisterTransport(TransportFile, func(config configparser.Config, _ *metrics.Metrics, _ *slog.Logger) (Transport, error) {
+		return NewFileTransport(configparser.GetCfgValue("EVENTS_FILE_PATH", config.Events.File.Path))
+	})
+}
+
+type fileRecord struct {
+	Topic string        `json:"topic"`
+	Event structs.Event `json:"event"`
+}
+
+// FileTransport appends every event as one JSON line to a file.
+type FileTransport struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileTransport(path string) (*FileTransport, error) {
+	if path == "" {
+		return nil, errors.New("file transport requires a path")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileTransport{file: file}, nil
+}
+
+func (t *FileTransport) PublishEvent(ctx context.Context, topic string, event structs.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(fileRecord{Topic: topic, Event: event})
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil {
+		return ErrSenderClosed
+	}
+
+	_, err = t.file.Write(append(line, '\n'))
+	return err
+}
+
+func (t *FileTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == nil {
+		return nil
+	}
+
+	err := t.file.Close()
+	t.file = nil
+	return err
+}
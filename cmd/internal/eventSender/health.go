@@ -0,0 +1,46 @@
+package eventsender
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const defaultHealthTimeoutMs = 1000
+
+// HealthChecker is implemented by the transports whose dependencies can be
+// probed, the Kafka ones ask the broker for metadata.
+type HealthChecker interface {
+	CheckHealth(context.Context) error
+}
+
+func checkBroker(ctx context.Context, producer Producer) error {
+	timeoutMs := defaultHealthTimeoutMs
+	if deadline, ok := ctx.Deadline(); ok {
+		timeoutMs = int(time.Until(deadline).Milliseconds())
+	}
+
+	if timeoutMs <= 0 {
+		return context.DeadlineExceeded
+	}
+
+	if _, err := producer.GetMetadata(nil, false, timeoutMs); err != nil {
+		return fmt.Errorf("kafka metadata: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sender) CheckHealth(ctx context.Context) error {
+	return checkBroker(ctx, s.producer)
+}
+
+// CheckHealth fails when the broker can't be reached or the queue is full, in
+// which case new events block, get dropped or spill to disk.
+func (s *asyncSender) CheckHealth(ctx context.Context) error {
+	if len(s.queue) >= cap(s.queue) {
+		return ErrQueueFull
+	}
+
+	return checkBroker(ctx, s.producer)
+}
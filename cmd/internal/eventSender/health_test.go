@@ -0,0 +1,46 @@
+package eventsender
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"companies/cmd/tests/mocks"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSender_CheckHealth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockProducer := mocks.NewMockProducer(ctrl)
+	mockProducer.EXPECT().GetMetadata(nil, false, gomock.Any()).Return(&kafka.Metadata{}, nil)
+	mockProducer.EXPECT().GetMetadata(nil, false, gomock.Any()).Return(nil, errors.New("broker down"))
+
+	s := &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, s.CheckHealth(ctx))
+	require.ErrorContains(t, s.CheckHealth(ctx), "broker down")
+}
+
+func TestSender_CheckHealth_DeadlinePassed(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	s := &sender{producer: mocks.NewMockProducer(gomock.NewController(t)), metrics: newTestMetrics(t), logger: testLogger}
+
+	require.ErrorIs(t, s.CheckHealth(ctx), context.DeadlineExceeded)
+}
+
+func TestAsyncSender_CheckHealth_QueueFull(t *testing.T) {
+	s := &asyncSender{metrics: newTestMetrics(t), queue: make(chan envelope, 1), logger: testLogger}
+	s.queue <- envelope{Topic: "test-topic"}
+
+	require.ErrorIs(t, s.CheckHealth(context.Background()), ErrQueueFull)
+}
@@ -0,0 +1,80 @@
+package eventsender
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/metrics"
+	"companies/cmd/internal/structs"
+	"context"
+	"log/slog"
+	"sync"
+)
+
+const defaultMemoryBuffer = 1000
+
+func init() {
+	RegisterTransport(TransportMemory, func(config configparser.Config, m *metrics.Metrics, _ *slog.Logger) (Transport, error) {
+		return NewMemoryTransport(configparser.GetCfgValue("EVENTS_MEMORY_BUFFER", config.Events.Memory.Buffer), m), nil
+	})
+}
+
+type Message struct {
+	Topic string
+	Event structs.Event
+}
+
+// MemoryTransport delivers events to a channel in the same process. It is
+// meant for tests and local runs without a broker. When the buffer is full the
+// oldest message is dropped, so publishing never waits for a reader.
+type MemoryTransport struct {
+	metrics  *metrics.Metrics
+	mu       sync.Mutex
+	closed   bool
+	messages chan Message
+}
+
+func NewMemoryTransport(buffer int, m *metrics.Metrics) *MemoryTransport {
+	return &MemoryTransport{metrics: m, messages: make(chan Message, positiveOr(buffer, defaultMemoryBuffer))}
+}
+
+func (t *MemoryTransport) PublishEvent(_ context.Context, topic string, event structs.Event) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return ErrSenderClosed
+	}
+
+	message := Message{Topic: topic, Event: event}
+	for {
+		select {
+		case t.messages <- message:
+			return nil
+		default:
+		}
+
+		// Only publishers holding mu fill the channel, so after dropping the
+		// oldest the next send finds room unless a reader raced us.
+		select {
+		case dropped := <-t.messages:
+			t.metrics.EventsDroppedTotal.WithLabelValues(dropped.Topic, OnQueueFullDrop).Inc()
+		default:
+		}
+	}
+}
+
+// Messages is closed when the transport is closed.
+func (t *MemoryTransport) Messages() <-chan Message {
+	return t.messages
+}
+
+func (t *MemoryTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.closed {
+		t.closed = true
+		close(t.messages)
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package eventsender
+
+import (
+	"bufio"
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/metrics"
+	"companies/cmd/internal/structs"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNATSURL       = "nats://localhost:4222"
+	defaultNATSTimeoutMs = 5000
+)
+
+func init() {
+	RegisterTransport(TransportNATS, func(config configparser.Config, _ *metrics.Metrics, logger *slog.Logger) (Transport, error) {
+		natsURL := configparser.GetCfgValue("EVENTS_NATS_URL", config.Events.NATS.URL)
+		timeoutMs := configparser.GetCfgValue("EVENTS_NATS_TIMEOUT_MS", config.Events.NATS.TimeoutMs)
+
+		if natsURL == "" {
+			natsURL = defaultNATSURL
+		}
+
+		return NewNATSTransport(natsURL, time.Duration(positiveOr(timeoutMs, defaultNATSTimeoutMs))*time.Millisecond, logger)
+	})
+}
+
+// natsInfo is the part of the server's INFO message the transport uses.
+type natsInfo struct {
+	Headers    bool  `json:"headers"`
+	MaxPayload int64 `json:"max_payload"`
+}
+
+// NATSTransport publishes every event to the NATS subject named like the
+// topic, speaking the core NATS protocol. A publish succeeds once the server
+// answered the PING sent after it, so the server has accepted the message.
+// The connection is opened on first use and again after any error.
+type NATSTransport struct {
+	addr     string
+	user     string
+	password string
+	timeout  time.Duration
+	logger   *slog.Logger
+
+	mu     sync.Mutex
+	closed bool
+	conn   net.Conn
+	reader *bufio.Reader
+	info   natsInfo
+}
+
+func NewNATSTransport(rawURL string, timeout time.Duration, logger *slog.Logger) (*NATSTransport, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("nats transport url: %w", err)
+	}
+	if u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("nats transport url %q must look like nats://host:port", rawURL)
+	}
+
+	t := &NATSTransport{addr: u.Host, timeout: timeout, logger: logger}
+	if u.Port() == "" {
+		t.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil {
+		t.user = u.User.Username()
+		t.password, _ = u.User.Password()
+	}
+
+	return t, nil
+}
+
+func (t *NATSTransport) PublishEvent(ctx context.Context, topic string, event structs.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return ErrSenderClosed
+	}
+
+	deadline := time.Now().Add(t.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	if err := t.publish(deadline, topic, event, payload); err != nil {
+		// The connection is in an unknown state, start over on the next
+		// publish.
+		t.disconnect()
+		return fmt.Errorf("nats publish: %w", err)
+	}
+
+	return nil
+}
+
+func (t *NATSTransport) publish(deadline time.Time, topic string, event structs.Event, payload []byte) error {
+	if t.conn == nil {
+		if err := t.connect(deadline); err != nil {
+			return err
+		}
+	}
+
+	if err := t.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	if t.info.MaxPayload > 0 && int64(len(payload)) > t.info.MaxPayload {
+		return fmt.Errorf("event of %d bytes exceeds the server's max_payload of %d", len(payload), t.info.MaxPayload)
+	}
+
+	var msg strings.Builder
+	if t.info.Headers {
+		headers := natsHeaders(event)
+		fmt.Fprintf(&msg, "HPUB %s %d %d\r\n%s%s\r\n", topic, len(headers), len(headers)+len(payload), headers, payload)
+	} else {
+		fmt.Fprintf(&msg, "PUB %s %d\r\n%s\r\n", topic, len(payload), payload)
+	}
+	msg.WriteString("PING\r\n")
+
+	if _, err := t.conn.Write([]byte(msg.String())); err != nil {
+		return err
+	}
+
+	return t.awaitPong()
+}
+
+// natsHeaders carries the same metadata as the Kafka headers.
+func natsHeaders(event structs.Event) string {
+	var b strings.Builder
+	b.WriteString("NATS/1.0\r\n")
+	for _, header := range messageHeaders(event) {
+		fmt.Fprintf(&b, "%s: %s\r\n", header.Key, header.Value)
+	}
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+func (t *NATSTransport) connect(deadline time.Time) error {
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.Dial("tcp", t.addr)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	t.conn, t.reader = conn, bufio.NewReader(conn)
+
+	line, err := t.readLine()
+	if err != nil {
+		return err
+	}
+	info, ok := strings.CutPrefix(line, "INFO ")
+	if !ok {
+		return fmt.Errorf("expected INFO from the server, got %q", line)
+	}
+	if err := json.Unmarshal([]byte(info), &t.info); err != nil {
+		return fmt.Errorf("server INFO: %w", err)
+	}
+
+	connectOptions := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"headers":  t.info.Headers,
+		"name":     "companies",
+		"lang":     "go",
+	}
+	if t.user != "" {
+		connectOptions["user"], connectOptions["pass"] = t.user, t.password
+	}
+
+	options, err := json.Marshal(connectOptions)
+	if err != nil {
+		return err
+	}
+
+	// The PING makes the server report a refused CONNECT right away.
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", options); err != nil {
+		return err
+	}
+	if err := t.awaitPong(); err != nil {
+		return err
+	}
+
+	t.logger.Info("Connected to NATS", slog.String("addr", t.addr))
+	return nil
+}
+
+// awaitPong reads until the server answers our PING, answering its own.
+func (t *NATSTransport) awaitPong() error {
+	for {
+		line, err := t.readLine()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := t.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case line == "+OK", strings.HasPrefix(line, "INFO "):
+		default:
+			return fmt.Errorf("unexpected message from the server: %q", line)
+		}
+	}
+}
+
+func (t *NATSTransport) readLine() (string, error) {
+	line, err := t.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (t *NATSTransport) disconnect() {
+	if t.conn != nil {
+		t.conn.Close()
+		t.conn, t.reader = nil, nil
+	}
+}
+
+func (t *NATSTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	t.disconnect()
+
+	return nil
+}
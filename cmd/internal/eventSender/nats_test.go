@@ -0,0 +1,142 @@
+package eventsender
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"companies/cmd/internal/structs"
+
+	"github.com/stretchr/testify/require"
+)
+
+type natsPublish struct {
+	subject string
+	headers string
+	payload []byte
+}
+
+// fakeNATSServer speaks enough of the NATS protocol for one client and hands
+// every published message to the test.
+func fakeNATSServer(t *testing.T, info string) (string, <-chan natsPublish) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	published := make(chan natsPublish, 10)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		fmt.Fprintf(conn, "INFO %s\r\n", info)
+		reader := bufio.NewReader(conn)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+
+			switch fields[0] {
+			case "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+			case "PUB", "HPUB":
+				total, _ := strconv.Atoi(fields[len(fields)-1])
+				body := make([]byte, total+2)
+				if _, err := io.ReadFull(reader, body); err != nil {
+					return
+				}
+
+				msg := natsPublish{subject: fields[1], payload: body[:total]}
+				if fields[0] == "HPUB" {
+					headerLen, _ := strconv.Atoi(fields[2])
+					msg.headers, msg.payload = string(body[:headerLen]), body[headerLen:total]
+				}
+				published <- msg
+			}
+		}
+	}()
+
+	return "nats://" + listener.Addr().String(), published
+}
+
+func TestNATSTransport_PublishesWithHeaders(t *testing.T) {
+	url, published := fakeNATSServer(t, `{"headers":true,"max_payload":1048576}`)
+
+	transport, err := NewNATSTransport(url, time.Second, testLogger)
+	require.NoError(t, err)
+	t.Cleanup(func() { transport.Close() })
+
+	event := dummyEvent
+	event.CorrelationID = "req-1"
+	require.NoError(t, transport.PublishEvent(context.Background(), "test-topic", event))
+
+	msg := <-published
+	require.Equal(t, "test-topic", msg.subject)
+	require.Contains(t, msg.headers, "NATS/1.0\r\n")
+	require.Contains(t, msg.headers, HeaderEventType+": "+event.Type+"\r\n")
+	require.Contains(t, msg.headers, HeaderCorrelationID+": req-1\r\n")
+
+	var got structs.Event
+	require.NoError(t, json.Unmarshal(msg.payload, &got))
+	require.Equal(t, event.ID, got.ID)
+
+	require.NoError(t, transport.Close())
+	require.ErrorIs(t, transport.PublishEvent(context.Background(), "test-topic", event), ErrSenderClosed)
+}
+
+func TestNATSTransport_WithoutHeaderSupport(t *testing.T) {
+	url, published := fakeNATSServer(t, `{}`)
+
+	transport, err := NewNATSTransport(url, time.Second, testLogger)
+	require.NoError(t, err)
+	t.Cleanup(func() { transport.Close() })
+
+	require.NoError(t, transport.PublishEvent(context.Background(), "test-topic", dummyEvent))
+
+	msg := <-published
+	require.Empty(t, msg.headers)
+	require.True(t, json.Valid(msg.payload))
+}
+
+func TestNATSTransport_ServerError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		fmt.Fprint(conn, "INFO {}\r\n-ERR 'Authorization Violation'\r\n")
+		io.Copy(io.Discard, conn)
+	}()
+
+	transport, err := NewNATSTransport("nats://"+listener.Addr().String(), time.Second, testLogger)
+	require.NoError(t, err)
+	t.Cleanup(func() { transport.Close() })
+
+	require.ErrorContains(t, transport.PublishEvent(context.Background(), "test-topic", dummyEvent), "Authorization Violation")
+}
+
+func TestNewNATSTransport_InvalidURL(t *testing.T) {
+	_, err := NewNATSTransport("http://localhost:4222", time.Second, testLogger)
+	require.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package eventsender
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/metrics"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+const (
+	TransportKafka   = "kafka"
+	TransportMemory  = "memory"
+	TransportFile    = "file"
+	TransportWebhook = "webhook"
+	TransportNATS    = "nats"
+)
+
+// TransportFactory creates a transport, counting what it publishes on the
+// metrics.
+type TransportFactory func(configparser.Config, *metrics.Metrics, *slog.Logger) (Transport, error)
+
+var (
+	transportsMu sync.RWMutex
+	transports   = map[string]TransportFactory{}
+)
+
+// RegisterTransport makes a transport selectable by name through the
+// events.transport config value. Registering a name twice replaces it.
+func RegisterTransport(name string, factory TransportFactory) {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	transports[name] = factory
+}
+
+func Transports() []string {
+	transportsMu.RLock()
+	defer transportsMu.RUnlock()
+
+	names := make([]string, 0, len(transports))
+	for name := range transports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func NewTransport(config configparser.Config, m *metrics.Metrics, logger *slog.Logger) (Transport, error) {
+	name := configparser.GetCfgValue("EVENTS_TRANSPORT", config.Events.Transport)
+	if name == "" {
+		name = TransportKafka
+	}
+
+	transportsMu.RLock()
+	factory, ok := transports[name]
+	transportsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown event transport %q, available: %v", name, Transports())
+	}
+
+	return factory(config, m, logger)
+}
+
+func NewEventSender(config configparser.Config, m *metrics.Metrics, logger *slog.Logger) (Transport, error) {
+	logger.Info("Starting EventSender")
+
+	transport, err := NewTransport(config, m, logger)
+	if err != nil {
+		return nil, fmt.Errorf("create event transport: %w", err)
+	}
+
+	return transport, nil
+}
@@ -2,48 +2,97 @@ package eventsender
 
 import (
 	configparser "companies/cmd/internal/configParser"
-	"companies/cmd/internal/consts"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/metrics"
 	"companies/cmd/internal/structs"
+	"companies/cmd/internal/tracing"
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
 	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	HeaderContentType   = "content-type"
+	HeaderEventType     = "ce_type"
+	HeaderEventID       = "ce_id"
+	HeaderCorrelationID = "correlation-id"
+	HeaderTraceParent   = "traceparent"
+	HeaderTraceState    = "tracestate"
+
+	defaultPublishTimeoutMs = 10000
+	kafkaTracerName         = "companies/kafka"
 )
 
 //go:generate mockgen -source=sender.go -destination=../../tests/mocks/mock_event_sender.go -package=mocks
 type EventSender interface {
-	PublishEvent(string, structs.Event) error
+	PublishEvent(context.Context, string, structs.Event) error
+}
+
+// Transport is an EventSender that owns a connection and must be closed.
+type Transport interface {
+	EventSender
+	io.Closer
 }
 
 type Producer interface {
 	Produce(msg *kafka.Message, deliveryChan chan kafka.Event) error
 	GetMetadata(topic *string, allTopics bool, timeoutMs int) (*kafka.Metadata, error)
+	Flush(timeoutMs int) int
 	Close()
 }
 
 type sender struct {
 	producer Producer
+	metrics  *metrics.Metrics
+	logger   *slog.Logger
+	timeout  time.Duration
+}
+
+func init() {
+	RegisterTransport(TransportKafka, newKafkaTransport)
 }
 
-func NewEventSender(config configparser.Kafka) EventSender {
-	log.Println(consts.ApplicationPrefix, "Starting EventSender")
+func newKafkaTransport(cfg configparser.Config, m *metrics.Metrics, logger *slog.Logger) (Transport, error) {
+	config := cfg.Kafka
 
 	brokerAddr := configparser.GetCfgValue("KAFKA_BROKER", config.Broker)
+	acks := configparser.GetCfgValue("KAFKA_ACKS", config.Acks)
+	lingerMs := configparser.GetCfgValue("KAFKA_LINGER_MS", config.LingerMs)
+
+	kafkaConfig := kafka.ConfigMap{"bootstrap.servers": brokerAddr}
+	if acks != "" {
+		kafkaConfig["acks"] = acks
+	}
+	if lingerMs > 0 {
+		kafkaConfig["linger.ms"] = lingerMs
+	}
 
-	p, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": brokerAddr})
+	p, err := kafka.NewProducer(&kafkaConfig)
 	if err != nil {
-		log.Println(consts.ApplicationPrefix, "Failed to create producer: ", err)
-		return nil
+		return nil, fmt.Errorf("failed to create producer: %w", err)
 	}
 
-	s := sender{p}
+	publishTimeoutMs := configparser.GetCfgValue("KAFKA_PUBLISH_TIMEOUT_MS", config.PublishTimeoutMs)
+	s := sender{producer: p, metrics: m, logger: logger, timeout: time.Duration(positiveOr(publishTimeoutMs, defaultPublishTimeoutMs)) * time.Millisecond}
 
 	s.waitRediness()
 
-	return &s
+	if configparser.GetCfgValue("KAFKA_ASYNC", config.Async) {
+		async := newAsyncSender(p, config, m, logger)
+		async.confirmed = &s
+		return async, nil
+	}
+
+	return &s, nil
 }
 
 func (s *sender) waitRediness() {
@@ -53,50 +102,154 @@ func (s *sender) waitRediness() {
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println(consts.ApplicationPrefix, "Timeout waiting for Kafka to be ready")
+			s.logger.Warn("Timeout waiting for Kafka to be ready")
 			return
 		default:
 			_, err := s.producer.GetMetadata(nil, false, 1000)
 			if err == nil {
-				log.Println(consts.ApplicationPrefix, "Kafka is ready")
+				s.logger.Info("Kafka is ready")
 				return
 			}
-			log.Println(consts.ApplicationPrefix, "Waiting for Kafka broker...")
+			s.logger.Info("Waiting for Kafka broker...")
 			time.Sleep(500 * time.Millisecond)
 		}
 	}
 }
 
-func (s *sender) PublishEvent(topic string, event structs.Event) error {
-	deliveryChan := make(chan kafka.Event)
+// messageKey partitions events by company, so changes to one company are
+// consumed in the order they were made.
+func messageKey(event structs.Event) []byte {
+	if event.Subject == "" {
+		return nil
+	}
+	return []byte(event.Subject)
+}
+
+// messageHeaders lets consumers route and filter without parsing the body.
+func messageHeaders(event structs.Event) []kafka.Header {
+	headers := []kafka.Header{
+		{Key: HeaderContentType, Value: []byte(structs.ContentType)},
+		{Key: HeaderEventType, Value: []byte(event.Type)},
+		{Key: HeaderEventID, Value: []byte(event.ID)},
+	}
+
+	optional := []kafka.Header{
+		{Key: HeaderCorrelationID, Value: []byte(event.CorrelationID)},
+		{Key: HeaderTraceParent, Value: []byte(event.TraceParent)},
+		{Key: HeaderTraceState, Value: []byte(event.TraceState)},
+	}
+
+	for _, header := range optional {
+		if len(header.Value) > 0 {
+			headers = append(headers, header)
+		}
+	}
+
+	return headers
+}
+
+// startPublishSpan starts the producer span of a publish and points the
+// traceparent of event at it, so consumers continue the trace from there.
+// Without a span in ctx, as for events relayed from the outbox, the span is a
+// child of the traceparent the event already has.
+func startPublishSpan(ctx context.Context, topic string, event *structs.Event) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = tracing.Extract(ctx, event.TraceParent, event.TraceState)
+	}
+
+	ctx, span := tracing.Tracer(kafkaTracerName).Start(ctx, "publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingMessageID(event.ID),
+		),
+	)
+
+	event.TraceParent, event.TraceState = tracing.Inject(ctx)
+
+	return ctx, span
+}
+
+func endPublishSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// PublishEvent waits for the delivery report until ctx ends or the publish
+// timeout passes. A message given up on may still be delivered later.
+func (s *sender) PublishEvent(ctx context.Context, topic string, event structs.Event) (err error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ctx, span := startPublishSpan(ctx, topic, &event)
+	defer func() { endPublishSpan(span, err) }()
 
 	message, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	deliveryChan := make(chan kafka.Event, 1)
+	start := time.Now()
 
 	err = s.producer.Produce(&kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
-		Value:          []byte(message),
+		Key:            messageKey(event),
+		Headers:        messageHeaders(event),
+		Value:          message,
 	}, deliveryChan)
 
 	if err != nil {
-		log.Fatalf("Produce error: %s", err)
+		s.logger.ErrorContext(ctx, "Produce failed", slog.String("topic", topic), logging.Err(err))
+		s.metrics.EventsFailedTotal.WithLabelValues(topic).Inc()
+		return fmt.Errorf("produce failed: %w", err)
+	}
+
+	var e kafka.Event
+	select {
+	case e = <-deliveryChan:
+	case <-ctx.Done():
+		s.logger.WarnContext(ctx, "Delivery not confirmed", slog.String("topic", topic), logging.Err(ctx.Err()))
+		s.metrics.EventsFailedTotal.WithLabelValues(topic).Inc()
+		return fmt.Errorf("delivery not confirmed: %w", ctx.Err())
 	}
 
-	e := <-deliveryChan
 	m := e.(*kafka.Message)
 
 	if m.TopicPartition.Error != nil {
-		log.Println(consts.ApplicationPrefix, "Delivery failed: ", m.TopicPartition.Error)
+		s.logger.ErrorContext(ctx, "Delivery failed", slog.String("topic", topic), logging.Err(m.TopicPartition.Error))
+		s.metrics.EventsFailedTotal.WithLabelValues(topic).Inc()
 		return errors.New("Delivery failed")
-	} else {
-		log.Println(consts.ApplicationPrefix, "Message delivered to ", m.TopicPartition)
 	}
 
-	close(deliveryChan)
+	s.logger.DebugContext(ctx, "Message delivered", slog.String("topic", topic), slog.Int("partition", int(m.TopicPartition.Partition)), slog.Int64("offset", int64(m.TopicPartition.Offset)))
+	s.metrics.EventsPublishedTotal.WithLabelValues(topic).Inc()
+	s.metrics.EventsPublishDuration.WithLabelValues(topic).Observe(time.Since(start).Seconds())
 
 	return nil
 }
 
+// Close waits for the messages still in flight, those given up on by
+// PublishEvent included, then closes the producer.
 func (s *sender) Close() error {
+	remaining := s.producer.Flush(flushTimeoutMs)
 	s.producer.Close()
+
+	if remaining > 0 {
+		return fmt.Errorf("%d messages not delivered before close", remaining)
+	}
+
 	return nil
 }
@@ -1,29 +1,46 @@
 package eventsender
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"testing"
+	"time"
 
+	"companies/cmd/internal/metrics"
 	"companies/cmd/internal/structs"
 	"companies/cmd/tests/mocks"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
-var dummyEvent = structs.Event{
-	URL:    "/test",
-	Type:   structs.Created,
-	Status: structs.Success,
+var testLogger = slog.New(slog.DiscardHandler)
+
+// newTestMetrics gives every test counts of its own.
+func newTestMetrics(t *testing.T) *metrics.Metrics {
+	t.Helper()
+
+	m, err := metrics.New(prometheus.NewRegistry())
+	require.NoError(t, err)
+	return m
 }
 
+var dummyEvent = structs.NewErrorEvent(structs.CompanyCreateFailed, "", "/test", "invalid data provided")
+
 func TestSender_PublishEvent_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	mockProducer := mocks.NewMockProducer(ctrl)
 
-	s := &sender{producer: mockProducer}
+	s := &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger}
 
 	mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).DoAndReturn(
 		func(msg *kafka.Message, deliveryChan chan kafka.Event) error {
@@ -41,7 +58,7 @@ func TestSender_PublishEvent_Success(t *testing.T) {
 		},
 	)
 
-	err := s.PublishEvent("test-topic", dummyEvent)
+	err := s.PublishEvent(context.Background(), "test-topic", dummyEvent)
 	require.NoError(t, err)
 }
 
@@ -50,7 +67,7 @@ func TestSender_PublishEvent_Failure(t *testing.T) {
 
 	mockProducer := mocks.NewMockProducer(ctrl)
 
-	s := &sender{producer: mockProducer}
+	s := &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger}
 
 	mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).DoAndReturn(
 		func(msg *kafka.Message, deliveryChan chan kafka.Event) error {
@@ -68,18 +85,145 @@ func TestSender_PublishEvent_Failure(t *testing.T) {
 		},
 	)
 
-	err := s.PublishEvent("test-topic", dummyEvent)
+	err := s.PublishEvent(context.Background(), "test-topic", dummyEvent)
 	require.Error(t, err)
 	require.Equal(t, "Delivery failed", err.Error())
 }
 
+func TestSender_PublishEvent_KeyAndHeaders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockProducer := mocks.NewMockProducer(ctrl)
+
+	s := &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger}
+
+	event := structs.NewErrorEvent(structs.CompanyUpdateFailed, "3f0e5f0a-3a43-4d0c-9d7c-4c8b7f3e2a11", "/test", "update failed")
+	event.CorrelationID = "req-1"
+	event.TraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+			require.Equal(t, []byte(event.Subject), msg.Key)
+
+			headers := map[string]string{}
+			for _, header := range msg.Headers {
+				headers[header.Key] = string(header.Value)
+			}
+
+			require.Equal(t, map[string]string{
+				HeaderContentType:   structs.ContentType,
+				HeaderEventType:     structs.CompanyUpdateFailed,
+				HeaderEventID:       event.ID,
+				HeaderCorrelationID: "req-1",
+				HeaderTraceParent:   event.TraceParent,
+			}, headers)
+
+			deliveryChan <- &kafka.Message{TopicPartition: msg.TopicPartition}
+			return nil
+		},
+	)
+
+	err := s.PublishEvent(context.Background(), "test-topic", event)
+	require.NoError(t, err)
+}
+
+func TestSender_PublishEvent_ContinuesEventTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	ctrl := gomock.NewController(t)
+
+	mockProducer := mocks.NewMockProducer(ctrl)
+
+	s := &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger}
+
+	event := structs.NewErrorEvent(structs.CompanyUpdateFailed, "3f0e5f0a-3a43-4d0c-9d7c-4c8b7f3e2a11", "/test", "update failed")
+	event.TraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	var traceParent string
+	mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+			for _, header := range msg.Headers {
+				if header.Key == HeaderTraceParent {
+					traceParent = string(header.Value)
+				}
+			}
+
+			deliveryChan <- &kafka.Message{TopicPartition: msg.TopicPartition}
+			return nil
+		},
+	)
+
+	require.NoError(t, s.PublishEvent(context.Background(), "test-topic", event))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	require.Equal(t, "publish test-topic", span.Name())
+	require.Equal(t, trace.SpanKindProducer, span.SpanKind())
+	require.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanContext().SpanID().String()+"-01", traceParent)
+}
+
+func TestSender_PublishEvent_ProduceError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockProducer := mocks.NewMockProducer(ctrl)
+	mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).Return(errors.New("local queue full"))
+
+	s := &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger}
+
+	err := s.PublishEvent(context.Background(), "test-topic", dummyEvent)
+	require.Error(t, err)
+}
+
+func TestSender_PublishEvent_Timeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockProducer := mocks.NewMockProducer(ctrl)
+	mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).Return(nil)
+
+	s := &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger, timeout: 10 * time.Millisecond}
+
+	err := s.PublishEvent(context.Background(), "test-topic", dummyEvent)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSender_PublishEvent_Canceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockProducer := mocks.NewMockProducer(ctrl)
+
+	s := &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := s.PublishEvent(ctx, "test-topic", dummyEvent)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
 func TestSender_Close(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	mockProducer := mocks.NewMockProducer(ctrl)
+	mockProducer.EXPECT().Flush(gomock.Any()).Return(0)
 	mockProducer.EXPECT().Close().Return()
 
-	s := &sender{producer: mockProducer}
+	s := &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger}
 	err := s.Close()
 	require.NoError(t, err)
 }
+
+func TestSender_Close_Undelivered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockProducer := mocks.NewMockProducer(ctrl)
+	mockProducer.EXPECT().Flush(gomock.Any()).Return(2)
+	mockProducer.EXPECT().Close().Return()
+
+	s := &sender{producer: mockProducer, metrics: newTestMetrics(t), logger: testLogger}
+	require.EqualError(t, s.Close(), "2 messages not delivered before close")
+}
@@ -0,0 +1,161 @@
+package eventsender
+
+import (
+	"bufio"
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/metrics"
+	"companies/cmd/internal/structs"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTransport_SelectsByName(t *testing.T) {
+	transport, err := NewTransport(configparser.Config{Events: configparser.Events{Transport: TransportMemory}}, newTestMetrics(t), testLogger)
+	require.NoError(t, err)
+	require.IsType(t, &MemoryTransport{}, transport)
+	require.NoError(t, transport.Close())
+}
+
+func TestNewTransport_Unknown(t *testing.T) {
+	_, err := NewTransport(configparser.Config{Events: configparser.Events{Transport: "carrier-pigeon"}}, newTestMetrics(t), testLogger)
+	require.Error(t, err)
+}
+
+func TestNewEventSender_FactoryError(t *testing.T) {
+	RegisterTransport("broken", func(configparser.Config, *metrics.Metrics, *slog.Logger) (Transport, error) {
+		return nil, errors.New("no broker")
+	})
+
+	transport, err := NewEventSender(configparser.Config{Events: configparser.Events{Transport: "broken"}}, newTestMetrics(t), testLogger)
+	require.ErrorContains(t, err, "no broker")
+	require.Nil(t, transport)
+}
+
+func TestRegisterTransport_Custom(t *testing.T) {
+	memory := NewMemoryTransport(1, newTestMetrics(t))
+	RegisterTransport("custom", func(configparser.Config, *metrics.Metrics, *slog.Logger) (Transport, error) { return memory, nil })
+
+	transport, err := NewTransport(configparser.Config{Events: configparser.Events{Transport: "custom"}}, newTestMetrics(t), testLogger)
+	require.NoError(t, err)
+	require.Same(t, memory, transport)
+	require.Contains(t, Transports(), "custom")
+}
+
+func TestMemoryTransport(t *testing.T) {
+	transport := NewMemoryTransport(2, newTestMetrics(t))
+
+	require.NoError(t, transport.PublishEvent(context.Background(), "test-topic", dummyEvent))
+
+	msg := <-transport.Messages()
+	require.Equal(t, "test-topic", msg.Topic)
+	require.Equal(t, dummyEvent.ID, msg.Event.ID)
+
+	require.NoError(t, transport.Close())
+	require.ErrorIs(t, transport.PublishEvent(context.Background(), "test-topic", dummyEvent), ErrSenderClosed)
+
+	_, open := <-transport.Messages()
+	require.False(t, open)
+}
+
+func TestMemoryTransport_DropsOldestWhenFull(t *testing.T) {
+	transport := NewMemoryTransport(2, newTestMetrics(t))
+
+	for _, topic := range []string{"first", "second", "third"} {
+		require.NoError(t, transport.PublishEvent(context.Background(), topic, dummyEvent))
+	}
+
+	require.NoError(t, transport.Close())
+
+	var topics []string
+	for msg := range transport.Messages() {
+		topics = append(topics, msg.Topic)
+	}
+	require.Equal(t, []string{"second", "third"}, topics)
+}
+
+func TestFileTransport_AppendsNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events", "out.ndjson")
+
+	transport, err := NewFileTransport(path)
+	require.NoError(t, err)
+
+	require.NoError(t, transport.PublishEvent(context.Background(), "test-topic", dummyEvent))
+	require.NoError(t, transport.PublishEvent(context.Background(), "other-topic", dummyEvent))
+	require.NoError(t, transport.Close())
+	require.ErrorIs(t, transport.PublishEvent(context.Background(), "test-topic", dummyEvent), ErrSenderClosed)
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var records []fileRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record fileRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+
+	require.Len(t, records, 2)
+	require.Equal(t, "other-topic", records[1].Topic)
+	require.Equal(t, dummyEvent.Type, records[1].Event.Type)
+}
+
+func TestWebhookTransport(t *testing.T) {
+	var gotHeaders http.Header
+	var gotBody structs.Event
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &gotBody)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	transport, err := NewWebhookTransport(srv.URL, time.Second)
+	require.NoError(t, err)
+	defer transport.Close()
+
+	require.NoError(t, transport.PublishEvent(context.Background(), "test-topic", dummyEvent))
+	require.Equal(t, structs.ContentType, gotHeaders.Get("Content-Type"))
+	require.Equal(t, dummyEvent.Type, gotHeaders.Get("Ce-Type"))
+	require.Equal(t, "test-topic", gotHeaders.Get("X-Topic"))
+	require.Equal(t, dummyEvent.ID, gotBody.ID)
+}
+
+func TestWebhookTransport_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	transport, err := NewWebhookTransport(srv.URL, time.Second)
+	require.NoError(t, err)
+
+	require.Error(t, transport.PublishEvent(context.Background(), "test-topic", dummyEvent))
+}
+
+func TestFanout_PublishesToAll(t *testing.T) {
+	first := NewMemoryTransport(1, newTestMetrics(t))
+	second := NewMemoryTransport(1, newTestMetrics(t))
+	closed := NewMemoryTransport(1, newTestMetrics(t))
+	require.NoError(t, closed.Close())
+
+	fanout := NewFanout(first, nil, closed, second)
+
+	require.ErrorIs(t, fanout.PublishEvent(context.Background(), "test-topic", dummyEvent), ErrSenderClosed)
+	require.Equal(t, dummyEvent.ID, (<-first.Messages()).Event.ID)
+	require.Equal(t, dummyEvent.ID, (<-second.Messages()).Event.ID)
+}
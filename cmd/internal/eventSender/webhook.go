@@ -0,0 +1,85 @@
+package eventsender
+
+import (
+	"bytes"
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/metrics"
+	"companies/cmd/internal/structs"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const defaultWebhookTimeoutMs = 5000
+
+func init() {
+	RegisterTransport(TransportWebhook, func(config configparser.Config, _ *metrics.Metrics, _ *slog.Logger) (Transport, error) {
+		url := configparser.GetCfgValue("EVENTS_WEBHOOK_URL", config.Events.Webhook.URL)
+		timeoutMs := configparser.GetCfgValue("EVENTS_WEBHOOK_TIMEOUT_MS", config.Events.Webhook.TimeoutMs)
+
+		return NewWebhookTransport(url, time.Duration(positiveOr(timeoutMs, defaultWebhookTimeoutMs))*time.Millisecond)
+	})
+}
+
+// WebhookTransport POSTs every event to a single URL in CloudEvents
+// structured mode. Any non-2xx response is reported as an error.
+type WebhookTransport struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookTransport(url string, timeout time.Duration) (*WebhookTransport, error) {
+	if url == "" {
+		return nil, errors.New("webhook transport requires a url")
+	}
+
+	return &WebhookTransport{url: url, client: &http.Client{Timeout: timeout}}, nil
+}
+
+func (t *WebhookTransport) PublishEvent(ctx context.Context, topic string, event structs.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", structs.ContentType)
+	req.Header.Set("Ce-Type", event.Type)
+	req.Header.Set("Ce-Id", event.ID)
+	req.Header.Set("X-Topic", topic)
+
+	if event.CorrelationID != "" {
+		req.Header.Set("X-Request-Id", event.CorrelationID)
+	}
+	if event.TraceParent != "" {
+		req.Header.Set(HeaderTraceParent, event.TraceParent)
+	}
+	if event.TraceState != "" {
+		req.Header.Set(HeaderTraceState, event.TraceState)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %v", resp.Status)
+	}
+
+	return nil
+}
+
+func (t *WebhookTransport) Close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,146 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	defaultTimeout = 2 * time.Second
+)
+
+// Check reports whether a dependency is usable, it must return once ctx ends.
+type Check func(context.Context) error
+
+type component struct {
+	name  string
+	check Check
+}
+
+// ComponentReport is the outcome of one check.
+type ComponentReport struct {
+	Status   string `json:"status" example:"ok"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration" example:"1.2ms"`
+}
+
+type Report struct {
+	Status     string                     `json:"status" example:"ok"`
+	Draining   bool                       `json:"draining,omitempty"`
+	Components map[string]ComponentReport `json:"components,omitempty"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs the readiness checks. Once draining it reports failure
+// whatever the checks say, so load balancers stop routing to the instance
+// before it shuts down.
+type Checker struct {
+	timeout  time.Duration
+	draining atomic.Bool
+
+	mu         sync.RWMutex
+	components []component
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a check reported under name.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.components = append(c.components, component{name, check})
+}
+
+// Drain makes every later report fail, it can't be undone.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Check runs all checks concurrently, each bounded by the checker timeout.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	components := append([]component(nil), c.components...)
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	reports := make([]ComponentReport, len(components))
+
+	var wg sync.WaitGroup
+	for i, comp := range components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reports[i] = run(ctx, comp.check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Draining: c.Draining(), Components: make(map[string]ComponentReport, len(components))}
+	if report.Draining {
+		report.Status = StatusFail
+	}
+
+	for i, comp := range components {
+		report.Components[comp.name] = reports[i]
+		if reports[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+func run(ctx context.Context, check Check) (report ComponentReport) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			report = ComponentReport{Status: StatusFail, Error: fmt.Sprint("check panicked: ", r)}
+		}
+		report.Duration = time.Since(start).String()
+	}()
+
+	if err := check(ctx); err != nil {
+		return ComponentReport{Status: StatusFail, Error: err.Error()}
+	}
+
+	return ComponentReport{Status: StatusOK}
+}
+
+// MaxBacklog fails once count reports more than limit items waiting, such as
+// unpublished outbox messages.
+func MaxBacklog(count func(context.Context) (int64, error), limit int64) Check {
+	return func(ctx context.Context) error {
+		n, err := count(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n > limit {
+			return fmt.Errorf("backlog of %d exceeds %d", n, limit)
+		}
+
+		return nil
+	}
+}
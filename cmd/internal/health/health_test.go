@@ -0,0 +1,43 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker_TimesOutSlowChecks(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	checker.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := checker.Check(context.Background())
+
+	assert.False(t, report.OK())
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Components["slow"].Error)
+}
+
+func TestChecker_RecoversPanics(t *testing.T) {
+	checker := NewChecker(0)
+	checker.Add("broken", func(context.Context) error { panic("boom") })
+
+	report := checker.Check(context.Background())
+
+	assert.False(t, report.OK())
+	assert.Equal(t, "check panicked: boom", report.Components["broken"].Error)
+}
+
+func TestMaxBacklog(t *testing.T) {
+	count := func(n int64, err error) func(context.Context) (int64, error) {
+		return func(context.Context) (int64, error) { return n, err }
+	}
+
+	assert.NoError(t, MaxBacklog(count(10, nil), 10)(context.Background()))
+	assert.EqualError(t, MaxBacklog(count(11, nil), 10)(context.Background()), "backlog of 11 exceeds 10")
+	assert.EqualError(t, MaxBacklog(count(0, errors.New("db down")), 10)(context.Background()), "db down")
+}
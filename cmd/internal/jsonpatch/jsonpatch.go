@@ -0,0 +1,391 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+var (
+	ErrInvalidPatch = errors.New("invalid patch")
+	ErrTestFailed   = errors.New("test operation failed")
+)
+
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+func decode(data []byte) (any, error) {
+	var value any
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// MergePatch applies an RFC 7396 merge patch: objects are merged
+// recursively, null removes a member and any other value replaces it.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(mergePatch(target, p))
+}
+
+func mergePatch(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = map[string]any{}
+	}
+
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+			continue
+		}
+		object[name] = mergePatch(object[name], value)
+	}
+
+	return object
+}
+
+// Apply applies an RFC 6902 patch. Operations run in order and the patch is
+// all or nothing: on error the document must be discarded.
+func Apply(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []operation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, op := range operations {
+		if target, err = op.apply(target); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func (op operation) apply(doc any) (any, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("%w: %v without path", ErrInvalidPatch, op.Op)
+	}
+
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: %v without value", ErrInvalidPatch, op.Op)
+		}
+
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			return update(doc, path, func(any) (any, error) { return value, nil })
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(current, value) {
+				return nil, fmt.Errorf("%w: %v", ErrTestFailed, *op.Path)
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: %v without from", ErrInvalidPatch, op.Op)
+		}
+
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "copy" {
+			value, err := get(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return add(doc, path, deepCopy(value))
+		}
+
+		if *op.Path == *op.From {
+			return doc, nil
+		}
+
+		if strings.HasPrefix(*op.Path, *op.From+"/") {
+			return nil, fmt.Errorf("%w: can't move %v into itself", ErrInvalidPatch, *op.From)
+		}
+
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// index parses an array index, it must be below limit.
+func index(token string, limit int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') || token[0] == '+' {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+
+	if i >= limit {
+		return 0, fmt.Errorf("%w: array index %v out of bounds", ErrInvalidPatch, i)
+	}
+
+	return i, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	var value any
+	_, err := update(doc, path, func(current any) (any, error) {
+		value = current
+		return current, nil
+	})
+	return value, err
+}
+
+// update replaces the existing value at path with fn's result and returns the
+// new document root.
+func update(doc any, path []string, fn func(any) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return fn(doc)
+	}
+
+	switch container := doc.(type) {
+	case map[string]any:
+		child, ok := container[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q doesn't exist", ErrInvalidPatch, path[0])
+		}
+
+		value, err := update(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+
+		container[path[0]] = value
+		return container, nil
+
+	case []any:
+		i, err := index(path[0], len(container))
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := update(container[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+
+		container[i] = value
+		return container, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q isn't a container", ErrInvalidPatch, path[0])
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	last := path[len(path)-1]
+
+	return update(doc, path[:len(path)-1], func(parent any) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[last] = value
+			return container, nil
+
+		case []any:
+			if last == "-" {
+				return append(container, value), nil
+			}
+
+			i, err := index(last, len(container)+1)
+			if err != nil {
+				return nil, err
+			}
+
+			container = append(container, nil)
+			copy(container[i+1:], container[i:])
+			container[i] = value
+			return container, nil
+		}
+
+		return nil, fmt.Errorf("%w: can't add %q to a scalar", ErrInvalidPatch, last)
+	})
+}
+
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: can't remove the whole document", ErrInvalidPatch)
+	}
+
+	last := path[len(path)-1]
+	var removed any
+
+	doc, err := update(doc, path[:len(path)-1], func(parent any) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			value, ok := container[last]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q doesn't exist", ErrInvalidPatch, last)
+			}
+
+			removed = value
+			delete(container, last)
+			return container, nil
+
+		case []any:
+			i, err := index(last, len(container))
+			if err != nil {
+				return nil, err
+			}
+
+			removed = container[i]
+			return append(container[:i], container[i+1:]...), nil
+		}
+
+		return nil, fmt.Errorf("%w: can't remove %q from a scalar", ErrInvalidPatch, last)
+	})
+
+	return doc, removed, err
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		object := make(map[string]any, len(v))
+		for name, member := range v {
+			object[name] = deepCopy(member)
+		}
+		return object
+
+	case []any:
+		array := make([]any, len(v))
+		for i, item := range v {
+			array[i] = deepCopy(item)
+		}
+		return array
+	}
+
+	return value
+}
+
+// equal compares JSON values, numbers by value so 1 equals 1.0.
+func equal(a, b any) bool {
+	x, okA := a.(json.Number)
+	y, okB := b.(json.Number)
+	if okA && okB {
+		fx, errA := x.Float64()
+		fy, errB := y.Float64()
+		return errA == nil && errB == nil && fx == fy
+	}
+
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
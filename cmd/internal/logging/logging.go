@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	KeyRequestID = "request_id"
+	KeyRoute     = "route"
+	KeyTraceID   = "trace_id"
+	KeyUser      = "user"
+	KeyCompanyID = "company_id"
+	KeyWebhookID = "webhook_id"
+	KeyError     = "error"
+)
+
+// New returns a logger writing JSON lines to w. Records logged with a request
+// context carry its request ID, route, trace ID and the attributes added with
+// AddAttrs. Secrets are redacted, see Redact.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: Redact,
+	})})
+}
+
+// ParseLevel accepts the slog level names in any case, such as debug or WARN.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(strings.TrimSpace(name)))
+	return level, err
+}
+
+// Err is the attribute of a failure, so every record names it the same way.
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := middleware.GetReqID(ctx); requestID != "" {
+		record.AddAttrs(slog.String(KeyRequestID, requestID))
+	}
+
+	if rctx := chi.RouteContext(ctx); rctx != nil {
+		if route := rctx.RoutePattern(); route != "" {
+			record.AddAttrs(slog.String(KeyRoute, route))
+		}
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String(KeyTraceID, spanContext.TraceID().String()))
+	}
+
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		record.AddAttrs(f.list()...)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type fieldsKey struct{}
+
+// fields collects the attributes learned while a request is served, such as
+// the user, so that the access log has them as well.
+type fields struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+func (f *fields) list() []slog.Attr {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]slog.Attr(nil), f.attrs...)
+}
+
+// WithFields prepares ctx for AddAttrs, Middleware does it for every request.
+func WithFields(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, &fields{})
+}
+
+// AddAttrs attaches attrs to every later record of the request, an attribute
+// with the same key is replaced. Outside of a request it does nothing.
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+next:
+	for _, attr := range attrs {
+		for i := range f.attrs {
+			if f.attrs[i].Key == attr.Key {
+				f.attrs[i] = attr
+				continue next
+			}
+		}
+		f.attrs = append(f.attrs, attr)
+	}
+}
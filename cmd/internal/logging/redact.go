@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	Redacted = "[REDACTED]"
+
+	// minSecretLength keeps short values from redacting ordinary words.
+	minSecretLength = 4
+)
+
+// sensitiveKeys are redacted whatever their value, matched as substrings of
+// the lower-cased key so that DB_PASSWORD and refresh_token are covered.
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "authorization", "cookie", "api_key", "apikey"}
+
+var (
+	// jwtPattern matches a JWS compact serialization, its header and payload
+	// are base64url JSON objects and so start with eyJ.
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// bearerPattern matches credentials that aren't JWTs as well.
+	bearerPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	// dsnPattern matches the password of a go-sql-driver DSN.
+	dsnPattern = regexp.MustCompile(`([^\s:/@"]+):[^\s@"]*@(tcp|unix)\(`)
+)
+
+var (
+	secretsMu sync.RWMutex
+	secrets   = map[string]struct{}{}
+)
+
+// RegisterSecret makes the given values redacted wherever they appear, it is
+// meant for configured credentials such as the DB password.
+func RegisterSecret(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, value := range values {
+		if len(value) >= minSecretLength {
+			secrets[value] = struct{}{}
+		}
+	}
+}
+
+// Redact is a slog ReplaceAttr function hiding sensitive attributes and the
+// credentials found in messages, strings and errors.
+func Redact(_ []string, attr slog.Attr) slog.Attr {
+	if isSensitive(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(RedactString(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			attr.Value = slog.StringValue(RedactString(err.Error()))
+		}
+	}
+
+	return attr
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString replaces tokens, DSN passwords and registered secrets in s.
+func RedactString(s string) string {
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "$1 "+Redacted)
+	s = dsnPattern.ReplaceAllString(s, "$1:"+Redacted+"@$2(")
+
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+
+	for secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+
+	return s
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// countTimeout bounds the query behind a scrape, Prometheus gives up after
+// 10 seconds by default.
+const countTimeout = 5 * time.Second
+
+// CompanyCount is the number of live companies sharing a type and a
+// registration status.
+type CompanyCount struct {
+	Type         int
+	IsRegistered bool
+	Count        int64
+}
+
+type CompanyCounter interface {
+	CountCompanies(context.Context) ([]CompanyCount, error)
+}
+
+var companiesDesc = prometheus.NewDesc(
+	"companies_total",
+	"Number of companies that aren't deleted, by type and registration status",
+	[]string{"type", "registered"},
+	nil,
+)
+
+// companiesCollector counts the companies on every scrape, so the gauges
+// can't drift from the table whichever replica made the change.
+type companiesCollector struct {
+	counter CompanyCounter
+}
+
+func NewCompaniesCollector(counter CompanyCounter) prometheus.Collector {
+	return companiesCollector{counter: counter}
+}
+
+func (c companiesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- companiesDesc
+}
+
+func (c companiesCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	counts, err := c.counter.CountCompanies(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(companiesDesc, err)
+		return
+	}
+
+	for _, count := range counts {
+		ch <- prometheus.MustNewConstMetric(companiesDesc, prometheus.GaugeValue, float64(count.Count),
+			strconv.Itoa(count.Type), strconv.FormatBool(count.IsRegistered))
+	}
+}
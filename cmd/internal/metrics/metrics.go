@@ -1,38 +1,166 @@
 package metrics
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
-var (
-	HttpRequestsTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Total number of HTTP requests",
-		},
-		[]string{"method", "path", "status"},
-	)
+// unmatchedRoute labels requests no route matched, so scanners probing random
+// paths can't create series either.
+const unmatchedRoute = "unmatched"
+
+// Metrics holds the collectors registered on one registry. Every app, and
+// every test, creates its own, so none of them share counts.
+type Metrics struct {
+	reg prometheus.Registerer
+
+	HttpRequestsTotal     *prometheus.CounterVec
+	HttpRequestDuration   *prometheus.HistogramVec
+	DBQueryDuration       *prometheus.HistogramVec
+	DBQueryErrorsTotal    *prometheus.CounterVec
+	EventsPublishedTotal  *prometheus.CounterVec
+	EventsPublishDuration *prometheus.HistogramVec
+	EventsFailedTotal     *prometheus.CounterVec
+	EventsDroppedTotal    *prometheus.CounterVec
+	EventsSpilledTotal    *prometheus.CounterVec
+	EventsQueueDepth      prometheus.Gauge
+}
+
+// New creates the collectors and registers them on reg, together with the Go
+// runtime and process collectors.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		reg: reg,
+
+		HttpRequestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_requests_total",
+				Help: "Total number of HTTP requests",
+			},
+			[]string{"method", "path", "status"},
+		),
+
+		HttpRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_request_duration_seconds",
+				Help:    "Duration of HTTP requests in seconds",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"method", "path"},
+		),
+
+		DBQueryDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "db_query_duration_seconds",
+				Help:    "Duration of database statements in seconds",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"operation", "table"},
+		),
+
+		DBQueryErrorsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "db_query_errors_total",
+				Help: "Total number of failed database statements, missing records aren't failures",
+			},
+			[]string{"operation", "table"},
+		),
+
+		EventsPublishedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "events_published_total",
+				Help: "Total number of events acknowledged by the broker",
+			},
+			[]string{"topic"},
+		),
+
+		EventsPublishDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "events_publish_duration_seconds",
+				Help:    "Time from producing an event to its acknowledgement by the broker in seconds",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"topic"},
+		),
+
+		EventsFailedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "events_failed_total",
+				Help: "Total number of events that could not be delivered",
+			},
+			[]string{"topic"},
+		),
+
+		EventsDroppedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "events_dropped_total",
+				Help: "Total number of events dropped because the send queue was full",
+			},
+			[]string{"topic", "action"},
+		),
 
-	HttpRequestDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "Duration of HTTP requests in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"method", "path"},
+		EventsSpilledTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "events_spilled_total",
+				Help: "Total number of events spilled to disk because the send queue was full, they are published later",
+			},
+			[]string{"topic"},
+		),
+
+		EventsQueueDepth: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "events_queue_depth",
+				Help: "Number of events waiting in the in-memory send queue",
+			},
+		),
+	}
+
+	err := m.register(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HttpRequestsTotal,
+		m.HttpRequestDuration,
+		m.DBQueryDuration,
+		m.DBQueryErrorsTotal,
+		m.EventsPublishedTotal,
+		m.EventsPublishDuration,
+		m.EventsFailedTotal,
+		m.EventsDroppedTotal,
+		m.EventsSpilledTotal,
+		m.EventsQueueDepth,
 	)
-)
+	if err != nil {
+		return nil, err
+	}
 
-func Init() {
-	prometheus.MustRegister(HttpRequestsTotal, HttpRequestDuration)
+	return m, nil
 }
 
-func MetricsMiddleware(next http.Handler) http.Handler {
+// RegisterCompanies adds the company gauges, counted through counter on every
+// scrape.
+func (m *Metrics) RegisterCompanies(counter CompanyCounter) error {
+	return m.register(NewCompaniesCollector(counter))
+}
+
+func (m *Metrics) register(cs ...prometheus.Collector) error {
+	var errs []error
+	for _, c := range cs {
+		errs = append(errs, m.reg.Register(c))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Middleware labels requests with the chi route pattern rather than the
+// path, ids in the path would make a series per record otherwise.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
@@ -40,9 +168,30 @@ func MetricsMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(ww, r)
 
 		duration := time.Since(start).Seconds()
-		routePattern := r.URL.Path
+		routePattern := RoutePattern(r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
 
-		HttpRequestsTotal.WithLabelValues(r.Method, routePattern, strconv.Itoa(ww.Status())).Inc()
-		HttpRequestDuration.WithLabelValues(r.Method, routePattern).Observe(duration)
+		m.HttpRequestsTotal.WithLabelValues(r.Method, routePattern, strconv.Itoa(status)).Inc()
+		m.HttpRequestDuration.WithLabelValues(r.Method, routePattern).Observe(duration)
 	})
 }
+
+// RoutePattern returns the pattern of the route that served r, it is only
+// complete once the router is done with the request.
+func RoutePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return unmatchedRoute
+	}
+
+	pattern := rctx.RoutePattern()
+	if pattern == "" {
+		return unmatchedRoute
+	}
+
+	return pattern
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type counterFunc func(context.Context) ([]CompanyCount, error)
+
+func (f counterFunc) CountCompanies(ctx context.Context) ([]CompanyCount, error) {
+	return f(ctx)
+}
+
+func TestNew_SeveralRegistries(t *testing.T) {
+	first, err := New(prometheus.NewRegistry())
+	require.NoError(t, err)
+	second, err := New(prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	// Each registry counts on its own.
+	first.EventsFailedTotal.WithLabelValues("data-changed").Inc()
+	assert.Equal(t, 1.0, testutil.ToFloat64(first.EventsFailedTotal.WithLabelValues("data-changed")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(second.EventsFailedTotal.WithLabelValues("data-changed")))
+}
+
+func TestNew_SameRegistryTwice(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	_, err := New(reg)
+	require.NoError(t, err)
+	_, err = New(reg)
+	assert.Error(t, err)
+}
+
+func TestMiddleware_RoutePattern(t *testing.T) {
+	m, err := New(prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/api/v1/widgets/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/widgets/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/widgets/2", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.HttpRequestsTotal.WithLabelValues(http.MethodGet, "/api/v1/widgets/{id}", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HttpRequestsTotal.WithLabelValues(http.MethodGet, unmatchedRoute, "404")))
+}
+
+func TestCompaniesCollector(t *testing.T) {
+	collector := NewCompaniesCollector(counterFunc(func(context.Context) ([]CompanyCount, error) {
+		return []CompanyCount{{Type: 0, IsRegistered: false, Count: 2}, {Type: 2, IsRegistered: true, Count: 5}}, nil
+	}))
+
+	err := testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP companies_total Number of companies that aren't deleted, by type and registration status
+# TYPE companies_total gauge
+companies_total{registered="false",type="0"} 2
+companies_total{registered="true",type="2"} 5
+`))
+	assert.NoError(t, err)
+}
+
+func TestCompaniesCollector_Error(t *testing.T) {
+	collector := NewCompaniesCollector(counterFunc(func(context.Context) ([]CompanyCount, error) {
+		return nil, errors.New("database is down")
+	}))
+
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(collector))
+
+	_, err := reg.Gather()
+	assert.ErrorContains(t, err, "database is down")
+}
@@ -0,0 +1,345 @@
+package outbox
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/database"
+	eventsender "companies/cmd/internal/eventSender"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/structs"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultPollIntervalMs = 500
+	defaultBatchSize      = 100
+	defaultRetentionHours = 24
+	defaultMaxAttempts    = 10
+
+	// pruneInterval is how often sent messages past the retention are
+	// deleted, pruneBatchSize how many one statement deletes.
+	pruneInterval  = 10 * time.Minute
+	pruneBatchSize = 1000
+
+	// finalDrainTimeout bounds the drain on Close. Messages still being
+	// published then are abandoned and stay pending.
+	finalDrainTimeout = 10 * time.Second
+
+	// claimLease is how long other relays skip the messages a relay claimed.
+	// A batch still publishing after half of it is claimed again.
+	claimLease = 5 * time.Minute
+
+	// retryBackoff is the pause after a sink failed, it doubles with every
+	// failure in a row up to maxRetryBackoff.
+	retryBackoff    = time.Second
+	maxRetryBackoff = time.Minute
+)
+
+// Relay drains the outbox table to the sender of each sink. A message is
+// marked as sent only after the sender acknowledged it, so delivery is
+// at-least-once: a crash between publishing and marking re-sends the message
+// on restart. Senders must not return before the broker confirmed the event,
+// see eventsender.Confirmed. Every sink is drained by its own goroutine, so a
+// slow sink doesn't hold back the others. Replicas claim the messages they
+// publish, so they don't publish the same ones. A message failing maxAttempts
+// times is parked, so the messages behind it get through.
+type Relay struct {
+	store       database.OutboxStore
+	sinks       map[string]eventsender.EventSender
+	logger      *slog.Logger
+	interval    time.Duration
+	batchSize   int
+	retention   time.Duration
+	maxAttempts int
+
+	// ctx ends when Close gives up on the final drain, it cancels the
+	// publishes still running.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	started  atomic.Bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRelay delivers the messages of each sink in sinks, those of other sinks
+// stay pending.
+func NewRelay(config configparser.Outbox, store database.OutboxStore, sinks map[string]eventsender.EventSender, logger *slog.Logger) *Relay {
+	pollIntervalMs := configparser.GetCfgValue("OUTBOX_POLL_INTERVAL_MS", config.PollIntervalMs)
+	if pollIntervalMs <= 0 {
+		pollIntervalMs = defaultPollIntervalMs
+	}
+
+	batchSize := configparser.GetCfgValue("OUTBOX_BATCH_SIZE", config.BatchSize)
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	retentionHours := configparser.GetCfgValue("OUTBOX_RETENTION_HOURS", config.RetentionHours)
+	if retentionHours <= 0 {
+		retentionHours = defaultRetentionHours
+	}
+
+	maxAttempts := configparser.GetCfgValue("OUTBOX_MAX_ATTEMPTS", config.MaxAttempts)
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Relay{
+		ctx:         ctx,
+		cancel:      cancel,
+		store:       store,
+		sinks:       sinks,
+		logger:      logger,
+		interval:    time.Duration(pollIntervalMs) * time.Millisecond,
+		batchSize:   batchSize,
+		retention:   time.Duration(retentionHours) * time.Hour,
+		maxAttempts: maxAttempts,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+func (r *Relay) Start() {
+	if !r.started.CompareAndSwap(false, true) {
+		return
+	}
+
+	r.logger.Info("Starting outbox relay")
+
+	var wg sync.WaitGroup
+	for sink, sender := range r.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.poll(sink, sender)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.prune()
+	}()
+
+	go func() {
+		wg.Wait()
+		close(r.done)
+	}()
+}
+
+// poll drains the sink every interval, and a last time once stopped. After a
+// failure it backs off, so a broker outage doesn't use up the attempts of the
+// oldest message within seconds.
+func (r *Relay) poll(sink string, sender eventsender.EventSender) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	var failures int
+	var retryAt time.Time
+
+	for {
+		select {
+		case <-r.stop:
+			r.drainSink(r.ctx, sink, sender)
+			return
+		case <-ticker.C:
+			if time.Now().Before(retryAt) {
+				continue
+			}
+
+			if r.drainSink(r.ctx, sink, sender) {
+				failures = 0
+				continue
+			}
+
+			failures++
+			retryAt = time.Now().Add(backoff(failures))
+		}
+	}
+}
+
+func backoff(failures int) time.Duration {
+	delay := retryBackoff << (failures - 1)
+	if delay <= 0 || delay > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return delay
+}
+
+func (r *Relay) prune() {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.Prune(r.ctx)
+		}
+	}
+}
+
+// Drain publishes the pending messages of every sink, the sinks concurrently.
+func (r *Relay) Drain(ctx context.Context) {
+	var wg sync.WaitGroup
+	for sink, sender := range r.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.drainSink(ctx, sink, sender)
+		}()
+	}
+	wg.Wait()
+}
+
+// drainSink publishes pending messages in insertion order until the sink has
+// none left or a message fails, and reports whether it got through. Stopping
+// at the first failure keeps events about the same company in order.
+func (r *Relay) drainSink(ctx context.Context, sink string, sender eventsender.EventSender) bool {
+batches:
+	for {
+		claimedAt := time.Now()
+		messages, err := r.store.ClaimOutbox(ctx, sink, r.batchSize, claimLease)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to read the outbox", slog.String("sink", sink), logging.Err(err))
+			return false
+		}
+
+		for i, message := range messages {
+			if ctx.Err() != nil {
+				r.release(ctx, messages[i:])
+				return false
+			}
+
+			// Another relay would publish the rest too once the lease ran
+			// out, claim them again instead.
+			if time.Since(claimedAt) > claimLease/2 {
+				r.release(ctx, messages[i:])
+				continue batches
+			}
+
+			if !r.publish(ctx, sender, message) {
+				r.release(ctx, messages[i+1:])
+				return false
+			}
+		}
+
+		if len(messages) < r.batchSize {
+			return true
+		}
+	}
+}
+
+// release gives up the claim on messages that weren't published, even when
+// ctx has ended.
+func (r *Relay) release(ctx context.Context, messages []database.OutboxMessage) {
+	if len(messages) == 0 {
+		return
+	}
+
+	ids := make([]uint64, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+
+	if err := r.store.ReleaseOutbox(context.WithoutCancel(ctx), ids); err != nil {
+		r.logger.ErrorContext(ctx, "Failed to release outbox messages", logging.Err(err))
+	}
+}
+
+// Prune deletes the messages sent longer ago than the retention, in batches
+// so no statement locks the table for long.
+func (r *Relay) Prune(ctx context.Context) {
+	sentBefore := time.Now().Add(-r.retention)
+
+	var total int64
+	for {
+		deleted, err := r.store.PruneOutbox(ctx, sentBefore, pruneBatchSize)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to prune the outbox", logging.Err(err))
+			return
+		}
+
+		total += deleted
+		if deleted < pruneBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		r.logger.InfoContext(ctx, "Pruned the outbox", slog.Int64("deleted", total))
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, sender eventsender.EventSender, message database.OutboxMessage) bool {
+	var event structs.Event
+	if err := json.Unmarshal(message.Payload, &event); err != nil {
+		// A payload that can't be decoded will never succeed, park it so it
+		// doesn't block the messages behind it.
+		r.park(ctx, message, err)
+		return true
+	}
+
+	if err := sender.PublishEvent(ctx, message.Topic, event); err != nil {
+		// Stopping isn't the message's fault, it doesn't count as an attempt.
+		if ctx.Err() != nil {
+			r.release(ctx, []database.OutboxMessage{message})
+			return false
+		}
+
+		if message.Attempts+1 >= r.maxAttempts {
+			r.park(ctx, message, err)
+			return true
+		}
+
+		r.logger.WarnContext(ctx, "Failed to publish outbox message", slog.Uint64("message_id", message.ID), slog.String("sink", message.Sink), logging.Err(err))
+		if err := r.store.MarkOutboxFailed(ctx, message.ID, err); err != nil {
+			r.logger.ErrorContext(ctx, "Failed to mark outbox message failed", slog.Uint64("message_id", message.ID), logging.Err(err))
+		}
+		return false
+	}
+
+	if err := r.store.MarkOutboxSent(ctx, message.ID); err != nil {
+		r.logger.ErrorContext(ctx, "Failed to mark outbox message sent", slog.Uint64("message_id", message.ID), logging.Err(err))
+		return false
+	}
+
+	return true
+}
+
+func (r *Relay) park(ctx context.Context, message database.OutboxMessage, cause error) {
+	r.logger.ErrorContext(ctx, "Parking outbox message", slog.Uint64("message_id", message.ID), slog.String("sink", message.Sink), slog.Int("attempts", message.Attempts+1), logging.Err(cause))
+	if err := r.store.ParkOutbox(ctx, message.ID, cause); err != nil {
+		r.logger.ErrorContext(ctx, "Failed to park outbox message", slog.Uint64("message_id", message.ID), logging.Err(err))
+	}
+}
+
+// Close stops polling and waits for a final drain to finish, at most
+// finalDrainTimeout. Then the publishes still running are cancelled.
+func (r *Relay) Close() error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	defer r.cancel()
+
+	if !r.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-r.done:
+	case <-time.After(finalDrainTimeout):
+		r.logger.Warn("Outbox drain didn't finish in time, leaving the rest pending")
+		r.cancel()
+		<-r.done
+	}
+
+	return nil
+}
@@ -0,0 +1,315 @@
+package outbox
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/database"
+	eventsender "companies/cmd/internal/eventSender"
+	"companies/cmd/internal/structs"
+	"companies/cmd/tests/mocks"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogger = slog.New(slog.DiscardHandler)
+
+func makeMessage(t *testing.T, id uint64, eventType string) database.OutboxMessage {
+	event, err := structs.NewChangeEvent[database.CompanyInfo](eventType, "x", nil, nil)
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	return database.OutboxMessage{ID: id, Topic: "data-changed", Payload: payload}
+}
+
+func TestRelay_DrainPublishesInOrderAndMarksSent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockStore := mocks.NewMockOutboxStore(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	relay := NewRelay(configparser.Outbox{BatchSize: 10}, mockStore, map[string]eventsender.EventSender{database.SinkEvents: mockSender}, testLogger)
+
+	mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkEvents, 10, claimLease).Return([]database.OutboxMessage{
+		makeMessage(t, 1, structs.CompanyCreated),
+		makeMessage(t, 2, structs.CompanyUpdated),
+	}, nil)
+
+	gomock.InOrder(
+		mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.AssignableToTypeOf(structs.Event{})).DoAndReturn(
+			func(_ context.Context, topic string, event structs.Event) error {
+				require.Equal(t, structs.CompanyCreated, event.Type)
+				return nil
+			}),
+		mockStore.EXPECT().MarkOutboxSent(gomock.Any(), uint64(1)).Return(nil),
+		mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.AssignableToTypeOf(structs.Event{})).DoAndReturn(
+			func(_ context.Context, topic string, event structs.Event) error {
+				require.Equal(t, structs.CompanyUpdated, event.Type)
+				return nil
+			}),
+		mockStore.EXPECT().MarkOutboxSent(gomock.Any(), uint64(2)).Return(nil),
+	)
+
+	relay.Drain(context.Background())
+}
+
+func TestRelay_DrainStopsAtFirstFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockStore := mocks.NewMockOutboxStore(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	relay := NewRelay(configparser.Outbox{BatchSize: 10}, mockStore, map[string]eventsender.EventSender{database.SinkEvents: mockSender}, testLogger)
+
+	publishErr := errors.New("Delivery failed")
+
+	mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkEvents, 10, claimLease).Return([]database.OutboxMessage{
+		makeMessage(t, 1, structs.CompanyCreated),
+		makeMessage(t, 2, structs.CompanyDeleted),
+	}, nil)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(publishErr)
+	mockStore.EXPECT().MarkOutboxFailed(gomock.Any(), uint64(1), publishErr).Return(nil)
+	// The rest of the batch is left to the next attempt, by any replica.
+	mockStore.EXPECT().ReleaseOutbox(gomock.Any(), []uint64{2}).Return(nil)
+
+	relay.Drain(context.Background())
+}
+
+func TestRelay_ParksAfterMaxAttempts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockStore := mocks.NewMockOutboxStore(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	relay := NewRelay(configparser.Outbox{BatchSize: 10, MaxAttempts: 3}, mockStore, map[string]eventsender.EventSender{database.SinkEvents: mockSender}, testLogger)
+
+	rejected := makeMessage(t, 1, structs.CompanyCreated)
+	rejected.Attempts = 2
+	rejectErr := errors.New("message too large")
+
+	mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkEvents, 10, claimLease).Return([]database.OutboxMessage{
+		rejected,
+		makeMessage(t, 2, structs.CompanyUpdated),
+	}, nil)
+
+	// The message behind the parked one still gets through.
+	gomock.InOrder(
+		mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(rejectErr),
+		mockStore.EXPECT().ParkOutbox(gomock.Any(), uint64(1), rejectErr).Return(nil),
+		mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(nil),
+		mockStore.EXPECT().MarkOutboxSent(gomock.Any(), uint64(2)).Return(nil),
+	)
+
+	relay.Drain(context.Background())
+}
+
+func TestRelay_ParksMalformedMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockStore := mocks.NewMockOutboxStore(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	relay := NewRelay(configparser.Outbox{BatchSize: 10}, mockStore, map[string]eventsender.EventSender{database.SinkEvents: mockSender}, testLogger)
+
+	mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkEvents, 10, claimLease).Return([]database.OutboxMessage{
+		{ID: 1, Topic: "data-changed", Payload: []byte("{")},
+	}, nil)
+	mockStore.EXPECT().ParkOutbox(gomock.Any(), uint64(1), gomock.Any()).Return(nil)
+
+	relay.Drain(context.Background())
+}
+
+func TestRelay_StoppingDoesNotCountAsAttempt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockStore := mocks.NewMockOutboxStore(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	relay := NewRelay(configparser.Outbox{BatchSize: 10}, mockStore, map[string]eventsender.EventSender{database.SinkEvents: mockSender}, testLogger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkEvents, 10, claimLease).Return([]database.OutboxMessage{
+		makeMessage(t, 1, structs.CompanyCreated),
+		makeMessage(t, 2, structs.CompanyUpdated),
+	}, nil)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).DoAndReturn(func(ctx context.Context, _ string, _ structs.Event) error {
+		cancel()
+		return ctx.Err()
+	})
+	mockStore.EXPECT().ReleaseOutbox(gomock.Any(), []uint64{1}).Return(nil)
+	mockStore.EXPECT().ReleaseOutbox(gomock.Any(), []uint64{2}).Return(nil)
+
+	relay.Drain(ctx)
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, retryBackoff, backoff(1))
+	require.Equal(t, 4*retryBackoff, backoff(3))
+	require.Equal(t, maxRetryBackoff, backoff(10))
+	require.Equal(t, maxRetryBackoff, backoff(100))
+}
+
+func TestRelay_SinkFailureDoesNotAffectOtherSinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockStore := mocks.NewMockOutboxStore(ctrl)
+	events := mocks.NewMockEventSender(ctrl)
+	webhooks := mocks.NewMockEventSender(ctrl)
+
+	relay := NewRelay(configparser.Outbox{BatchSize: 10}, mockStore, map[string]eventsender.EventSender{
+		database.SinkEvents:   events,
+		database.SinkWebhooks: webhooks,
+	}, testLogger)
+
+	eventsMessage := makeMessage(t, 1, structs.CompanyCreated)
+	eventsMessage.Sink = database.SinkEvents
+	webhooksMessage := makeMessage(t, 2, structs.CompanyCreated)
+	webhooksMessage.Sink = database.SinkWebhooks
+
+	queueFull := errors.New("webhook queue is full")
+
+	mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkEvents, 10, claimLease).Return([]database.OutboxMessage{eventsMessage}, nil)
+	mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkWebhooks, 10, claimLease).Return([]database.OutboxMessage{webhooksMessage}, nil)
+
+	events.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(nil)
+	webhooks.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(queueFull)
+
+	// Only the webhooks message is retried, the event isn't published again.
+	mockStore.EXPECT().MarkOutboxSent(gomock.Any(), uint64(1)).Return(nil)
+	mockStore.EXPECT().MarkOutboxFailed(gomock.Any(), uint64(2), queueFull).Return(nil)
+
+	relay.Drain(context.Background())
+}
+
+func TestRelay_BlockedSinkDoesNotHoldBackOtherSinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockStore := mocks.NewMockOutboxStore(ctrl)
+	events := mocks.NewMockEventSender(ctrl)
+	webhooks := mocks.NewMockEventSender(ctrl)
+
+	relay := NewRelay(configparser.Outbox{PollIntervalMs: 1, BatchSize: 10}, mockStore, map[string]eventsender.EventSender{
+		database.SinkEvents:   events,
+		database.SinkWebhooks: webhooks,
+	}, testLogger)
+
+	eventsMessage := makeMessage(t, 1, structs.CompanyCreated)
+	webhooksMessage := makeMessage(t, 2, structs.CompanyCreated)
+
+	release := make(chan struct{})
+	sent := make(chan struct{})
+
+	gomock.InOrder(
+		mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkWebhooks, 10, claimLease).Return([]database.OutboxMessage{webhooksMessage}, nil),
+		mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkWebhooks, 10, claimLease).Return(nil, nil).AnyTimes(),
+	)
+	webhooks.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).DoAndReturn(func(context.Context, string, structs.Event) error {
+		<-release
+		return nil
+	})
+	mockStore.EXPECT().MarkOutboxSent(gomock.Any(), uint64(2)).Return(nil)
+
+	gomock.InOrder(
+		mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkEvents, 10, claimLease).Return([]database.OutboxMessage{eventsMessage}, nil),
+		mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkEvents, 10, claimLease).Return(nil, nil).AnyTimes(),
+	)
+	events.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(nil)
+	mockStore.EXPECT().MarkOutboxSent(gomock.Any(), uint64(1)).DoAndReturn(func(context.Context, uint64) error {
+		close(sent)
+		return nil
+	})
+
+	relay.Start()
+
+	// The events sink makes progress while the webhooks sink is stuck.
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("the events sink was held back")
+	}
+
+	close(release)
+	require.NoError(t, relay.Close())
+}
+
+func TestRelay_DrainFetchesNextBatchWhenFull(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockStore := mocks.NewMockOutboxStore(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	relay := NewRelay(configparser.Outbox{BatchSize: 1}, mockStore, map[string]eventsender.EventSender{database.SinkEvents: mockSender}, testLogger)
+
+	gomock.InOrder(
+		mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkEvents, 1, claimLease).Return([]database.OutboxMessage{makeMessage(t, 1, structs.CompanyCreated)}, nil),
+		mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkEvents, 1, claimLease).Return(nil, nil),
+	)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(nil)
+	mockStore.EXPECT().MarkOutboxSent(gomock.Any(), uint64(1)).Return(nil)
+
+	relay.Drain(context.Background())
+}
+
+func TestRelay_StartAndClose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockStore := mocks.NewMockOutboxStore(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	relay := NewRelay(configparser.Outbox{PollIntervalMs: 1, BatchSize: 10}, mockStore, map[string]eventsender.EventSender{database.SinkEvents: mockSender}, testLogger)
+
+	polled := make(chan struct{}, 1)
+	mockStore.EXPECT().ClaimOutbox(gomock.Any(), database.SinkEvents, 10, claimLease).DoAndReturn(func(context.Context, string, int, time.Duration) ([]database.OutboxMessage, error) {
+		select {
+		case polled <- struct{}{}:
+		default:
+		}
+		return nil, nil
+	}).MinTimes(1)
+
+	relay.Start()
+
+	select {
+	case <-polled:
+	case <-time.After(time.Second):
+		t.Fatal("relay did not poll the outbox")
+	}
+
+	require.NoError(t, relay.Close())
+}
+
+func TestRelay_PruneDeletesSentMessagesPastRetention(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockStore := mocks.NewMockOutboxStore(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	relay := NewRelay(configparser.Outbox{RetentionHours: 2}, mockStore, map[string]eventsender.EventSender{database.SinkEvents: mockSender}, testLogger)
+
+	var cutoffs []time.Time
+	prune := func(deleted int64) func(context.Context, time.Time, int) (int64, error) {
+		return func(_ context.Context, sentBefore time.Time, _ int) (int64, error) {
+			cutoffs = append(cutoffs, sentBefore)
+			return deleted, nil
+		}
+	}
+
+	gomock.InOrder(
+		mockStore.EXPECT().PruneOutbox(gomock.Any(), gomock.Any(), pruneBatchSize).DoAndReturn(prune(pruneBatchSize)),
+		mockStore.EXPECT().PruneOutbox(gomock.Any(), gomock.Any(), pruneBatchSize).DoAndReturn(prune(3)),
+	)
+
+	relay.Prune(context.Background())
+
+	require.Len(t, cutoffs, 2)
+	require.WithinDuration(t, time.Now().Add(-2*time.Hour), cutoffs[0], time.Minute)
+	require.Equal(t, cutoffs[0], cutoffs[1])
+}
@@ -0,0 +1,109 @@
+// Package problem writes RFC 7807 problem details responses.
+package problem
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const ContentType = "application/problem+json"
+
+// Problem types, clients should branch on these rather than on the title or
+// detail. Problems without a more specific type use about:blank.
+const (
+	TypeBlank                = "about:blank"
+	TypeInvalidID            = "urn:companies:problem:invalid-id"
+	TypeInvalidBody          = "urn:companies:problem:invalid-body"
+	TypeInvalidQuery         = "urn:companies:problem:invalid-query"
+	TypeValidation           = "urn:companies:problem:validation"
+	TypeUnauthorized         = "urn:companies:problem:unauthorized"
+	TypeForbidden            = "urn:companies:problem:forbidden"
+	TypeNotFound             = "urn:companies:problem:not-found"
+	TypeConflict             = "urn:companies:problem:conflict"
+	TypePreconditionFailed   = "urn:companies:problem:precondition-failed"
+	TypeUnsupportedMediaType = "urn:companies:problem:unsupported-media-type"
+	TypeUnavailable          = "urn:companies:problem:unavailable"
+	TypeBodyTooLarge         = "urn:companies:problem:body-too-large"
+)
+
+var titles = map[string]string{
+	TypeInvalidID:            "Invalid ID",
+	TypeInvalidBody:          "Malformed request body",
+	TypeInvalidQuery:         "Invalid query parameters",
+	TypeValidation:           "Validation failed",
+	TypeUnauthorized:         "Authentication required",
+	TypeForbidden:            "Not allowed",
+	TypeNotFound:             "Resource not found",
+	TypeConflict:             "Conflicting state",
+	TypePreconditionFailed:   "Precondition failed",
+	TypeUnsupportedMediaType: "Unsupported media type",
+	TypeUnavailable:          "Service temporarily unavailable",
+	TypeBodyTooLarge:         "Request body too large",
+}
+
+// FieldError is a single failed validation rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+func New(status int, problemType, detail string) *Problem {
+	if problemType == "" {
+		problemType = TypeBlank
+	}
+
+	title, ok := titles[problemType]
+	if !ok {
+		title = http.StatusText(status)
+	}
+
+	return &Problem{Type: problemType, Title: title, Status: status, Detail: detail}
+}
+
+// Validation reports every invalid field of a request.
+func Validation(fields []FieldError) *Problem {
+	p := New(http.StatusBadRequest, TypeValidation, fmt.Sprintf("%v invalid field(s)", len(fields)))
+	p.Errors = fields
+	return p
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// Write sends the problem, the instance defaults to the request path. p isn't
+// modified, so shared problems are safe to write.
+func Write(w http.ResponseWriter, r *http.Request, shared *Problem) {
+	p := *shared
+
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+
+	if p.RequestID == "" {
+		p.RequestID = middleware.GetReqID(r.Context())
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+
+	json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,47 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies/x", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "req-1"))
+	rr := httptest.NewRecorder()
+
+	Write(rr, req, New(http.StatusBadRequest, TypeInvalidID, "x is not a UUID"))
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Equal(t, ContentType, rr.Header().Get("Content-Type"))
+
+	var got Problem
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	require.Equal(t, Problem{
+		Type:      TypeInvalidID,
+		Title:     "Invalid ID",
+		Status:    http.StatusBadRequest,
+		Detail:    "x is not a UUID",
+		Instance:  "/api/v1/companies/x",
+		RequestID: "req-1",
+	}, got)
+}
+
+func TestNew_Blank(t *testing.T) {
+	p := New(http.StatusInternalServerError, "", "")
+	require.Equal(t, TypeBlank, p.Type)
+	require.Equal(t, "Internal Server Error", p.Title)
+}
+
+func TestValidation(t *testing.T) {
+	p := Validation([]FieldError{{Field: "name", Rule: "required", Message: "name is required"}})
+	require.Equal(t, http.StatusBadRequest, p.Status)
+	require.Equal(t, TypeValidation, p.Type)
+	require.Len(t, p.Errors, 1)
+}
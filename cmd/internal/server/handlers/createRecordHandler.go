@@ -1,14 +1,17 @@
 package handlers
 
 import (
-	"companies/cmd/internal/consts"
 	"companies/cmd/internal/database"
 	eventsender "companies/cmd/internal/eventSender"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 
 	"companies/cmd/internal/structs"
+	"context"
 
 	"github.com/google/uuid"
 )
@@ -20,36 +23,50 @@ const (
 
 //go:generate mockgen -source=createRecordHandler.go -destination=../../../tests/mocks/mock_create_record.go -package=mocks
 type createRecordDB interface {
-	CreateRecord(database.CompanyInfo) (uuid.UUID, error)
-	IsRecordExists(string) bool
+	CreateRecord(context.Context, database.CompanyInfo) (uuid.UUID, error)
+	IsRecordExists(context.Context, string) bool
 }
 
-func IsValidInfo(data database.CompanyInfo) bool {
-	if data.Name == nil {
-		return false
+// ValidateInfo checks a complete company record and reports every invalid
+// field, it returns nil for a valid record.
+func ValidateInfo(data database.CompanyInfo) []problem.FieldError {
+	var fields []problem.FieldError
+
+	required := func(field string) {
+		fields = append(fields, problem.FieldError{Field: field, Rule: "required", Message: field + " is required"})
+	}
+
+	maxLength := func(field string, limit int) {
+		fields = append(fields, problem.FieldError{
+			Field:   field,
+			Rule:    "maxLength",
+			Message: fmt.Sprintf("%v must be at most %v characters", field, limit),
+		})
 	}
 
-	if len(*data.Name) > kMaxNameLenght {
-		return false
+	if data.Name == nil {
+		required("name")
+	} else if len(*data.Name) > kMaxNameLenght {
+		maxLength("name", kMaxNameLenght)
 	}
 
 	if data.Description != nil && len(*data.Description) > kMaxDescriptionLenght {
-		return false
+		maxLength("description", kMaxDescriptionLenght)
 	}
 
 	if data.EmployeesCount == nil {
-		return false
+		required("employeesCount")
 	}
 
 	if data.IsRegistered == nil {
-		return false
+		required("isRegistered")
 	}
 
 	if data.Type == nil {
-		return false
+		required("type")
 	}
 
-	return true
+	return fields
 }
 
 // @Summary      Create a new company record
@@ -59,60 +76,50 @@ func IsValidInfo(data database.CompanyInfo) bool {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        company  body      database.CompanyInfo  true  "Company to create"
-// @Success      201      {object}  map[string]string      "Created. Returns the new company ID"
-// @Failure      400      {string}  string                 "Bad request – invalid input or error"
-// @Failure      409      {string}  string                 "Conflict – record already exists"
+// @Success      201      {object}  map[string]string     "Created. Returns the new company ID, the ETag header holds its version"
+// @Failure      400      {object}  problem.Problem       "Bad request – invalid input"
+// @Failure      403      {object}  problem.Problem       "Token lacks the companies:write scope"
+// @Failure      409      {object}  problem.Problem       "Conflict – record already exists"
+// @Failure      413      {object}  problem.Problem       "Body exceeds the size limit"
+// @Failure      500      {object}  problem.Problem       "Creation failed"
+// @Failure      503      {object}  problem.Problem       "Database unavailable"
 // @Router       /api/v1/companies [post]
-func NewCreateRecordHandler(db createRecordDB, eventSender eventsender.EventSender) http.HandlerFunc {
+func NewCreateRecordHandler(db createRecordDB, eventSender eventsender.EventSender, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Println(consts.ApplicationPrefix, "createRecordHandler::handler", r.Body)
+		logger.DebugContext(r.Context(), "Create company")
 		var record database.CompanyInfo
-		json.NewDecoder(r.Body).Decode(&record)
-
-		if !IsValidInfo(record) {
-			log.Println(consts.ApplicationPrefix, "createRecordHandler::handler invalid data")
-			w.WriteHeader(http.StatusBadRequest)
-			eventSender.PublishEvent("data-changed", structs.Event{
-				URL:           r.URL.Path,
-				Type:          structs.Created,
-				Status:        structs.Failed,
-				ErrorMesssage: "invalid data provided",
-			})
+		if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			invalidBody(w, r, err, err.Error())
+			publishError(eventSender, r, structs.CompanyCreateFailed, "", err.Error())
 			return
 		}
 
-		if db.IsRecordExists(*record.Name) {
-			log.Println(consts.ApplicationPrefix, "createRecordHandler::handler record alredy exist")
-			w.WriteHeader(http.StatusConflict)
-			eventSender.PublishEvent("data-changed", structs.Event{
-				URL:           r.URL.Path,
-				Type:          structs.Created,
-				Status:        structs.Failed,
-				ErrorMesssage: "record alredy exist",
-			})
+		if fields := ValidateInfo(record); fields != nil {
+			logger.InfoContext(r.Context(), "Invalid company")
+			problem.Write(w, r, problem.Validation(fields))
+			publishError(eventSender, r, structs.CompanyCreateFailed, "", "invalid data provided")
 			return
 		}
 
-		id, err := db.CreateRecord(record)
+		if db.IsRecordExists(r.Context(), *record.Name) {
+			logger.InfoContext(r.Context(), "Company name already taken")
+			problem.Write(w, r, problem.New(http.StatusConflict, problem.TypeConflict, "a company with this name already exists"))
+			publishError(eventSender, r, structs.CompanyCreateFailed, "", "record alredy exist")
+			return
+		}
+
+		id, err := db.CreateRecord(r.Context(), record)
 		if err != nil {
-			eventSender.PublishEvent("data-changed", structs.Event{
-				URL:           r.URL.Path,
-				Type:          structs.Created,
-				Status:        structs.Failed,
-				ErrorMesssage: err.Error(),
-			})
-			log.Println(consts.ApplicationPrefix, "createRecordHandler::handler error:", err)
-			w.WriteHeader(http.StatusBadRequest)
+			publishError(eventSender, r, structs.CompanyCreateFailed, "", err.Error())
+			writeError(logger, w, r, err)
 			return
 		}
 
-		eventSender.PublishEvent("data-changed", structs.Event{
-			URL:    r.URL.Path,
-			Type:   structs.Created,
-			Status: structs.Success,
-		})
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyCompanyID, id.String()))
 
 		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag(database.InitialVersion))
 		w.WriteHeader(http.StatusCreated)
 
 		resp := map[string]string{"companyId": id.String()}
@@ -3,11 +3,14 @@ package handlers
 import (
 	"bytes"
 	"companies/cmd/internal/database"
+	"companies/cmd/internal/problem"
 	"companies/cmd/tests/mocks"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -40,20 +43,24 @@ func TestNewCreateRecordHandler_Success(t *testing.T) {
 
 	company := makeValidCompany()
 
-	mockDB.EXPECT().IsRecordExists(*company.Name).Return(false)
-	mockDB.EXPECT().CreateRecord(company).Return(*company.ID, nil)
-	mockSender.EXPECT().PublishEvent("data-changed", gomock.Any()).Times(1)
+	mockDB.EXPECT().IsRecordExists(gomock.Any(), *company.Name).Return(false)
+	mockDB.EXPECT().CreateRecord(gomock.Any(), company).Return(*company.ID, nil)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
 	body, _ := json.Marshal(company)
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/companies", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
-	handler := NewCreateRecordHandler(mockDB, mockSender)
+	handler := NewCreateRecordHandler(mockDB, mockSender, testLogger)
 	handler.ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusCreated {
 		t.Errorf("expected status 201, got %v", rr.Code)
 	}
+
+	if etag := rr.Header().Get("ETag"); etag != `"1"` {
+		t.Errorf("expected ETag \"1\", got %v", etag)
+	}
 }
 
 func TestNewCreateRecordHandler_InvalidData(t *testing.T) {
@@ -64,18 +71,54 @@ func TestNewCreateRecordHandler_InvalidData(t *testing.T) {
 
 	invalid := database.CompanyInfo{}
 
-	mockSender.EXPECT().PublishEvent("data-changed", gomock.Any()).Times(1)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Times(1)
 
 	body, _ := json.Marshal(invalid)
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/companies", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
-	handler := NewCreateRecordHandler(mockDB, mockSender)
+	handler := NewCreateRecordHandler(mockDB, mockSender, testLogger)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %v", rr.Code)
+	}
+
+	p := decodeProblem(t, rr)
+	if p.Type != problem.TypeValidation {
+		t.Errorf("expected problem type %v, got %v", problem.TypeValidation, p.Type)
+	}
+
+	var fields []string
+	for _, e := range p.Errors {
+		fields = append(fields, e.Field)
+	}
+	if want := []string{"name", "employeesCount", "isRegistered", "type"}; !slices.Equal(fields, want) {
+		t.Errorf("expected errors for %v, got %v", want, fields)
+	}
+}
+
+func TestNewCreateRecordHandler_MalformedBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockcreateRecordDB(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Times(1)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/companies", bytes.NewBufferString(`{"name":`))
+	rr := httptest.NewRecorder()
+
+	handler := NewCreateRecordHandler(mockDB, mockSender, testLogger)
 	handler.ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusBadRequest {
 		t.Errorf("expected status 400, got %v", rr.Code)
 	}
+
+	if p := decodeProblem(t, rr); p.Type != problem.TypeInvalidBody {
+		t.Errorf("expected problem type %v, got %v", problem.TypeInvalidBody, p.Type)
+	}
 }
 
 func TestNewCreateRecordHandler_RecordExists(t *testing.T) {
@@ -86,19 +129,23 @@ func TestNewCreateRecordHandler_RecordExists(t *testing.T) {
 
 	company := makeValidCompany()
 
-	mockDB.EXPECT().IsRecordExists(*company.Name).Return(true)
-	mockSender.EXPECT().PublishEvent("data-changed", gomock.Any()).Times(1)
+	mockDB.EXPECT().IsRecordExists(gomock.Any(), *company.Name).Return(true)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Times(1)
 
 	body, _ := json.Marshal(company)
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/companies", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
-	handler := NewCreateRecordHandler(mockDB, mockSender)
+	handler := NewCreateRecordHandler(mockDB, mockSender, testLogger)
 	handler.ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusConflict {
 		t.Errorf("expected status 409, got %v", rr.Code)
 	}
+
+	if p := decodeProblem(t, rr); p.Type != problem.TypeConflict {
+		t.Errorf("expected problem type %v, got %v", problem.TypeConflict, p.Type)
+	}
 }
 
 func TestNewCreateRecordHandler_DBCreateError(t *testing.T) {
@@ -109,18 +156,42 @@ func TestNewCreateRecordHandler_DBCreateError(t *testing.T) {
 
 	company := makeValidCompany()
 
-	mockDB.EXPECT().IsRecordExists(*company.Name).Return(false)
-	mockDB.EXPECT().CreateRecord(company).Return(uuid.Nil, context.DeadlineExceeded)
-	mockSender.EXPECT().PublishEvent("data-changed", gomock.Any()).Times(1)
+	mockDB.EXPECT().IsRecordExists(gomock.Any(), *company.Name).Return(false)
+	mockDB.EXPECT().CreateRecord(gomock.Any(), company).Return(uuid.Nil, context.DeadlineExceeded)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Times(1)
 
 	body, _ := json.Marshal(company)
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/companies", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
-	handler := NewCreateRecordHandler(mockDB, mockSender)
+	handler := NewCreateRecordHandler(mockDB, mockSender, testLogger)
 	handler.ServeHTTP(rr, req)
 
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("expected status 400, got %v", rr.Code)
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %v", rr.Code)
+	}
+}
+
+func TestNewCreateRecordHandler_NameTakenConcurrently(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockcreateRecordDB(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	company := makeValidCompany()
+
+	mockDB.EXPECT().IsRecordExists(gomock.Any(), *company.Name).Return(false)
+	mockDB.EXPECT().CreateRecord(gomock.Any(), company).Return(uuid.Nil, fmt.Errorf("CreateRecord error: %w", database.ErrConflict))
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Times(1)
+
+	body, _ := json.Marshal(company)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/companies", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	handler := NewCreateRecordHandler(mockDB, mockSender, testLogger)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected status 409, got %v", rr.Code)
 	}
 }
@@ -1,11 +1,16 @@
 package handlers
 
 import (
-	"companies/cmd/internal/consts"
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/database"
 	eventsender "companies/cmd/internal/eventSender"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
 	"companies/cmd/internal/structs"
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -13,56 +18,78 @@ import (
 
 //go:generate mockgen -source=deleteRecordHandler.go -destination=../../../tests/mocks/mock_delete_record.go -package=mocks
 type deleteRecordDB interface {
-	DeleteRecord(uuid.UUID) error
+	DeleteRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error
+	PurgeRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error
 }
 
 // @Summary      Delete a company
-// @Description  Deletes a company record by its UUID
+// @Description  Soft-deletes a company record by its UUID, it can be restored later. Admins can pass purge=true to remove the record permanently
 // @Tags         Companies
 // @Security     BearerAuth
-// @Param        id   path      string  true  "Company UUID"
-// @Success      200  {string}  string  "Successfully deleted"
-// @Failure      400  {string}  string  "Invalid UUID or deletion failed"
+// @Param        id        path      string           true   "Company UUID"
+// @Param        purge     query     bool             false  "Permanently remove the record, admin only"
+// @Param        If-Match  header    string           false  "ETag the deletion is based on"
+// @Success      204       {string}  string           "Successfully deleted"
+// @Failure      400       {object}  problem.Problem  "Invalid UUID or purge flag"
+// @Failure      403       {object}  problem.Problem  "Token lacks the companies:delete scope, or purge requested by a non-admin"
+// @Failure      404       {object}  problem.Problem  "Company not found"
+// @Failure      412       {object}  problem.Problem  "The company changed since the ETag was issued"
+// @Failure      503       {object}  problem.Problem  "Database unavailable"
 // @Router       /api/v1/companies/{id} [delete]
-func NewDeleteRecordHandler(db deleteRecordDB, eventSender eventsender.EventSender) http.HandlerFunc {
+func NewDeleteRecordHandler(db deleteRecordDB, eventSender eventsender.EventSender, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Println(consts.ApplicationPrefix, "deleteRecordHandler::handler", r.Body)
-
-		log.Println(consts.ApplicationPrefix, "Request path: ", r.URL.Path)
-		log.Println(consts.ApplicationPrefix, "Path param id: ", chi.URLParam(r, "id"))
+		logger.DebugContext(r.Context(), "Delete company")
 
 		uuidStr := chi.URLParam(r, "id")
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyCompanyID, uuidStr))
 		id, err := uuid.Parse(uuidStr)
 
 		if err != nil {
-			eventSender.PublishEvent("data-changed", structs.Event{
-				URL:           r.URL.Path,
-				Type:          structs.Deleted,
-				Status:        structs.Failed,
-				ErrorMesssage: err.Error(),
-			})
-			log.Println(consts.ApplicationPrefix, "deleteRecordHandler::handler error:", err)
-			w.WriteHeader(http.StatusBadRequest)
+			publishError(eventSender, r, structs.CompanyDeleteFailed, uuidStr, err.Error())
+			logger.InfoContext(r.Context(), "Invalid id", logging.Err(err))
+			invalidID(w, r)
+			return
+		}
+
+		purge := false
+		if raw := r.URL.Query().Get("purge"); raw != "" {
+			if purge, err = strconv.ParseBool(raw); err != nil {
+				logger.InfoContext(r.Context(), "Invalid query", logging.Err(err))
+				problem.Write(w, r, problem.New(http.StatusBadRequest, problem.TypeInvalidQuery, "purge must be a boolean"))
+				return
+			}
+		}
+
+		ifMatch, ok := parseIfMatch(r)
+		if !ok {
+			publishError(eventSender, r, structs.CompanyDeleteFailed, uuidStr, database.ErrVersionMismatch.Error())
+			writeError(logger, w, r, database.ErrVersionMismatch)
 			return
 		}
 
-		if err := db.DeleteRecord(id); err != nil {
-			log.Println(consts.ApplicationPrefix, "deleteRecordHandler::handler error:", err)
-			eventSender.PublishEvent("data-changed", structs.Event{
-				URL:           r.URL.Path,
-				Type:          structs.Deleted,
-				Status:        structs.Failed,
-				ErrorMesssage: err.Error(),
-			})
-			w.WriteHeader(http.StatusBadRequest)
+		if purge {
+			if claims, _ := auth.ClaimsFromContext(r.Context()); !claims.IsAdmin() {
+				logger.InfoContext(r.Context(), "Purge requires admin")
+				publishError(eventSender, r, structs.CompanyDeleteFailed, uuidStr, "purge requires admin")
+				problem.Write(w, r, problem.New(http.StatusForbidden, problem.TypeForbidden, "purge requires admin"))
+				return
+			}
+
+			if err := db.PurgeRecord(r.Context(), id, ifMatch); err != nil {
+				publishError(eventSender, r, structs.CompanyDeleteFailed, uuidStr, err.Error())
+				writeError(logger, w, r, err)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 
-		eventSender.PublishEvent("data-changed", structs.Event{
-			URL:    r.URL.Path,
-			Type:   structs.Deleted,
-			Status: structs.Success,
-		})
+		if err := db.DeleteRecord(r.Context(), id, ifMatch); err != nil {
+			publishError(eventSender, r, structs.CompanyDeleteFailed, uuidStr, err.Error())
+			writeError(logger, w, r, err)
+			return
+		}
 
 		w.WriteHeader(http.StatusNoContent)
 	}
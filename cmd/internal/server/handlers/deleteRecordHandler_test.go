@@ -3,10 +3,13 @@ package handlers
 import (
 	"bytes"
 
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/database"
 	"companies/cmd/internal/structs"
 	"companies/cmd/tests/mocks"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -33,13 +36,13 @@ func TestDeleteRecordHandler_Success(t *testing.T) {
 
 	testID := uuid.New()
 
-	mockDB.EXPECT().DeleteRecord(testID).Return(nil)
-	mockSender.EXPECT().PublishEvent("data-changed", gomock.Any()).Times(1)
+	mockDB.EXPECT().DeleteRecord(gomock.Any(), testID, nil).Return(nil)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
 	req := newDeleteTestRequest(http.MethodDelete, "/api/v1/companies/"+testID.String(), testID.String())
 	rr := httptest.NewRecorder()
 
-	handler := NewDeleteRecordHandler(mockDB, mockSender)
+	handler := NewDeleteRecordHandler(mockDB, mockSender, testLogger)
 	handler.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusNoContent, rr.Code)
@@ -51,15 +54,16 @@ func TestDeleteRecordHandler_InvalidUUID(t *testing.T) {
 	mockDB := mocks.NewMockdeleteRecordDB(ctrl)
 	mockSender := mocks.NewMockEventSender(ctrl)
 
-	mockSender.EXPECT().PublishEvent("data-changed", gomock.AssignableToTypeOf(structs.Event{
-		Type:   structs.Deleted,
-		Status: structs.Failed,
-	})).Times(1)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.AssignableToTypeOf(structs.Event{})).DoAndReturn(
+		func(_ context.Context, topic string, event structs.Event) error {
+			assert.Equal(t, structs.CompanyDeleteFailed, event.Type)
+			return nil
+		})
 
 	req := newDeleteTestRequest(http.MethodDelete, "/api/v1/companies/invalid-uuid", "invalid-uuid")
 	rr := httptest.NewRecorder()
 
-	handler := NewDeleteRecordHandler(mockDB, mockSender)
+	handler := NewDeleteRecordHandler(mockDB, mockSender, testLogger)
 	handler.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
@@ -74,17 +78,112 @@ func TestDeleteRecordHandler_DeleteFails(t *testing.T) {
 	testID := uuid.New()
 	expectedErr := errors.New("delete failed")
 
-	mockDB.EXPECT().DeleteRecord(testID).Return(expectedErr)
-	mockSender.EXPECT().PublishEvent("data-changed", gomock.AssignableToTypeOf(structs.Event{
-		Type:   structs.Deleted,
-		Status: structs.Failed,
-	})).Times(1)
+	mockDB.EXPECT().DeleteRecord(gomock.Any(), testID, nil).Return(expectedErr)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.AssignableToTypeOf(structs.Event{})).DoAndReturn(
+		func(_ context.Context, topic string, event structs.Event) error {
+			assert.Equal(t, structs.CompanyDeleteFailed, event.Type)
+			return nil
+		})
 
 	req := newDeleteTestRequest(http.MethodDelete, "/api/v1/companies/"+testID.String(), testID.String())
 	rr := httptest.NewRecorder()
 
-	handler := NewDeleteRecordHandler(mockDB, mockSender)
+	handler := NewDeleteRecordHandler(mockDB, mockSender, testLogger)
 	handler.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestDeleteRecordHandler_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockdeleteRecordDB(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	testID := uuid.New()
+
+	mockDB.EXPECT().DeleteRecord(gomock.Any(), testID, nil).Return(fmt.Errorf("DeleteRecord error: %w", database.ErrNotFound))
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.AssignableToTypeOf(structs.Event{})).Return(nil)
+
+	req := newDeleteTestRequest(http.MethodDelete, "/api/v1/companies/"+testID.String(), testID.String())
+	rr := httptest.NewRecorder()
+
+	handler := NewDeleteRecordHandler(mockDB, mockSender, testLogger)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func newPurgeTestRequest(t *testing.T, username string, id uuid.UUID) *http.Request {
+	token := testToken(t, username)
+
+	req := newDeleteTestRequest(http.MethodDelete, "/api/v1/companies/"+id.String()+"?purge=true", id.String())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestDeleteRecordHandler_Purge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockdeleteRecordDB(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	testID := uuid.New()
+
+	mockDB.EXPECT().PurgeRecord(gomock.Any(), testID, nil).Return(nil)
+	mockDB.EXPECT().DeleteRecord(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	rr := httptest.NewRecorder()
+
+	handler := auth.JWTMiddleware(NewDeleteRecordHandler(mockDB, mockSender, testLogger))
+	handler.ServeHTTP(rr, newPurgeTestRequest(t, auth.AdminUsername, testID))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestDeleteRecordHandler_PurgeRequiresAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockdeleteRecordDB(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	testID := uuid.New()
+
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.AssignableToTypeOf(structs.Event{})).Return(nil)
+
+	rr := httptest.NewRecorder()
+
+	handler := auth.JWTMiddleware(NewDeleteRecordHandler(mockDB, mockSender, testLogger))
+	handler.ServeHTTP(rr, newPurgeTestRequest(t, "analyst", testID))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestDeleteRecordHandler_PreconditionFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockdeleteRecordDB(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	testID := uuid.New()
+
+	mockDB.EXPECT().DeleteRecord(gomock.Any(), testID, []uint64{2}).Return(fmt.Errorf("DeleteRecord error: %w", database.ErrVersionMismatch))
+	mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.AssignableToTypeOf(structs.Event{})).Return(nil).Times(2)
+
+	req := newDeleteTestRequest(http.MethodDelete, "/api/v1/companies/"+testID.String(), testID.String())
+	req.Header.Set("If-Match", `"2"`)
+	rr := httptest.NewRecorder()
+
+	handler := NewDeleteRecordHandler(mockDB, mockSender, testLogger)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	req.Header.Set("If-Match", `W/"2"`)
+	rr = httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
 }
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/problem"
+	"net/http"
+	"strconv"
+)
+
+// includeDeleted reports whether soft-deleted companies were requested. Every
+// reader has companies:read, so seeing deleted companies takes the
+// companies:delete scope, the one that restores and purges them.
+func includeDeleted(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get("includeDeleted")
+	if raw == "" {
+		return false, nil
+	}
+
+	include, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, problem.New(http.StatusBadRequest, problem.TypeInvalidQuery, "includeDeleted must be a boolean")
+	}
+
+	if include {
+		claims, ok := auth.Authenticate(r)
+		if !ok {
+			return false, problem.New(http.StatusUnauthorized, problem.TypeUnauthorized, "includeDeleted requires a valid token")
+		}
+
+		if !claims.HasScope(auth.ScopeCompaniesDelete) {
+			return false, problem.New(http.StatusForbidden, problem.TypeForbidden, "includeDeleted requires the "+auth.ScopeCompaniesDelete+" scope")
+		}
+	}
+
+	return include, nil
+}
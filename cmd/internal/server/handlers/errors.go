@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// writeError writes err as a problem. Problems raised while handling the
+// request are written as is, even when the database wrapped them, and
+// database errors are mapped by kind. Anything else is an internal failure:
+// it gets a blank 500 problem with no detail, the cause is logged instead.
+// Server errors are logged at error level, the caller's mistakes at info.
+func writeError(logger *slog.Logger, w http.ResponseWriter, r *http.Request, err error) {
+	var p *problem.Problem
+
+	switch {
+	case errors.As(err, &p):
+	case errors.Is(err, database.ErrVersionMismatch):
+		p = problem.New(http.StatusPreconditionFailed, problem.TypePreconditionFailed, "the record changed since the ETag was issued")
+	case errors.Is(err, database.ErrNotDeleted):
+		p = problem.New(http.StatusNotFound, problem.TypeNotFound, "no deleted company with this id")
+	case errors.Is(err, database.ErrInvalidCursor):
+		p = problem.New(http.StatusBadRequest, problem.TypeInvalidQuery, "cursor is invalid or belongs to another query")
+	case errors.Is(err, database.ErrNotFound):
+		p = problem.New(http.StatusNotFound, problem.TypeNotFound, "no record with this id")
+	case errors.Is(err, database.ErrConflict):
+		p = problem.New(http.StatusConflict, problem.TypeConflict, "the change conflicts with an existing record")
+	case errors.Is(err, database.ErrValidation):
+		p = problem.New(http.StatusBadRequest, problem.TypeValidation, "the record violates a database constraint")
+	case errors.Is(err, database.ErrUnavailable):
+		w.Header().Set("Retry-After", "1")
+		p = problem.New(http.StatusServiceUnavailable, problem.TypeUnavailable, "the database can't be reached, try again later")
+	default:
+		p = problem.New(http.StatusInternalServerError, "", "")
+	}
+
+	level := slog.LevelInfo
+	if p.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logger.LogAttrs(r.Context(), level, "Request failed", slog.Int("status", p.Status), logging.Err(err))
+
+	problem.Write(w, r, p)
+}
+
+func invalidID(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, r, problem.New(http.StatusBadRequest, problem.TypeInvalidID, "id must be a UUID"))
+}
+
+// invalidBody writes the problem of a body that couldn't be read or decoded,
+// detail describes the failure unless the body went over the size limit.
+func invalidBody(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		problem.Write(w, r, problem.New(http.StatusRequestEntityTooLarge, problem.TypeBodyTooLarge, fmt.Sprintf("the body exceeds %d bytes", tooLarge.Limit)))
+		return
+	}
+
+	problem.Write(w, r, problem.New(http.StatusBadRequest, problem.TypeInvalidBody, detail))
+}
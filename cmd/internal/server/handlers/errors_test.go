@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/problem"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogger = slog.New(slog.DiscardHandler)
+
+// testToken signs a token for username, the admin gets the admin role and
+// everyone else is an editor.
+func testToken(t *testing.T, username string) string {
+	t.Helper()
+
+	roles := []string{auth.RoleEditor}
+	if username == auth.AdminUsername {
+		roles = []string{auth.RoleAdmin}
+	}
+
+	token, err := auth.GenerateToken(username, roles)
+	require.NoError(t, err)
+	return token
+}
+
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) problem.Problem {
+	t.Helper()
+
+	require.Equal(t, problem.ContentType, rr.Header().Get("Content-Type"))
+
+	var p problem.Problem
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&p))
+	require.Equal(t, rr.Code, p.Status)
+	return p
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		typ    string
+		detail bool
+	}{
+		{"wrapped problem", fmt.Errorf("UpdateRecord error: %w", problem.New(http.StatusConflict, problem.TypeConflict, "test failed")), http.StatusConflict, problem.TypeConflict, true},
+		{"version mismatch", fmt.Errorf("DeleteRecord error: %w", database.ErrVersionMismatch), http.StatusPreconditionFailed, problem.TypePreconditionFailed, true},
+		{"not deleted", database.ErrNotDeleted, http.StatusNotFound, problem.TypeNotFound, true},
+		{"invalid cursor", database.ErrInvalidCursor, http.StatusBadRequest, problem.TypeInvalidQuery, true},
+		{"not found", fmt.Errorf("GetRecord error: %w", database.ErrNotFound), http.StatusNotFound, problem.TypeNotFound, true},
+		{"conflict", fmt.Errorf("CreateRecord error: %w", database.ErrConflict), http.StatusConflict, problem.TypeConflict, true},
+		{"constraint", fmt.Errorf("UpdateRecord error: %w", database.ErrValidation), http.StatusBadRequest, problem.TypeValidation, true},
+		{"unavailable", fmt.Errorf("ListRecords error: %w", database.ErrUnavailable), http.StatusServiceUnavailable, problem.TypeUnavailable, true},
+		{"unknown", errors.New("Error 1146: table 'companies.company_infos' doesn't exist"), http.StatusInternalServerError, problem.TypeBlank, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			writeError(testLogger, rr, httptest.NewRequest(http.MethodGet, "/api/v1/companies", nil), tt.err)
+
+			assert.Equal(t, tt.status, rr.Code)
+
+			p := decodeProblem(t, rr)
+			assert.Equal(t, tt.typ, p.Type)
+			assert.Equal(t, "/api/v1/companies", p.Instance)
+			assert.Equal(t, tt.detail, p.Detail != "")
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETags are the record version in quotes. They are strong validators since
+// every change to a record increments its version.
+func etag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+func splitETags(r *http.Request, header string) []string {
+	var tags []string
+	for _, value := range r.Header.Values(header) {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// parseIfMatch returns the versions listed in If-Match. ok is false if the
+// header lists only tags that can never match, such as weak ones. A missing
+// header or "*" returns no versions, which makes the write unconditional.
+func parseIfMatch(r *http.Request) (versions []uint64, ok bool) {
+	tags := splitETags(r, "If-Match")
+	if len(tags) == 0 {
+		return nil, true
+	}
+
+	for _, tag := range tags {
+		if tag == "*" {
+			return nil, true
+		}
+
+		unquoted, err := strconv.Unquote(tag)
+		if err != nil || !strings.HasPrefix(tag, `"`) {
+			continue
+		}
+
+		if version, err := strconv.ParseUint(unquoted, 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions, len(versions) > 0
+}
+
+// notModified reports whether If-None-Match matches the version, it uses the
+// weak comparison required for GET.
+func notModified(r *http.Request, version uint64) bool {
+	current := etag(version)
+
+	for _, tag := range splitETags(r, "If-None-Match") {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == current {
+			return true
+		}
+	}
+
+	return false
+}
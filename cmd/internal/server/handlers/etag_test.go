@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header   string
+		versions []uint64
+		ok       bool
+	}{
+		{"", nil, true},
+		{"*", nil, true},
+		{`"3"`, []uint64{3}, true},
+		{`"1", "4"`, []uint64{1, 4}, true},
+		{`W/"3"`, nil, false},
+		{`"abc"`, nil, false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPatch, "/", nil)
+		if tt.header != "" {
+			req.Header.Set("If-Match", tt.header)
+		}
+
+		versions, ok := parseIfMatch(req)
+		assert.Equal(t, tt.versions, versions, tt.header)
+		assert.Equal(t, tt.ok, ok, tt.header)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.False(t, notModified(req, 2))
+
+	req.Header.Set("If-None-Match", `"1", W/"2"`)
+	assert.True(t, notModified(req, 2))
+	assert.False(t, notModified(req, 3))
+
+	req.Header.Set("If-None-Match", "*")
+	assert.True(t, notModified(req, 3))
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"companies/cmd/internal/consts"
+	eventsender "companies/cmd/internal/eventSender"
+	"companies/cmd/internal/structs"
+	"companies/cmd/internal/tracing"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// publishError reports a failed change request, carrying the request ID and
+// the trace context of the request so consumers can correlate it.
+func publishError(eventSender eventsender.EventSender, r *http.Request, eventType, subject, message string) {
+	event := structs.NewErrorEvent(eventType, subject, r.URL.Path, message)
+	event.CorrelationID = middleware.GetReqID(r.Context())
+	event.TraceParent, event.TraceState = tracing.Inject(r.Context())
+
+	eventSender.PublishEvent(r.Context(), consts.DataChangedTopic, event)
+}
@@ -1,10 +1,11 @@
 package handlers
 
 import (
-	"companies/cmd/internal/consts"
 	"companies/cmd/internal/database"
+	"companies/cmd/internal/logging"
+	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -13,7 +14,7 @@ import (
 
 //go:generate mockgen -source=getRecordHandler.go -destination=../../../tests/mocks/mock_get_record.go -package=mocks
 type getRecordDB interface {
-	GetRecord(uuid.UUID) (database.CompanyInfo, error)
+	GetRecord(ctx context.Context, id uuid.UUID, includeDeleted bool) (database.CompanyInfo, error)
 }
 
 // @Summary      Get a company by ID
@@ -21,31 +22,48 @@ type getRecordDB interface {
 // @Tags         Companies
 // @Accept       json
 // @Produce      json
-// @Param        id   path      string                true  "Company UUID"
-// @Success      200  {object}  database.CompanyInfo  "Company found"
-// @Failure      400  {string}  string                "Invalid UUID"
-// @Failure      404  {string}  string                "Company not found"
+// @Security     BearerAuth
+// @Param        id              path      string                true   "Company UUID"
+// @Param        includeDeleted  query     bool                  false  "Return the company even if it is soft-deleted, needs the companies:delete scope"
+// @Param        If-None-Match   header    string                false  "ETag of a cached copy"
+// @Success      200             {object}  database.CompanyInfo  "Company found"
+// @Success      304             {string}  string                "Cached copy is current"
+// @Failure      400             {object}  problem.Problem       "Invalid UUID"
+// @Failure      401             {object}  problem.Problem       "Missing or invalid token"
+// @Failure      403             {object}  problem.Problem       "Token lacks the companies:read scope, or companies:delete with includeDeleted"
+// @Failure      404             {object}  problem.Problem       "Company not found"
+// @Failure      503             {object}  problem.Problem       "Database unavailable"
 // @Router       /api/v1/companies/{id} [get]
-func NewGetRecordHandler(db getRecordDB) http.HandlerFunc {
+func NewGetRecordHandler(db getRecordDB, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Println(consts.ApplicationPrefix, "getRecordHandler::handler")
-
-		log.Println(consts.ApplicationPrefix, "Request path: ", r.URL.Path)
-		log.Println(consts.ApplicationPrefix, "Path param id: ", chi.URLParam(r, "id"))
+		logger.DebugContext(r.Context(), "Get company")
 
 		uuidStr := chi.URLParam(r, "id")
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyCompanyID, uuidStr))
 		id, err := uuid.Parse(uuidStr)
 		if err != nil {
-			log.Println(consts.ApplicationPrefix, "getRecordHandler::handler error:", err)
-			w.WriteHeader(http.StatusBadRequest)
+			logger.InfoContext(r.Context(), "Invalid id", logging.Err(err))
+			invalidID(w, r)
+			return
+		}
+
+		withDeleted, err := includeDeleted(r)
+		if err != nil {
+			writeError(logger, w, r, err)
 			return
 		}
 
-		record, err := db.GetRecord(id)
+		record, err := db.GetRecord(r.Context(), id, withDeleted)
 
 		if err != nil {
-			log.Println(consts.ApplicationPrefix, "getRecordHandler::handler error:", err)
-			w.WriteHeader(http.StatusNotFound)
+			writeError(logger, w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", etag(record.Version))
+
+		if notModified(r, record.Version) {
+			w.WriteHeader(http.StatusNotModified)
 			return
 		}
 
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"companies/cmd/internal/auth"
 	"companies/cmd/internal/database"
+	"companies/cmd/internal/problem"
 	"companies/cmd/tests/mocks"
 	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,6 +16,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func ptrString(s string) *string {
@@ -24,7 +27,7 @@ func TestGetRecordHandler_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	mockDB := mocks.NewMockgetRecordDB(ctrl)
-	handler := NewGetRecordHandler(mockDB)
+	handler := NewGetRecordHandler(mockDB, testLogger)
 
 	id := uuid.New()
 	record := database.CompanyInfo{
@@ -32,7 +35,7 @@ func TestGetRecordHandler_Success(t *testing.T) {
 		Name: ptrString("Test Company"),
 	}
 
-	mockDB.EXPECT().GetRecord(id).Return(record, nil)
+	mockDB.EXPECT().GetRecord(gomock.Any(), id, false).Return(record, nil)
 
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", id.String())
@@ -56,7 +59,7 @@ func TestGetRecordHandler_InvalidUUID(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	mockDB := mocks.NewMockgetRecordDB(ctrl)
-	handler := NewGetRecordHandler(mockDB)
+	handler := NewGetRecordHandler(mockDB, testLogger)
 
 	invalidID := "invalid-uuid"
 	rctx := chi.NewRouteContext()
@@ -75,11 +78,11 @@ func TestGetRecordHandler_RecordNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	mockDB := mocks.NewMockgetRecordDB(ctrl)
-	handler := NewGetRecordHandler(mockDB)
+	handler := NewGetRecordHandler(mockDB, testLogger)
 
 	id := uuid.New()
 
-	mockDB.EXPECT().GetRecord(id).Return(database.CompanyInfo{}, errors.New("not found"))
+	mockDB.EXPECT().GetRecord(gomock.Any(), id, false).Return(database.CompanyInfo{}, fmt.Errorf("GetRecord error: %w", database.ErrNotFound))
 
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", id.String())
@@ -92,3 +95,128 @@ func TestGetRecordHandler_RecordNotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, rr.Code)
 }
+
+func TestGetRecordHandler_DatabaseUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockgetRecordDB(ctrl)
+	handler := NewGetRecordHandler(mockDB, testLogger)
+
+	id := uuid.New()
+
+	mockDB.EXPECT().GetRecord(gomock.Any(), id, false).Return(database.CompanyInfo{}, fmt.Errorf("GetRecord error: %w", database.ErrUnavailable))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies/"+id.String(), nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestGetRecordHandler_IncludeDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockgetRecordDB(ctrl)
+	handler := NewGetRecordHandler(mockDB, testLogger)
+
+	id := uuid.New()
+
+	mockDB.EXPECT().GetRecord(gomock.Any(), id, true).Return(database.CompanyInfo{ID: &id}, nil)
+
+	token := testToken(t, auth.AdminUsername)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies/"+id.String()+"?includeDeleted=true", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestGetRecordHandler_IncludeDeletedUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockgetRecordDB(ctrl)
+	handler := NewGetRecordHandler(mockDB, testLogger)
+
+	id := uuid.New()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies/"+id.String()+"?includeDeleted=true", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestGetRecordHandler_IncludeDeletedViewer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockgetRecordDB(ctrl)
+	handler := NewGetRecordHandler(mockDB, testLogger)
+
+	id := uuid.New()
+
+	// Seeing deleted companies takes the scope that restores them.
+	token, err := auth.GenerateToken("analytics", []string{auth.RoleViewer})
+	require.NoError(t, err)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies/"+id.String()+"?includeDeleted=true", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, problem.TypeForbidden, decodeProblem(t, rr).Type)
+}
+
+func TestGetRecordHandler_NotModified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockgetRecordDB(ctrl)
+	handler := NewGetRecordHandler(mockDB, testLogger)
+
+	id := uuid.New()
+
+	mockDB.EXPECT().GetRecord(gomock.Any(), id, false).Return(database.CompanyInfo{ID: &id, Version: 4}, nil).Times(2)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies/"+id.String(), nil)
+	req.Header.Set("If-None-Match", `"4"`)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+	assert.Empty(t, rr.Body.String())
+
+	req.Header.Set("If-None-Match", `"3"`)
+	rr = httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
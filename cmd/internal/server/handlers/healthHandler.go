@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"companies/cmd/internal/health"
+	"log/slog"
+	"net/http"
+)
+
+// @Summary      Liveness probe
+// @Description  Succeeds while the process can serve HTTP, dependencies aren't checked
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  health.Report  "The process is alive"
+// @Router       /healthz [get]
+func NewLivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, health.Report{Status: health.StatusOK})
+	}
+}
+
+// @Summary      Readiness probe
+// @Description  Checks MySQL, the event broker and the outbox backlog. Fails once the app starts shutting down so that load balancers drain it
+// @Tags         Health
+// @Produce      json
+// @Success      200  {object}  health.Report  "Ready to serve traffic"
+// @Failure      503  {object}  health.Report  "A dependency is failing or the app is shutting down"
+// @Router       /readyz [get]
+func NewReadinessHandler(checker *health.Checker, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Check(r.Context())
+		if !report.OK() {
+			logger.WarnContext(r.Context(), "Not ready", slog.Bool("draining", report.Draining), slog.Any("components", report.Components))
+			writeJSON(w, http.StatusServiceUnavailable, report)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, report)
+	}
+}
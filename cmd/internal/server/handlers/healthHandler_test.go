@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"companies/cmd/internal/health"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveReadiness(t *testing.T, checker *health.Checker) (int, health.Report) {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	NewReadinessHandler(checker, testLogger).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	return rr.Code, report
+}
+
+func TestLivenessHandler(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewLivenessHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+func TestReadinessHandler_Ready(t *testing.T) {
+	checker := health.NewChecker(0)
+	checker.Add("mysql", func(context.Context) error { return nil })
+
+	code, report := serveReadiness(t, checker)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Equal(t, health.StatusOK, report.Components["mysql"].Status)
+}
+
+func TestReadinessHandler_ComponentFails(t *testing.T) {
+	checker := health.NewChecker(0)
+	checker.Add("mysql", func(context.Context) error { return nil })
+	checker.Add("kafka", func(context.Context) error { return errors.New("broker down") })
+
+	code, report := serveReadiness(t, checker)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, health.StatusOK, report.Components["mysql"].Status)
+	assert.Equal(t, "broker down", report.Components["kafka"].Error)
+}
+
+func TestReadinessHandler_Draining(t *testing.T) {
+	checker := health.NewChecker(0)
+	checker.Add("mysql", func(context.Context) error { return nil })
+	checker.Drain()
+
+	code, report := serveReadiness(t, checker)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.True(t, report.Draining)
+}
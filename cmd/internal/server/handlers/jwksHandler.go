@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"net/http"
+	"time"
+)
+
+// jwksCacheControl lets verifiers cache the key set. Keys are published
+// before they start signing, so it only has to be shorter than that lead.
+const jwksCacheControl = "public, max-age=300"
+
+// @Summary      Token verification keys
+// @Description  Lists the public keys access tokens are signed with, selected by the kid token header. Keys are listed before they start signing and until the tokens they signed expire. Empty while tokens are signed with a shared secret
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  auth.JWKS  "JSON Web Key Set"
+// @Router       /.well-known/jwks.json [get]
+func NewJWKSHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", jwksCacheControl)
+		writeJSON(w, http.StatusOK, auth.PublicJWKS(time.Now()))
+	}
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKSHandler_SharedSecret(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewJWKSHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "public, max-age=300", rr.Header().Get("Cache-Control"))
+
+	// The HS256 secret must never be published.
+	var got auth.JWKS
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	assert.NotNil(t, got.Keys)
+	assert.Empty(t, got.Keys)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+//go:generate mockgen -source=listRecordsHandler.go -destination=../../../tests/mocks/mock_list_records.go -package=mocks
+type listRecordsDB interface {
+	ListRecords(context.Context, database.ListQuery) (database.ListPage, error)
+}
+
+func parseIntParam(values url.Values, key string) (*int, error) {
+	raw := values.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%v must be an integer", key)
+	}
+
+	return &value, nil
+}
+
+func parseListQuery(values url.Values) (database.ListQuery, error) {
+	query := database.ListQuery{
+		NamePrefix: values.Get("namePrefix"),
+		Cursor:     values.Get("cursor"),
+	}
+
+	var err error
+
+	if query.Type, err = parseIntParam(values, "type"); err != nil {
+		return query, err
+	}
+
+	if query.MinEmployees, err = parseIntParam(values, "minEmployees"); err != nil {
+		return query, err
+	}
+
+	if query.MaxEmployees, err = parseIntParam(values, "maxEmployees"); err != nil {
+		return query, err
+	}
+
+	if raw := values.Get("isRegistered"); raw != "" {
+		isRegistered, err := strconv.ParseBool(raw)
+		if err != nil {
+			return query, errors.New("isRegistered must be a boolean")
+		}
+		query.IsRegistered = &isRegistered
+	}
+
+	limit, err := parseIntParam(values, "limit")
+	if err != nil {
+		return query, err
+	}
+
+	if limit != nil {
+		if *limit < 1 || *limit > database.MaxListLimit {
+			return query, fmt.Errorf("limit must be between 1 and %v", database.MaxListLimit)
+		}
+		query.Limit = *limit
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		query.Descending = strings.HasPrefix(sort, "-")
+		query.SortBy = strings.TrimPrefix(sort, "-")
+
+		if !database.IsSortable(query.SortBy) {
+			return query, fmt.Errorf("unsupported sort field %v", query.SortBy)
+		}
+	}
+
+	return query, nil
+}
+
+// @Summary      List companies
+// @Description  Returns a page of companies matching the filters. Pass nextCursor from the previous page as cursor to fetch the next one
+// @Tags         Companies
+// @Produce      json
+// @Security     BearerAuth
+// @Param        type            query     int                false  "Company type"
+// @Param        isRegistered    query     bool               false  "Registration status"
+// @Param        minEmployees    query     int                false  "Minimum employees count"
+// @Param        maxEmployees    query     int                false  "Maximum employees count"
+// @Param        namePrefix      query     string             false  "Name prefix"
+// @Param        sort            query     string             false  "Sort field, prefix with - for descending order (e.g. -employeesCount)"
+// @Param        limit           query     int                false  "Page size (1-100, default 20)"
+// @Param        cursor          query     string             false  "Cursor returned by the previous page"
+// @Param        includeDeleted  query     bool               false  "Include soft-deleted companies, needs the companies:delete scope"
+// @Success      200             {object}  database.ListPage  "Page of companies"
+// @Failure      400             {object}  problem.Problem    "Invalid query parameters"
+// @Failure      401             {object}  problem.Problem    "Missing or invalid token"
+// @Failure      403             {object}  problem.Problem    "Token lacks the companies:read scope, or companies:delete with includeDeleted"
+// @Failure      500             {object}  problem.Problem    "Listing failed"
+// @Failure      503             {object}  problem.Problem    "Database unavailable"
+// @Router       /api/v1/companies [get]
+func NewListRecordsHandler(db listRecordsDB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "List companies")
+
+		query, err := parseListQuery(r.URL.Query())
+		if err != nil {
+			logger.InfoContext(r.Context(), "Invalid query", logging.Err(err))
+			problem.Write(w, r, problem.New(http.StatusBadRequest, problem.TypeInvalidQuery, err.Error()))
+			return
+		}
+
+		if query.IncludeDeleted, err = includeDeleted(r); err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		page, err := db.ListRecords(r.Context(), query)
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(page)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/problem"
+	"companies/cmd/tests/mocks"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListRecordsHandler_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocklistRecordsDB(ctrl)
+	handler := NewListRecordsHandler(mockDB, testLogger)
+
+	company := makeValidCompany()
+	typ := 2
+	isReg := false
+	minEmp := 10
+
+	mockDB.EXPECT().ListRecords(gomock.Any(), database.ListQuery{
+		Type:         &typ,
+		IsRegistered: &isReg,
+		MinEmployees: &minEmp,
+		NamePrefix:   "Te",
+		SortBy:       "employeesCount",
+		Descending:   true,
+		Limit:        5,
+		Cursor:       "abc",
+	}).Return(database.ListPage{Items: []database.CompanyInfo{company}, NextCursor: "next"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies?type=2&isRegistered=false&minEmployees=10&namePrefix=Te&sort=-employeesCount&limit=5&cursor=abc", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var got database.ListPage
+	err := json.NewDecoder(rr.Body).Decode(&got)
+	assert.NoError(t, err)
+	assert.Equal(t, "next", got.NextCursor)
+	assert.Len(t, got.Items, 1)
+	assert.Equal(t, *company.ID, *got.Items[0].ID)
+}
+
+func TestListRecordsHandler_InvalidQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocklistRecordsDB(ctrl)
+	handler := NewListRecordsHandler(mockDB, testLogger)
+
+	for _, query := range []string{"type=abc", "isRegistered=maybe", "limit=0", "limit=101", "sort=unknown"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/companies?"+query, nil)
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestListRecordsHandler_InvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocklistRecordsDB(ctrl)
+	handler := NewListRecordsHandler(mockDB, testLogger)
+
+	mockDB.EXPECT().ListRecords(gomock.Any(), gomock.Any()).Return(database.ListPage{}, database.ErrInvalidCursor)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies?cursor=broken", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestListRecordsHandler_DBError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocklistRecordsDB(ctrl)
+	handler := NewListRecordsHandler(mockDB, testLogger)
+
+	mockDB.EXPECT().ListRecords(gomock.Any(), gomock.Any()).Return(database.ListPage{}, errors.New("db down"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestListRecordsHandler_IncludeDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocklistRecordsDB(ctrl)
+	handler := NewListRecordsHandler(mockDB, testLogger)
+
+	mockDB.EXPECT().ListRecords(gomock.Any(), database.ListQuery{IncludeDeleted: true}).Return(database.ListPage{}, nil)
+
+	token := testToken(t, auth.AdminUsername)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies?includeDeleted=true", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/companies?includeDeleted=true", nil)
+	req.Header.Set("Authorization", "Bearer invalid")
+	rr = httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestListRecordsHandler_IncludeDeletedViewer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocklistRecordsDB(ctrl)
+	handler := NewListRecordsHandler(mockDB, testLogger)
+
+	// Viewers read companies but mustn't see the deleted ones.
+	token, err := auth.GenerateToken("analytics", []string{auth.RoleViewer})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies?includeDeleted=true", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, problem.TypeForbidden, decodeProblem(t, rr).Type)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+type logLevel struct {
+	Level string `json:"level" example:"INFO"`
+}
+
+// @Summary      Get the log level
+// @Description  Returns the level below which log records are dropped, admin only
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  logLevel         "Current log level"
+// @Failure      403  {object}  problem.Problem  "Caller is not an admin"
+// @Router       /api/v1/admin/log-level [get]
+func NewGetLogLevelHandler(level *slog.LevelVar, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(logger, w, r) {
+			return
+		}
+
+		writeJSON(w, http.StatusOK, logLevel{level.Level().String()})
+	}
+}
+
+// @Summary      Change the log level
+// @Description  Sets the level below which log records are dropped until the next restart, admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        level  body      logLevel         true  "One of debug, info, warn or error"
+// @Success      200    {object}  logLevel         "New log level"
+// @Failure      400    {object}  problem.Problem  "Invalid level"
+// @Failure      403    {object}  problem.Problem  "Caller is not an admin"
+// @Failure      413    {object}  problem.Problem  "Body exceeds the size limit"
+// @Router       /api/v1/admin/log-level [put]
+func NewSetLogLevelHandler(level *slog.LevelVar, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(logger, w, r) {
+			return
+		}
+
+		var req logLevel
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			invalidBody(w, r, err, err.Error())
+			return
+		}
+
+		newLevel, err := logging.ParseLevel(req.Level)
+		if err != nil {
+			logger.InfoContext(r.Context(), "Invalid log level", logging.Err(err))
+			problem.Write(w, r, problem.Validation([]problem.FieldError{{
+				Field:   "level",
+				Rule:    "oneOf",
+				Message: "level must be one of debug, info, warn or error",
+			}}))
+			return
+		}
+
+		old := level.Level()
+		level.Set(newLevel)
+		logger.WarnContext(r.Context(), "Log level changed", slog.String("from", old.String()), slog.String("to", newLevel.String()))
+
+		writeJSON(w, http.StatusOK, logLevel{newLevel.String()})
+	}
+}
+
+func requireAdmin(logger *slog.Logger, w http.ResponseWriter, r *http.Request) bool {
+	if claims, _ := auth.ClaimsFromContext(r.Context()); claims.IsAdmin() {
+		return true
+	}
+
+	logger.InfoContext(r.Context(), "Admin required")
+	problem.Write(w, r, problem.New(http.StatusForbidden, problem.TypeForbidden, "admin required"))
+	return false
+}
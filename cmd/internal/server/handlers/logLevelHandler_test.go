@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/problem"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLogLevelTestRequest(t *testing.T, method, username, body string) *http.Request {
+	token := testToken(t, username)
+
+	req := httptest.NewRequest(method, "/api/v1/admin/log-level", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestGetLogLevelHandler_Success(t *testing.T) {
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+
+	rr := httptest.NewRecorder()
+	auth.JWTMiddleware(NewGetLogLevelHandler(level, testLogger)).ServeHTTP(rr, newLogLevelTestRequest(t, http.MethodGet, auth.AdminUsername, ""))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"level":"WARN"}`, rr.Body.String())
+}
+
+func TestSetLogLevelHandler_Success(t *testing.T) {
+	level := new(slog.LevelVar)
+
+	rr := httptest.NewRecorder()
+	auth.JWTMiddleware(NewSetLogLevelHandler(level, testLogger)).ServeHTTP(rr, newLogLevelTestRequest(t, http.MethodPut, auth.AdminUsername, `{"level":"debug"}`))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"level":"DEBUG"}`, rr.Body.String())
+	assert.Equal(t, slog.LevelDebug, level.Level())
+}
+
+func TestSetLogLevelHandler_InvalidLevel(t *testing.T) {
+	level := new(slog.LevelVar)
+
+	rr := httptest.NewRecorder()
+	auth.JWTMiddleware(NewSetLogLevelHandler(level, testLogger)).ServeHTTP(rr, newLogLevelTestRequest(t, http.MethodPut, auth.AdminUsername, `{"level":"verbose"}`))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	p := decodeProblem(t, rr)
+	assert.Equal(t, problem.TypeValidation, p.Type)
+	require.Len(t, p.Errors, 1)
+	assert.Equal(t, "level", p.Errors[0].Field)
+	assert.Equal(t, slog.LevelInfo, level.Level())
+}
+
+func TestSetLogLevelHandler_RequiresAdmin(t *testing.T) {
+	level := new(slog.LevelVar)
+
+	rr := httptest.NewRecorder()
+	auth.JWTMiddleware(NewSetLogLevelHandler(level, testLogger)).ServeHTTP(rr, newLogLevelTestRequest(t, http.MethodPut, "alice", `{"level":"debug"}`))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, slog.LevelInfo, level.Level())
+	assert.Equal(t, problem.TypeForbidden, decodeProblem(t, rr).Type)
+}
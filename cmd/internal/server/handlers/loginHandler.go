@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=loginHandler.go -destination=../../../tests/mocks/mock_login.go -package=mocks
+type loginDB interface {
+	GetUser(ctx context.Context, username string) (database.User, error)
+	RecordLoginFailure(ctx context.Context, username string, maxFailures int, lockout time.Duration) (database.User, error)
+	RecordLoginSuccess(ctx context.Context, username string) error
+	CreateRefreshToken(context.Context, database.RefreshToken) error
+}
+
+type loginRequest struct {
+	Username string `json:"username" example:"admin"`
+	Password string `json:"password" example:"correct horse battery staple"`
+}
+
+type tokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	// ExpiresIn is the lifetime of the access token in seconds.
+	ExpiresIn int `json:"expiresIn" example:"3600"`
+}
+
+func newTokenResponse(user database.User, refreshToken string) (tokenResponse, error) {
+	token, err := auth.GenerateToken(user.Username, user.Roles)
+	if err != nil {
+		return tokenResponse{}, err
+	}
+
+	return tokenResponse{Token: token, RefreshToken: refreshToken, ExpiresIn: int(auth.AccessTokenTTL.Seconds())}, nil
+}
+
+// The same answer for unknown, locked and disabled users and wrong passwords,
+// so the endpoint can't be used to find out which usernames exist.
+func invalidCredentials(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.TypeUnauthorized, "invalid username or password"))
+}
+
+// @Summary      Log in
+// @Description  Exchanges a username and password for a bearer token and a refresh token. After maxFailedLogins failed logins in a row the user is locked out for a while, a locked out user gets the same answer as a wrong password
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        credentials  body      loginRequest     true  "Username and password"
+// @Success      200          {object}  tokenResponse    "Bearer and refresh tokens"
+// @Failure      400          {object}  problem.Problem  "Missing username or password"
+// @Failure      401          {object}  problem.Problem  "Invalid username or password"
+// @Failure      413          {object}  problem.Problem  "Body exceeds the size limit"
+// @Failure      503          {object}  problem.Problem  "Database unavailable"
+// @Router       /api/v1/auth/login [post]
+func NewLoginHandler(db loginDB, maxFailures int, lockout, refreshTTL time.Duration, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req loginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			invalidBody(w, r, err, err.Error())
+			return
+		}
+
+		var fields []problem.FieldError
+		if req.Username == "" {
+			fields = append(fields, problem.FieldError{Field: "username", Rule: "required", Message: "username is required"})
+		}
+		if req.Password == "" {
+			fields = append(fields, problem.FieldError{Field: "password", Rule: "required", Message: "password is required"})
+		}
+		if len(fields) > 0 {
+			problem.Write(w, r, problem.Validation(fields))
+			return
+		}
+
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyUser, req.Username))
+
+		// Longer passwords can't match, their prefix is only hashed to keep
+		// the timing of the answer the same.
+		tooLong := len(req.Password) > auth.MaxPasswordLength
+		if tooLong {
+			req.Password = req.Password[:auth.MaxPasswordLength]
+		}
+
+		user, err := db.GetUser(r.Context(), req.Username)
+		if errors.Is(err, database.ErrNotFound) {
+			auth.VerifyNoUser(req.Password)
+			logger.InfoContext(r.Context(), "Login failed", slog.String("reason", "unknown user"))
+			invalidCredentials(w, r)
+			return
+		}
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		// The password is checked before the lockout, so a locked user costs
+		// the same hashing as any other.
+		ok, err := auth.VerifyPassword(user.PasswordHash, req.Password)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "Stored password hash is invalid", logging.Err(err))
+		}
+		ok = ok && !tooLong
+
+		if user.Locked(time.Now()) {
+			logger.InfoContext(r.Context(), "Login failed", slog.String("reason", "locked"), slog.Time("until", *user.LockedUntil))
+			invalidCredentials(w, r)
+			return
+		}
+
+		if !ok {
+			user, err := db.RecordLoginFailure(r.Context(), user.Username, maxFailures, lockout)
+			if err != nil {
+				logger.WarnContext(r.Context(), "Failed to record failed login", logging.Err(err))
+			}
+
+			if user.Locked(time.Now()) {
+				logger.WarnContext(r.Context(), "User locked out", slog.Time("until", *user.LockedUntil))
+				invalidCredentials(w, r)
+				return
+			}
+
+			logger.InfoContext(r.Context(), "Login failed", slog.String("reason", "wrong password"))
+			invalidCredentials(w, r)
+			return
+		}
+
+		if user.Disabled {
+			logger.InfoContext(r.Context(), "Login failed", slog.String("reason", "disabled"))
+			invalidCredentials(w, r)
+			return
+		}
+
+		if user.FailedLogins > 0 || user.LockedUntil != nil {
+			if err := db.RecordLoginSuccess(r.Context(), user.Username); err != nil {
+				logger.WarnContext(r.Context(), "Failed to reset failed logins", logging.Err(err))
+			}
+		}
+
+		refreshToken, hash, err := auth.NewRefreshToken()
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		err = db.CreateRefreshToken(r.Context(), database.RefreshToken{
+			TokenHash: hash,
+			FamilyID:  uuid.New(),
+			Username:  user.Username,
+			ExpiresAt: time.Now().Add(refreshTTL),
+		})
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		resp, err := newTokenResponse(user, refreshToken)
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		logger.InfoContext(r.Context(), "Logged in")
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/problem"
+	"companies/cmd/tests/mocks"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testMaxFailures = 3
+	testLockout     = 15 * time.Minute
+	testRefreshTTL  = 24 * time.Hour
+	testPassword    = "correct horse battery staple"
+)
+
+func newTestUser(t *testing.T, username string) database.User {
+	hash, err := auth.HashPassword(testPassword)
+	require.NoError(t, err)
+
+	return database.User{Username: username, PasswordHash: hash}
+}
+
+func login(handler http.Handler, username, password string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"username":%q,"password":%q}`, username, password)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body)))
+	return rr
+}
+
+func TestLoginHandler_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(newTestUser(t, "jane"), nil)
+
+	var stored database.RefreshToken
+	mockDB.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, token database.RefreshToken) error {
+		stored = token
+		return nil
+	})
+
+	rr := login(handler, "jane", testPassword)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var got tokenResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	assert.Equal(t, 3600, got.ExpiresIn)
+	assert.Equal(t, auth.HashRefreshToken(got.RefreshToken), stored.TokenHash)
+	assert.Equal(t, "jane", stored.Username)
+	assert.WithinDuration(t, time.Now().Add(testRefreshTTL), stored.ExpiresAt, time.Minute)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+got.Token)
+	claims, ok := auth.Authenticate(req)
+	require.True(t, ok)
+	assert.Equal(t, "jane", claims.Username)
+}
+
+func TestLoginHandler_ResetsFailedLogins(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	user := newTestUser(t, "jane")
+	user.FailedLogins = 2
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(user, nil)
+	mockDB.EXPECT().RecordLoginSuccess(gomock.Any(), "jane").Return(nil)
+	mockDB.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+
+	rr := login(handler, "jane", testPassword)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestLoginHandler_WrongPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	user := newTestUser(t, "jane")
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(user, nil)
+	user.FailedLogins = 1
+	mockDB.EXPECT().RecordLoginFailure(gomock.Any(), "jane", testMaxFailures, testLockout).Return(user, nil)
+
+	rr := login(handler, "jane", "wrong password")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, problem.TypeUnauthorized, decodeProblem(t, rr).Type)
+}
+
+func TestLoginHandler_PasswordWithSuffix(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	// The longest password allowed, followed by anything, must not match it.
+	password := strings.Repeat("p", auth.MaxPasswordLength)
+	hash, err := auth.HashPassword(password)
+	require.NoError(t, err)
+
+	user := database.User{Username: "jane", PasswordHash: hash}
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(user, nil)
+	user.FailedLogins = 1
+	mockDB.EXPECT().RecordLoginFailure(gomock.Any(), "jane", testMaxFailures, testLockout).Return(user, nil)
+
+	rr := login(handler, "jane", password+"suffix")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestLoginHandler_LocksOut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	user := newTestUser(t, "jane")
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(user, nil)
+	lockedUntil := time.Now().Add(testLockout)
+	user.LockedUntil = &lockedUntil
+	mockDB.EXPECT().RecordLoginFailure(gomock.Any(), "jane", testMaxFailures, testLockout).Return(user, nil)
+
+	rr := login(handler, "jane", "wrong password")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, problem.TypeUnauthorized, decodeProblem(t, rr).Type)
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+}
+
+func TestLoginHandler_Locked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	// Even the right password is refused while the lockout lasts.
+	user := newTestUser(t, "jane")
+	lockedUntil := time.Now().Add(time.Minute)
+	user.LockedUntil = &lockedUntil
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(user, nil)
+
+	rr := login(handler, "jane", testPassword)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+}
+
+func TestLoginHandler_LockedLooksLikeUnknownUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	user := newTestUser(t, "jane")
+	lockedUntil := time.Now().Add(testLockout)
+	user.LockedUntil = &lockedUntil
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(user, nil)
+	mockDB.EXPECT().GetUser(gomock.Any(), "nobody").Return(database.User{}, fmt.Errorf("GetUser error: %w", database.ErrNotFound))
+
+	// Guessing on doesn't reveal that jane exists.
+	locked := login(handler, "jane", "wrong password")
+	unknown := login(handler, "nobody", "wrong password")
+
+	assert.Equal(t, unknown.Code, locked.Code)
+	assert.Equal(t, unknown.Header(), locked.Header())
+	assert.Equal(t, unknown.Body.String(), locked.Body.String())
+}
+
+func TestLoginHandler_LockoutExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	user := newTestUser(t, "jane")
+	lockedUntil := time.Now().Add(-time.Minute)
+	user.LockedUntil = &lockedUntil
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(user, nil)
+	mockDB.EXPECT().RecordLoginSuccess(gomock.Any(), "jane").Return(nil)
+	mockDB.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+
+	rr := login(handler, "jane", testPassword)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestLoginHandler_Disabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	user := newTestUser(t, "jane")
+	user.Disabled = true
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(user, nil)
+
+	rr := login(handler, "jane", testPassword)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "invalid username or password", decodeProblem(t, rr).Detail)
+}
+
+func TestLoginHandler_UnknownUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	mockDB.EXPECT().GetUser(gomock.Any(), "nobody").Return(database.User{}, fmt.Errorf("GetUser error: %w", database.ErrNotFound))
+
+	rr := login(handler, "nobody", testPassword)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "invalid username or password", decodeProblem(t, rr).Detail)
+}
+
+func TestLoginHandler_DatabaseUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(database.User{}, database.ErrUnavailable)
+
+	rr := login(handler, "jane", testPassword)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestLoginHandler_MissingFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockloginDB(ctrl)
+	handler := NewLoginHandler(mockDB, testMaxFailures, testLockout, testRefreshTTL, testLogger)
+
+	rr := login(handler, "", "")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	p := decodeProblem(t, rr)
+	assert.Equal(t, problem.TypeValidation, p.Type)
+	assert.Len(t, p.Errors, 2)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"companies/cmd/internal/database"
+	eventsender "companies/cmd/internal/eventSender"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/structs"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// @Summary      Replace a company
+// @Description  Replaces every field of the company, omitted optional fields are cleared
+// @Tags         Companies
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string                true   "Company UUID"
+// @Param        If-Match  header    string                false  "ETag the replacement is based on"
+// @Param        company   body      database.CompanyInfo  true   "New company data"
+// @Success      200       {object}  database.CompanyInfo  "Replaced company"
+// @Failure      400       {object}  problem.Problem       "Bad request – invalid UUID or body"
+// @Failure      403       {object}  problem.Problem       "Token lacks the companies:write scope"
+// @Failure      404       {object}  problem.Problem       "Company not found"
+// @Failure      409       {object}  problem.Problem       "Another company has this name"
+// @Failure      412       {object}  problem.Problem       "The company changed since the ETag was issued"
+// @Failure      413       {object}  problem.Problem       "Body exceeds the size limit"
+// @Failure      503       {object}  problem.Problem       "Database unavailable"
+// @Router       /api/v1/companies/{id} [put]
+func NewReplaceRecordHandler(db updateRecordDB, eventSender eventsender.EventSender, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "Replace company")
+
+		uuidStr := chi.URLParam(r, "id")
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyCompanyID, uuidStr))
+		id, err := uuid.Parse(uuidStr)
+		if err != nil {
+			logger.InfoContext(r.Context(), "Invalid id", logging.Err(err))
+			publishError(eventSender, r, structs.CompanyUpdateFailed, uuidStr, err.Error())
+			invalidID(w, r)
+			return
+		}
+
+		ifMatch, ok := parseIfMatch(r)
+		if !ok {
+			publishError(eventSender, r, structs.CompanyUpdateFailed, uuidStr, database.ErrVersionMismatch.Error())
+			writeError(logger, w, r, database.ErrVersionMismatch)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			publishError(eventSender, r, structs.CompanyUpdateFailed, uuidStr, err.Error())
+			invalidBody(w, r, err, "can't read the body")
+			return
+		}
+
+		replacement, err := decodeRecord(body)
+		if err != nil {
+			publishError(eventSender, r, structs.CompanyUpdateFailed, uuidStr, err.Error())
+			writeError(logger, w, r, err)
+			return
+		}
+
+		record, err := db.UpdateRecord(r.Context(), id, ifMatch, func(database.CompanyInfo) (database.CompanyInfo, error) {
+			return replacement, nil
+		})
+		if err != nil {
+			publishError(eventSender, r, structs.CompanyUpdateFailed, uuidStr, err.Error())
+			writeError(logger, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag(record.Version))
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(record)
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"companies/cmd/internal/database"
+	"companies/cmd/tests/mocks"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplaceRecordHandler_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockupdateRecordDB(ctrl)
+	mockEventSender := mocks.NewMockEventSender(ctrl)
+
+	handler := NewReplaceRecordHandler(mockDB, mockEventSender, testLogger)
+
+	stored := makeValidCompany()
+	stored.Version = 4
+
+	expectUpdate(mockDB, stored, []uint64{4})
+
+	mockEventSender.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	req := newUpdateTestRequest(http.MethodPut, stored.ID.String(), "application/json",
+		`{"name":"Replaced","employeesCount":1,"isRegistered":false,"type":2}`)
+	req.Header.Set("If-Match", `"4"`)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"5"`, rr.Header().Get("ETag"))
+
+	var got database.CompanyInfo
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	assert.Equal(t, "Replaced", *got.Name)
+	assert.Nil(t, got.Description)
+	assert.Equal(t, *stored.ID, *got.ID)
+}
+
+func TestReplaceRecordHandler_Incomplete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockupdateRecordDB(ctrl)
+	mockEventSender := mocks.NewMockEventSender(ctrl)
+
+	handler := NewReplaceRecordHandler(mockDB, mockEventSender, testLogger)
+
+	mockEventSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(nil)
+
+	stored := makeValidCompany()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newUpdateTestRequest(http.MethodPut, stored.ID.String(), "application/json", `{"name":"Replaced"}`))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
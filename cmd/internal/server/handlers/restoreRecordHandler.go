@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"companies/cmd/internal/database"
+	eventsender "companies/cmd/internal/eventSender"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/structs"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=restoreRecordHandler.go -destination=../../../tests/mocks/mock_restore_record.go -package=mocks
+type restoreRecordDB interface {
+	RestoreRecord(context.Context, uuid.UUID) (database.CompanyInfo, error)
+}
+
+// @Summary      Restore a deleted company
+// @Description  Undoes a soft delete. Fails if another company took the name in the meantime
+// @Tags         Companies
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string                true  "Company UUID"
+// @Success      200  {object}  database.CompanyInfo  "Restored company"
+// @Failure      400  {object}  problem.Problem       "Invalid UUID"
+// @Failure      403  {object}  problem.Problem       "Token lacks the companies:delete scope"
+// @Failure      404  {object}  problem.Problem       "No deleted company with this UUID"
+// @Failure      409  {object}  problem.Problem       "Another company took the name"
+// @Failure      503  {object}  problem.Problem       "Database unavailable"
+// @Router       /api/v1/companies/{id}/restore [post]
+func NewRestoreRecordHandler(db restoreRecordDB, eventSender eventsender.EventSender, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "Restore company")
+
+		uuidStr := chi.URLParam(r, "id")
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyCompanyID, uuidStr))
+		id, err := uuid.Parse(uuidStr)
+		if err != nil {
+			logger.InfoContext(r.Context(), "Invalid id", logging.Err(err))
+			publishError(eventSender, r, structs.CompanyRestoreFailed, uuidStr, err.Error())
+			invalidID(w, r)
+			return
+		}
+
+		record, err := db.RestoreRecord(r.Context(), id)
+		if err != nil {
+			publishError(eventSender, r, structs.CompanyRestoreFailed, uuidStr, err.Error())
+			writeError(logger, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag(record.Version))
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(record)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/structs"
+	"companies/cmd/tests/mocks"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreRecordHandler_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockrestoreRecordDB(ctrl)
+	mockSender := mocks.NewMockEventSender(ctrl)
+
+	company := makeValidCompany()
+
+	mockDB.EXPECT().RestoreRecord(gomock.Any(), *company.ID).Return(company, nil)
+	mockSender.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	req := newDeleteTestRequest(http.MethodPost, "/api/v1/companies/"+company.ID.String()+"/restore", company.ID.String())
+	rr := httptest.NewRecorder()
+
+	handler := NewRestoreRecordHandler(mockDB, mockSender, testLogger)
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var got database.CompanyInfo
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	assert.Equal(t, *company.ID, *got.ID)
+}
+
+func TestRestoreRecordHandler_Errors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{fmt.Errorf("RestoreRecord error: %w", database.ErrNotDeleted), http.StatusNotFound},
+		{fmt.Errorf("RestoreRecord error: %w", database.ErrConflict), http.StatusConflict},
+		{errors.New("RestoreRecord error: bad connection"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		ctrl := gomock.NewController(t)
+
+		mockDB := mocks.NewMockrestoreRecordDB(ctrl)
+		mockSender := mocks.NewMockEventSender(ctrl)
+
+		testID := uuid.New()
+
+		mockDB.EXPECT().RestoreRecord(gomock.Any(), testID).Return(database.CompanyInfo{}, tt.err)
+		mockSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.AssignableToTypeOf(structs.Event{})).DoAndReturn(
+			func(_ context.Context, topic string, event structs.Event) error {
+				assert.Equal(t, structs.CompanyRestoreFailed, event.Type)
+				return nil
+			})
+
+		req := newDeleteTestRequest(http.MethodPost, "/api/v1/companies/"+testID.String()+"/restore", testID.String())
+		rr := httptest.NewRecorder()
+
+		handler := NewRestoreRecordHandler(mockDB, mockSender, testLogger)
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, tt.status, rr.Code)
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/problem"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type searchResponse struct {
+	Items []database.SearchResult `json:"items"`
+}
+
+// @Summary      Search companies
+// @Description  Full-text search over company name and description ranked by relevance, names tolerate typos
+// @Tags         Companies
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q      query     string           true   "Search text"
+// @Param        limit  query     int              false  "Maximum number of results (1-100, default 20)"
+// @Success      200    {object}  searchResponse   "Matching companies, most relevant first"
+// @Failure      400    {object}  problem.Problem  "Missing query or invalid limit"
+// @Failure      401    {object}  problem.Problem  "Missing or invalid token"
+// @Failure      403    {object}  problem.Problem  "Token lacks the companies:read scope"
+// @Failure      500    {object}  problem.Problem  "Search failed"
+// @Failure      503    {object}  problem.Problem  "Database unavailable"
+// @Router       /api/v1/companies/search [get]
+func NewSearchRecordsHandler(searcher database.Searcher, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "Search companies")
+
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" {
+			logger.InfoContext(r.Context(), "Empty search query")
+			problem.Write(w, r, problem.New(http.StatusBadRequest, problem.TypeInvalidQuery, "q is required"))
+			return
+		}
+
+		limit := database.DefaultListLimit
+		if parsed, err := parseIntParam(r.URL.Query(), "limit"); err != nil || (parsed != nil && (*parsed < 1 || *parsed > database.MaxListLimit)) {
+			logger.InfoContext(r.Context(), "Invalid limit")
+			problem.Write(w, r, problem.New(http.StatusBadRequest, problem.TypeInvalidQuery,
+				fmt.Sprintf("limit must be an integer between 1 and %v", database.MaxListLimit)))
+			return
+		} else if parsed != nil {
+			limit = *parsed
+		}
+
+		results, err := searcher.Search(r.Context(), q, limit)
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		json.NewEncoder(w).Encode(searchResponse{Items: results})
+	}
+}
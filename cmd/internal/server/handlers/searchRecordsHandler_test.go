@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"companies/cmd/internal/database"
+	"companies/cmd/tests/mocks"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchRecordsHandler_Success(t *testing.T) {
+	index := database.NewInvertedIndex()
+
+	company := makeValidCompany()
+	other := makeValidCompany()
+	*other.Name = "Other"
+	*other.Description = "Nothing to see"
+
+	index.Add(company)
+	index.Add(other)
+
+	handler := NewSearchRecordsHandler(index, testLogger)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies/search?q=Compnay", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var got searchResponse
+	err := json.NewDecoder(rr.Body).Decode(&got)
+	assert.NoError(t, err)
+	assert.Len(t, got.Items, 1)
+	assert.Equal(t, *company.ID, *got.Items[0].Company.ID)
+}
+
+func TestSearchRecordsHandler_InvalidQuery(t *testing.T) {
+	handler := NewSearchRecordsHandler(database.NewInvertedIndex(), testLogger)
+
+	for _, query := range []string{"", "q=", "q=abc&limit=0", "q=abc&limit=x"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/companies/search?"+query, nil)
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestSearchRecordsHandler_SearchError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockSearcher := mocks.NewMockSearcher(ctrl)
+	mockSearcher.EXPECT().Search(gomock.Any(), "acme", database.DefaultListLimit).Return(nil, errors.New("db down"))
+
+	handler := NewSearchRecordsHandler(mockSearcher, testLogger)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies/search?q=acme", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
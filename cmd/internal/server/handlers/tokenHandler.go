@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+//go:generate mockgen -source=tokenHandler.go -destination=../../../tests/mocks/mock_tokens.go -package=mocks
+type tokensDB interface {
+	GetUser(ctx context.Context, username string) (database.User, error)
+	RotateRefreshToken(ctx context.Context, hash, nextHash string, expiresAt time.Time) (database.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, hash string) error
+}
+
+type tokenRevoker interface {
+	Revoke(ctx context.Context, jti string, until time.Time) error
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func invalidRefreshToken(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.TypeUnauthorized, "invalid or expired refresh token"))
+}
+
+// @Summary      Refresh tokens
+// @Description  Exchanges a refresh token for a new bearer token and a new refresh token, the old refresh token can't be used again. Presenting a used refresh token revokes every token of that login
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        token  body      refreshRequest   true  "Refresh token"
+// @Success      200    {object}  tokenResponse    "Bearer and refresh tokens"
+// @Failure      400    {object}  problem.Problem  "Missing refresh token"
+// @Failure      401    {object}  problem.Problem  "Invalid, expired, revoked or reused refresh token"
+// @Failure      413    {object}  problem.Problem  "Body exceeds the size limit"
+// @Failure      503    {object}  problem.Problem  "Database unavailable"
+// @Router       /api/v1/auth/refresh [post]
+func NewRefreshHandler(db tokensDB, refreshTTL time.Duration, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req refreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			invalidBody(w, r, err, err.Error())
+			return
+		}
+
+		if req.RefreshToken == "" {
+			problem.Write(w, r, problem.Validation([]problem.FieldError{{Field: "refreshToken", Rule: "required", Message: "refreshToken is required"}}))
+			return
+		}
+
+		refreshToken, nextHash, err := auth.NewRefreshToken()
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		next, err := db.RotateRefreshToken(r.Context(), auth.HashRefreshToken(req.RefreshToken), nextHash, time.Now().Add(refreshTTL))
+		switch {
+		case errors.Is(err, database.ErrTokenReused):
+			logger.WarnContext(r.Context(), "Refresh token reused, login revoked")
+			problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.TypeUnauthorized, "refresh token was already used, log in again"))
+			return
+		case errors.Is(err, database.ErrNotFound):
+			logger.InfoContext(r.Context(), "Invalid refresh token")
+			invalidRefreshToken(w, r)
+			return
+		case err != nil:
+			writeError(logger, w, r, err)
+			return
+		}
+
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyUser, next.Username))
+
+		user, err := db.GetUser(r.Context(), next.Username)
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		if err != nil || user.Disabled {
+			logger.InfoContext(r.Context(), "Refresh refused, user disabled or removed")
+			if err := db.RevokeRefreshToken(r.Context(), nextHash); err != nil {
+				logger.WarnContext(r.Context(), "Failed to revoke refresh token", logging.Err(err))
+			}
+			invalidRefreshToken(w, r)
+			return
+		}
+
+		resp, err := newTokenResponse(user, refreshToken)
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// @Summary      Log out
+// @Description  Revokes the bearer token and, when given, the refresh token with every token of its login
+// @Tags         Auth
+// @Accept       json
+// @Security     BearerAuth
+// @Param        token  body      refreshRequest   false  "Refresh token to revoke"
+// @Success      204    {string}  string           "Logged out"
+// @Failure      401    {object}  problem.Problem  "Missing or invalid token"
+// @Failure      413    {object}  problem.Problem  "Body exceeds the size limit"
+// @Failure      503    {object}  problem.Problem  "Database unavailable"
+// @Router       /api/v1/auth/logout [post]
+func NewLogoutHandler(db tokensDB, revocations tokenRevoker, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req refreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			invalidBody(w, r, err, err.Error())
+			return
+		}
+
+		if req.RefreshToken != "" {
+			err := db.RevokeRefreshToken(r.Context(), auth.HashRefreshToken(req.RefreshToken))
+			if err != nil && !errors.Is(err, database.ErrNotFound) {
+				writeError(logger, w, r, err)
+				return
+			}
+		}
+
+		if claims, ok := auth.ClaimsFromContext(r.Context()); ok && claims.ID != "" && claims.ExpiresAt != nil {
+			if err := revocations.Revoke(r.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+				writeError(logger, w, r, err)
+				return
+			}
+		}
+
+		logger.InfoContext(r.Context(), "Logged out")
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
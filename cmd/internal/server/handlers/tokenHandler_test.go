@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/database"
+	"companies/cmd/tests/mocks"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type revokerFunc func(ctx context.Context, jti string, until time.Time) error
+
+func (f revokerFunc) Revoke(ctx context.Context, jti string, until time.Time) error {
+	return f(ctx, jti, until)
+}
+
+func refresh(handler http.Handler, token string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", strings.NewReader(`{"refreshToken":"`+token+`"}`)))
+	return rr
+}
+
+func TestRefreshHandler_Rotates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocktokensDB(ctrl)
+	handler := NewRefreshHandler(mockDB, testRefreshTTL, testLogger)
+
+	var nextHash string
+	mockDB.EXPECT().RotateRefreshToken(gomock.Any(), auth.HashRefreshToken("old"), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _, hash string, expiresAt time.Time) (database.RefreshToken, error) {
+		nextHash = hash
+		assert.WithinDuration(t, time.Now().Add(testRefreshTTL), expiresAt, time.Minute)
+		return database.RefreshToken{TokenHash: hash, Username: "jane", ExpiresAt: expiresAt}, nil
+	})
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(database.User{Username: "jane"}, nil)
+
+	rr := refresh(handler, "old")
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var got tokenResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	assert.Equal(t, nextHash, auth.HashRefreshToken(got.RefreshToken))
+	assert.NotEmpty(t, got.Token)
+}
+
+func TestRefreshHandler_Reused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocktokensDB(ctrl)
+	handler := NewRefreshHandler(mockDB, testRefreshTTL, testLogger)
+
+	mockDB.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(database.RefreshToken{}, database.ErrTokenReused)
+
+	rr := refresh(handler, "old")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "refresh token was already used, log in again", decodeProblem(t, rr).Detail)
+}
+
+func TestRefreshHandler_Unknown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocktokensDB(ctrl)
+	handler := NewRefreshHandler(mockDB, testRefreshTTL, testLogger)
+
+	mockDB.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(database.RefreshToken{}, database.ErrNotFound)
+
+	rr := refresh(handler, "old")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "invalid or expired refresh token", decodeProblem(t, rr).Detail)
+}
+
+func TestRefreshHandler_DisabledUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocktokensDB(ctrl)
+	handler := NewRefreshHandler(mockDB, testRefreshTTL, testLogger)
+
+	var nextHash string
+	mockDB.EXPECT().RotateRefreshToken(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _, hash string, expiresAt time.Time) (database.RefreshToken, error) {
+		nextHash = hash
+		return database.RefreshToken{TokenHash: hash, Username: "jane"}, nil
+	})
+	mockDB.EXPECT().GetUser(gomock.Any(), "jane").Return(database.User{Username: "jane", Disabled: true}, nil)
+	mockDB.EXPECT().RevokeRefreshToken(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, hash string) error {
+		assert.Equal(t, nextHash, hash)
+		return nil
+	})
+
+	rr := refresh(handler, "old")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestRefreshHandler_Missing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocktokensDB(ctrl)
+	handler := NewRefreshHandler(mockDB, testRefreshTTL, testLogger)
+
+	rr := refresh(handler, "")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func newLogoutRequest(t *testing.T, body string) (*http.Request, *auth.Claims) {
+	token := testToken(t, "jane")
+
+	claims := &auth.Claims{}
+	_, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req, claims
+}
+
+func TestLogoutHandler_RevokesBoth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocktokensDB(ctrl)
+	mockDB.EXPECT().RevokeRefreshToken(gomock.Any(), auth.HashRefreshToken("refresh")).Return(nil)
+
+	req, claims := newLogoutRequest(t, `{"refreshToken":"refresh"}`)
+
+	revoked := ""
+	handler := NewLogoutHandler(mockDB, revokerFunc(func(_ context.Context, jti string, until time.Time) error {
+		revoked = jti
+		assert.Equal(t, claims.ExpiresAt.Time, until)
+		return nil
+	}), testLogger)
+
+	rr := httptest.NewRecorder()
+	auth.JWTMiddleware(handler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, claims.ID, revoked)
+}
+
+func TestLogoutHandler_NoBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocktokensDB(ctrl)
+	req, _ := newLogoutRequest(t, "")
+
+	calls := 0
+	handler := NewLogoutHandler(mockDB, revokerFunc(func(context.Context, string, time.Time) error {
+		calls++
+		return nil
+	}), testLogger)
+
+	rr := httptest.NewRecorder()
+	auth.JWTMiddleware(handler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestLogoutHandler_RevocationFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMocktokensDB(ctrl)
+	req, _ := newLogoutRequest(t, "")
+
+	handler := NewLogoutHandler(mockDB, revokerFunc(func(context.Context, string, time.Time) error {
+		return database.ErrUnavailable
+	}), testLogger)
+
+	rr := httptest.NewRecorder()
+	auth.JWTMiddleware(handler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
@@ -1,12 +1,20 @@
 package handlers
 
 import (
-	"companies/cmd/internal/consts"
+	"bytes"
 	"companies/cmd/internal/database"
 	eventsender "companies/cmd/internal/eventSender"
+	"companies/cmd/internal/jsonpatch"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
 	"companies/cmd/internal/structs"
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -15,68 +23,141 @@ import (
 
 //go:generate mockgen -source=updateRecordHandler.go -destination=../../../tests/mocks/mock_update_record.go -package=mocks
 type updateRecordDB interface {
-	UpdateRecord(database.CompanyInfo, uuid.UUID) error
+	UpdateRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64, update database.UpdateFunc) (database.CompanyInfo, error)
+}
+
+// decodeRecord strictly decodes a complete record and validates it.
+func decodeRecord(data []byte) (database.CompanyInfo, error) {
+	var record database.CompanyInfo
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&record); err != nil {
+		return record, problem.New(http.StatusBadRequest, problem.TypeInvalidBody, err.Error())
+	}
+
+	if fields := ValidateInfo(record); fields != nil {
+		return record, problem.Validation(fields)
+	}
+
+	return record, nil
+}
+
+// patchUpdate applies a PATCH body to the stored record. Plain JSON bodies
+// are treated as merge patches, which is what clients sending partial
+// records expect.
+func patchUpdate(contentType string, body []byte) (database.UpdateFunc, error) {
+	unsupported := problem.New(http.StatusUnsupportedMediaType, problem.TypeUnsupportedMediaType,
+		fmt.Sprintf("use %v or %v", jsonpatch.MergePatchContentType, jsonpatch.JSONPatchContentType))
+
+	mediaType := "application/json"
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return nil, unsupported
+		}
+	}
+
+	var apply func(doc, patch []byte) ([]byte, error)
+
+	switch mediaType {
+	case jsonpatch.MergePatchContentType, "application/json":
+		apply = jsonpatch.MergePatch
+	case jsonpatch.JSONPatchContentType:
+		apply = jsonpatch.Apply
+	default:
+		return nil, unsupported
+	}
+
+	return func(current database.CompanyInfo) (database.CompanyInfo, error) {
+		doc, err := json.Marshal(current)
+		if err != nil {
+			return current, err
+		}
+
+		patched, err := apply(doc, body)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return current, problem.New(http.StatusConflict, problem.TypeConflict, err.Error())
+		}
+		if err != nil {
+			return current, problem.New(http.StatusBadRequest, problem.TypeInvalidBody, err.Error())
+		}
+
+		return decodeRecord(patched)
+	}, nil
 }
 
 // @Summary      Update an existing company
-// @Description  Updates company information by UUID
+// @Description  Applies a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) to the company, plain JSON is treated as a merge patch. The patched company must be valid
 // @Tags         Companies
 // @Security     BearerAuth
 // @Accept       json
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
 // @Produce      json
-// @Param        id      path      string                true  "Company UUID"
-// @Param        company body      database.CompanyInfo  true  "Updated company data"
-// @Success      202     {string}  string                "Accepted – update in progress"
-// @Failure      400     {string}  string                "Bad request – invalid UUID or body"
+// @Param        id        path      string                true   "Company UUID"
+// @Param        If-Match  header    string                false  "ETag the update is based on"
+// @Param        patch     body      object                true   "Merge patch or JSON Patch document"
+// @Success      200       {object}  database.CompanyInfo  "Patched company"
+// @Failure      400       {object}  problem.Problem       "Bad request – invalid UUID, patch or patched company"
+// @Failure      403       {object}  problem.Problem       "Token lacks the companies:write scope"
+// @Failure      404       {object}  problem.Problem       "Company not found"
+// @Failure      409       {object}  problem.Problem       "A JSON Patch test operation failed or another company has this name"
+// @Failure      412       {object}  problem.Problem       "The company changed since the ETag was issued"
+// @Failure      413       {object}  problem.Problem       "Body exceeds the size limit"
+// @Failure      415       {object}  problem.Problem       "Unsupported content type"
+// @Failure      503       {object}  problem.Problem       "Database unavailable"
 // @Router       /api/v1/companies/{id} [patch]
-func NewUpdateRecordHandler(db updateRecordDB, eventSender eventsender.EventSender) http.HandlerFunc {
+func NewUpdateRecordHandler(db updateRecordDB, eventSender eventsender.EventSender, logger *slog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Println(consts.ApplicationPrefix, "updateRecordHandler::handler", r.Body)
-
-		log.Println(consts.ApplicationPrefix, "Request path: ", r.URL.Path)
-		log.Println(consts.ApplicationPrefix, "Path param id: ", chi.URLParam(r, "id"))
+		logger.DebugContext(r.Context(), "Update company")
 
 		uuidStr := chi.URLParam(r, "id")
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyCompanyID, uuidStr))
 		id, err := uuid.Parse(uuidStr)
 
 		if err != nil {
-			log.Println(consts.ApplicationPrefix, "updateRecordHandler::handler error:", err)
-			eventSender.PublishEvent("data-changed", structs.Event{
-				URL:           r.URL.Path,
-				Type:          structs.Updated,
-				Status:        structs.Failed,
-				ErrorMesssage: err.Error(),
-			})
-			w.WriteHeader(http.StatusBadRequest)
+			logger.InfoContext(r.Context(), "Invalid id", logging.Err(err))
+			publishError(eventSender, r, structs.CompanyUpdateFailed, uuidStr, err.Error())
+			invalidID(w, r)
 			return
 		}
 
-		data := database.CompanyInfo{}
+		ifMatch, ok := parseIfMatch(r)
+		if !ok {
+			publishError(eventSender, r, structs.CompanyUpdateFailed, uuidStr, database.ErrVersionMismatch.Error())
+			writeError(logger, w, r, database.ErrVersionMismatch)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			publishError(eventSender, r, structs.CompanyUpdateFailed, uuidStr, err.Error())
+			invalidBody(w, r, err, "can't read the body")
+			return
+		}
 
-		json.NewDecoder(r.Body).Decode(&data)
+		update, err := patchUpdate(r.Header.Get("Content-Type"), body)
+		if err != nil {
+			publishError(eventSender, r, structs.CompanyUpdateFailed, uuidStr, err.Error())
+			writeError(logger, w, r, err)
+			return
+		}
 
-		err = db.UpdateRecord(data, id)
+		record, err := db.UpdateRecord(r.Context(), id, ifMatch, update)
 
 		if err != nil {
-			eventSender.PublishEvent("data-changed", structs.Event{
-				URL:           r.URL.Path,
-				Type:          structs.Updated,
-				Status:        structs.Failed,
-				ErrorMesssage: err.Error(),
-			})
-			log.Println(consts.ApplicationPrefix, "updateRecordHandler::handler error:", err)
-			w.WriteHeader(http.StatusBadRequest)
+			publishError(eventSender, r, structs.CompanyUpdateFailed, uuidStr, err.Error())
+			writeError(logger, w, r, err)
 			return
 		}
 
-		bytes, _ := json.Marshal(data)
-		eventSender.PublishEvent("data-changed", structs.Event{
-			URL:    r.URL.Path,
-			Type:   structs.Updated,
-			Status: structs.Success,
-			Data:   bytes,
-		})
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag(record.Version))
+		w.WriteHeader(http.StatusOK)
 
-		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(record)
 	}
 }
@@ -3,11 +3,14 @@ package handlers
 import (
 	"bytes"
 	"companies/cmd/internal/database"
+	"companies/cmd/internal/jsonpatch"
+	"companies/cmd/internal/problem"
 	"companies/cmd/internal/structs"
 	"companies/cmd/tests/mocks"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -18,61 +21,185 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestUpdateRecordHandler_Success(t *testing.T) {
+func newUpdateTestRequest(method, id, contentType, body string) *http.Request {
+	req := httptest.NewRequest(method, "/api/v1/companies/"+id, bytes.NewBufferString(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+// expectUpdate makes the mock run the handler's UpdateFunc against stored,
+// the way MySQLDB.UpdateRecord does.
+func expectUpdate(mockDB *mocks.MockupdateRecordDB, stored database.CompanyInfo, ifMatch []uint64) {
+	mockDB.EXPECT().UpdateRecord(gomock.Any(), *stored.ID, ifMatch, gomock.Any()).DoAndReturn(
+		func(_ context.Context, id uuid.UUID, ifMatch []uint64, update database.UpdateFunc) (database.CompanyInfo, error) {
+			record, err := update(stored)
+			if err != nil {
+				return record, fmt.Errorf("UpdateRecord error: %w", err)
+			}
+			record.ID = stored.ID
+			record.Version = stored.Version + 1
+			return record, nil
+		})
+}
+
+func TestUpdateRecordHandler_MergePatch(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	mockDB := mocks.NewMockupdateRecordDB(ctrl)
 	mockEventSender := mocks.NewMockEventSender(ctrl)
 
-	handler := NewUpdateRecordHandler(mockDB, mockEventSender)
+	handler := NewUpdateRecordHandler(mockDB, mockEventSender, testLogger)
 
-	id := uuid.New()
-	company := database.CompanyInfo{
-		Name: ptrString("Updated Company"),
-	}
+	stored := makeValidCompany()
+	stored.Version = 1
 
-	mockDB.EXPECT().UpdateRecord(company, id).Return(nil)
+	expectUpdate(mockDB, stored, nil)
 
-	mockEventSender.EXPECT().PublishEvent("data-changed", gomock.Any()).DoAndReturn(
-		func(topic string, event structs.Event) error {
-			assert.Equal(t, structs.Updated, event.Type)
-			assert.Equal(t, structs.Success, event.Status)
-			return nil
-		})
+	mockEventSender.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
-	bodyBytes, _ := json.Marshal(company)
-	req := httptest.NewRequest(http.MethodPatch, "/api/v1/companies/"+id.String(), bytes.NewReader(bodyBytes))
+	req := newUpdateTestRequest(http.MethodPatch, stored.ID.String(), jsonpatch.MergePatchContentType,
+		`{"name":"Updated","description":null}`)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
 
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", id.String())
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	var got database.CompanyInfo
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	assert.Equal(t, "Updated", *got.Name)
+	assert.Nil(t, got.Description)
+	assert.Equal(t, *stored.EmployeesCount, *got.EmployeesCount)
+	assert.Equal(t, "Test Company", *stored.Name)
+}
+
+func TestUpdateRecordHandler_PlainJSONIsMergePatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockupdateRecordDB(ctrl)
+	mockEventSender := mocks.NewMockEventSender(ctrl)
 
+	handler := NewUpdateRecordHandler(mockDB, mockEventSender, testLogger)
+
+	stored := makeValidCompany()
+
+	expectUpdate(mockDB, stored, nil)
+
+	mockEventSender.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	req := newUpdateTestRequest(http.MethodPatch, stored.ID.String(), "application/json", `{"employeesCount":5}`)
 	rr := httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var got database.CompanyInfo
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	assert.Equal(t, 5, *got.EmployeesCount)
+	assert.Equal(t, *stored.Name, *got.Name)
 }
 
-func TestUpdateRecordHandler_InvalidUUID(t *testing.T) {
+func TestUpdateRecordHandler_JSONPatch(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	mockDB := mocks.NewMockupdateRecordDB(ctrl)
 	mockEventSender := mocks.NewMockEventSender(ctrl)
 
-	handler := NewUpdateRecordHandler(mockDB, mockEventSender)
+	handler := NewUpdateRecordHandler(mockDB, mockEventSender, testLogger)
 
-	invalidID := "not-a-uuid"
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", invalidID)
+	stored := makeValidCompany()
 
-	req := httptest.NewRequest(http.MethodPatch, "/api/v1/companies/"+invalidID, nil)
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	expectUpdate(mockDB, stored, nil)
 
-	mockEventSender.EXPECT().PublishEvent("data-changed", gomock.Any()).Return(nil)
+	mockEventSender.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
+	req := newUpdateTestRequest(http.MethodPatch, stored.ID.String(), jsonpatch.JSONPatchContentType,
+		`[{"op":"test","path":"/type","value":1},{"op":"replace","path":"/type","value":3}]`)
 	rr := httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var got database.CompanyInfo
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	assert.Equal(t, 3, *got.Type)
+}
+
+func TestUpdateRecordHandler_PatchErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		problemType string
+	}{
+		{"test op fails", jsonpatch.JSONPatchContentType, `[{"op":"test","path":"/type","value":2}]`, http.StatusConflict, problem.TypeConflict},
+		{"malformed patch", jsonpatch.JSONPatchContentType, `[{"op":"remove","path":"/missing"}]`, http.StatusBadRequest, problem.TypeInvalidBody},
+		{"invalid result", jsonpatch.MergePatchContentType, `{"name":null}`, http.StatusBadRequest, problem.TypeValidation},
+		{"name too long", jsonpatch.MergePatchContentType, `{"name":"a name way too long"}`, http.StatusBadRequest, problem.TypeValidation},
+		{"unknown field", jsonpatch.MergePatchContentType, `{"founded":1999}`, http.StatusBadRequest, problem.TypeInvalidBody},
+		{"wrong type", jsonpatch.MergePatchContentType, `{"employeesCount":"many"}`, http.StatusBadRequest, problem.TypeInvalidBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			mockDB := mocks.NewMockupdateRecordDB(ctrl)
+			mockEventSender := mocks.NewMockEventSender(ctrl)
+
+			handler := NewUpdateRecordHandler(mockDB, mockEventSender, testLogger)
+
+			stored := makeValidCompany()
+
+			expectUpdate(mockDB, stored, nil)
+
+			mockEventSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.AssignableToTypeOf(structs.Event{})).Return(nil)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, newUpdateTestRequest(http.MethodPatch, stored.ID.String(), tt.contentType, tt.body))
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.problemType, decodeProblem(t, rr).Type)
+		})
+	}
+}
+
+func TestUpdateRecordHandler_UnsupportedContentType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockupdateRecordDB(ctrl)
+	mockEventSender := mocks.NewMockEventSender(ctrl)
+
+	handler := NewUpdateRecordHandler(mockDB, mockEventSender, testLogger)
+
+	mockEventSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newUpdateTestRequest(http.MethodPatch, uuid.NewString(), "text/plain", "name=x"))
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	assert.Equal(t, problem.TypeUnsupportedMediaType, decodeProblem(t, rr).Type)
+}
+
+func TestUpdateRecordHandler_InvalidUUID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockupdateRecordDB(ctrl)
+	mockEventSender := mocks.NewMockEventSender(ctrl)
+
+	handler := NewUpdateRecordHandler(mockDB, mockEventSender, testLogger)
+
+	mockEventSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newUpdateTestRequest(http.MethodPatch, "not-a-uuid", "", ""))
+
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
 
@@ -82,26 +209,41 @@ func TestUpdateRecordHandler_UpdateRecordError(t *testing.T) {
 	mockDB := mocks.NewMockupdateRecordDB(ctrl)
 	mockEventSender := mocks.NewMockEventSender(ctrl)
 
-	handler := NewUpdateRecordHandler(mockDB, mockEventSender)
+	handler := NewUpdateRecordHandler(mockDB, mockEventSender, testLogger)
 
 	id := uuid.New()
-	company := database.CompanyInfo{
-		Name: ptrString("Company With Error"),
-	}
 
-	mockDB.EXPECT().UpdateRecord(company, id).Return(errors.New("update failed"))
+	mockDB.EXPECT().UpdateRecord(gomock.Any(), id, nil, gomock.Any()).Return(database.CompanyInfo{}, errors.New("update failed"))
 
-	mockEventSender.EXPECT().PublishEvent("data-changed", gomock.Any()).Return(nil)
+	mockEventSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(nil)
 
-	bodyBytes, _ := json.Marshal(company)
-	req := httptest.NewRequest(http.MethodPatch, "/api/v1/companies/"+id.String(), bytes.NewReader(bodyBytes))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newUpdateTestRequest(http.MethodPatch, id.String(), "", `{"name":"Company"}`))
 
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", id.String())
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestUpdateRecordHandler_PreconditionFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockupdateRecordDB(ctrl)
+	mockEventSender := mocks.NewMockEventSender(ctrl)
+
+	handler := NewUpdateRecordHandler(mockDB, mockEventSender, testLogger)
+
+	id := uuid.New()
+
+	mockDB.EXPECT().UpdateRecord(gomock.Any(), id, []uint64{3}, gomock.Any()).
+		Return(database.CompanyInfo{}, fmt.Errorf("UpdateRecord error: %w", database.ErrVersionMismatch))
+
+	mockEventSender.EXPECT().PublishEvent(gomock.Any(), "data-changed", gomock.Any()).Return(nil)
+
+	req := newUpdateTestRequest(http.MethodPatch, id.String(), "", `{"name":"Stale Company"}`)
+	req.Header.Set("If-Match", `"3"`)
 
 	rr := httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	assert.Equal(t, problem.TypePreconditionFailed, decodeProblem(t, rr).Type)
 }
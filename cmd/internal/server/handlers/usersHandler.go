@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+//go:generate mockgen -source=usersHandler.go -destination=../../../tests/mocks/mock_users.go -package=mocks
+type usersDB interface {
+	CreateUser(context.Context, database.User) (database.User, error)
+	ListUsers(context.Context) ([]database.User, error)
+	SetUserRoles(ctx context.Context, username string, roles []string) (database.User, error)
+	SetUserDisabled(ctx context.Context, username string, disabled bool) (database.User, error)
+	SetUserPassword(ctx context.Context, username, passwordHash string) (database.User, error)
+}
+
+type createUserRequest struct {
+	Username string   `json:"username" example:"jane.doe"`
+	Password string   `json:"password" example:"correct horse battery staple"`
+	Roles    []string `json:"roles" example:"editor"`
+}
+
+// updateUserRequest changes the fields that are set, at least one must be.
+type updateUserRequest struct {
+	Disabled *bool     `json:"disabled"`
+	Roles    *[]string `json:"roles"`
+}
+
+type resetPasswordRequest struct {
+	Password string `json:"password" example:"correct horse battery staple"`
+}
+
+func rolesField(roles []string) []problem.FieldError {
+	if err := auth.ValidateRoles(roles); err != nil {
+		return []problem.FieldError{{Field: "roles", Rule: "oneOf", Message: err.Error()}}
+	}
+	return nil
+}
+
+func passwordField(password string) []problem.FieldError {
+	if err := auth.ValidatePassword(password); err != nil {
+		return []problem.FieldError{{Field: "password", Rule: "length", Message: err.Error()}}
+	}
+	return nil
+}
+
+// writeUserError is writeError with a not found detail that names users.
+func writeUserError(logger *slog.Logger, w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, database.ErrNotFound) {
+		err = problem.New(http.StatusNotFound, problem.TypeNotFound, "no user with this username")
+	}
+	writeError(logger, w, r, err)
+}
+
+// @Summary      Create a user
+// @Description  Creates a user that can log in with the given password. Roles are viewer, editor or admin, a user without roles can log in but not use the protected endpoints. Admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user  body      createUserRequest  true  "User to create"
+// @Success      201   {object}  database.User      "Created user"
+// @Failure      400   {object}  problem.Problem    "Invalid username or password"
+// @Failure      403   {object}  problem.Problem    "Caller is not an admin"
+// @Failure      409   {object}  problem.Problem    "Username already taken"
+// @Failure      413   {object}  problem.Problem    "Body exceeds the size limit"
+// @Failure      503   {object}  problem.Problem    "Database unavailable"
+// @Router       /api/v1/admin/users [post]
+func NewCreateUserHandler(db usersDB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(logger, w, r) {
+			return
+		}
+
+		var req createUserRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			invalidBody(w, r, err, err.Error())
+			return
+		}
+
+		var fields []problem.FieldError
+		if err := auth.ValidateUsername(req.Username); err != nil {
+			fields = append(fields, problem.FieldError{Field: "username", Rule: "pattern", Message: err.Error()})
+		}
+		fields = append(fields, passwordField(req.Password)...)
+		fields = append(fields, rolesField(req.Roles)...)
+		if len(fields) > 0 {
+			problem.Write(w, r, problem.Validation(fields))
+			return
+		}
+
+		hash, err := auth.HashPassword(req.Password)
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		user, err := db.CreateUser(r.Context(), database.User{Username: req.Username, PasswordHash: hash, Roles: req.Roles})
+		if errors.Is(err, database.ErrConflict) {
+			err = problem.New(http.StatusConflict, problem.TypeConflict, "username already taken")
+		}
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		logger.InfoContext(r.Context(), "User created", slog.String("username", user.Username))
+		writeJSON(w, http.StatusCreated, user)
+	}
+}
+
+// @Summary      List users
+// @Description  Returns every user ordered by username, admin only
+// @Tags         Admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   database.User    "Users"
+// @Failure      403  {object}  problem.Problem  "Caller is not an admin"
+// @Failure      503  {object}  problem.Problem  "Database unavailable"
+// @Router       /api/v1/admin/users [get]
+func NewListUsersHandler(db usersDB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(logger, w, r) {
+			return
+		}
+
+		users, err := db.ListUsers(r.Context())
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, users)
+	}
+}
+
+// @Summary      Update a user
+// @Description  Changes the roles of a user or disables or enables them. New roles apply from the next login or token refresh. Disabled users can't log in or refresh their tokens, access tokens they hold stay valid until they expire. Enabling a user also lifts a lockout. Admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        username  path      string             true  "Username"
+// @Param        user      body      updateUserRequest  true  "Fields to change"
+// @Success      200       {object}  database.User      "Updated user"
+// @Failure      400       {object}  problem.Problem    "Nothing to change or unknown role"
+// @Failure      403       {object}  problem.Problem    "Caller is not an admin"
+// @Failure      404       {object}  problem.Problem    "User not found"
+// @Failure      413       {object}  problem.Problem    "Body exceeds the size limit"
+// @Failure      503       {object}  problem.Problem    "Database unavailable"
+// @Router       /api/v1/admin/users/{username} [patch]
+func NewUpdateUserHandler(db usersDB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(logger, w, r) {
+			return
+		}
+
+		var req updateUserRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			invalidBody(w, r, err, err.Error())
+			return
+		}
+
+		if req.Disabled == nil && req.Roles == nil {
+			problem.Write(w, r, problem.Validation([]problem.FieldError{{Field: "disabled", Rule: "required", Message: "disabled or roles is required"}}))
+			return
+		}
+
+		if req.Roles != nil {
+			if fields := rolesField(*req.Roles); fields != nil {
+				problem.Write(w, r, problem.Validation(fields))
+				return
+			}
+		}
+
+		username := chi.URLParam(r, "username")
+
+		var user database.User
+		var err error
+		if req.Roles != nil {
+			if user, err = db.SetUserRoles(r.Context(), username, *req.Roles); err != nil {
+				writeUserError(logger, w, r, err)
+				return
+			}
+			logger.InfoContext(r.Context(), "User roles changed", slog.String("username", username), slog.Any("roles", *req.Roles))
+		}
+
+		if req.Disabled != nil {
+			if user, err = db.SetUserDisabled(r.Context(), username, *req.Disabled); err != nil {
+				writeUserError(logger, w, r, err)
+				return
+			}
+			logger.InfoContext(r.Context(), "User updated", slog.String("username", username), slog.Bool("disabled", *req.Disabled))
+		}
+
+		writeJSON(w, http.StatusOK, user)
+	}
+}
+
+// @Summary      Reset a user's password
+// @Description  Replaces the password, revokes the user's refresh tokens and lifts a lockout, admin only
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        username  path      string                true  "Username"
+// @Param        password  body      resetPasswordRequest  true  "New password"
+// @Success      200       {object}  database.User         "Updated user"
+// @Failure      400       {object}  problem.Problem       "Invalid password"
+// @Failure      403       {object}  problem.Problem       "Caller is not an admin"
+// @Failure      404       {object}  problem.Problem       "User not found"
+// @Failure      413       {object}  problem.Problem       "Body exceeds the size limit"
+// @Failure      503       {object}  problem.Problem       "Database unavailable"
+// @Router       /api/v1/admin/users/{username}/password [put]
+func NewResetPasswordHandler(db usersDB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireAdmin(logger, w, r) {
+			return
+		}
+
+		var req resetPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			invalidBody(w, r, err, err.Error())
+			return
+		}
+
+		if fields := passwordField(req.Password); fields != nil {
+			problem.Write(w, r, problem.Validation(fields))
+			return
+		}
+
+		hash, err := auth.HashPassword(req.Password)
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		username := chi.URLParam(r, "username")
+		user, err := db.SetUserPassword(r.Context(), username, hash)
+		if err != nil {
+			writeUserError(logger, w, r, err)
+			return
+		}
+
+		logger.InfoContext(r.Context(), "Password reset", slog.String("username", username))
+		writeJSON(w, http.StatusOK, user)
+	}
+}
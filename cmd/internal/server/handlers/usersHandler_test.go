@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/problem"
+	"companies/cmd/tests/mocks"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveUsers(t *testing.T, handler http.Handler, method, target, username, caller, body string) *httptest.ResponseRecorder {
+	token := testToken(t, caller)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	if username != "" {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("username", username)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+
+	rr := httptest.NewRecorder()
+	auth.JWTMiddleware(handler).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCreateUserHandler_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+	handler := NewCreateUserHandler(mockDB, testLogger)
+
+	mockDB.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u database.User) (database.User, error) {
+		assert.Equal(t, "jane", u.Username)
+		ok, err := auth.VerifyPassword(u.PasswordHash, testPassword)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		return u, nil
+	})
+
+	rr := serveUsers(t, handler, http.MethodPost, "/api/v1/admin/users", "", auth.AdminUsername, `{"username":"jane","password":"`+testPassword+`"}`)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"username":"jane"`)
+	assert.NotContains(t, rr.Body.String(), "argon2id")
+}
+
+func TestCreateUserHandler_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+	handler := NewCreateUserHandler(mockDB, testLogger)
+
+	rr := serveUsers(t, handler, http.MethodPost, "/api/v1/admin/users", "", auth.AdminUsername, `{"username":"j d","password":"short"}`)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	p := decodeProblem(t, rr)
+	require.Len(t, p.Errors, 2)
+	assert.Equal(t, "username", p.Errors[0].Field)
+	assert.Equal(t, "password", p.Errors[1].Field)
+}
+
+func TestCreateUserHandler_Taken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+	handler := NewCreateUserHandler(mockDB, testLogger)
+
+	mockDB.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(database.User{}, database.ErrConflict)
+
+	rr := serveUsers(t, handler, http.MethodPost, "/api/v1/admin/users", "", auth.AdminUsername, `{"username":"jane","password":"`+testPassword+`"}`)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Equal(t, "username already taken", decodeProblem(t, rr).Detail)
+}
+
+func TestUsersHandlers_RequireAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+
+	for _, handler := range []http.Handler{
+		NewCreateUserHandler(mockDB, testLogger),
+		NewListUsersHandler(mockDB, testLogger),
+		NewUpdateUserHandler(mockDB, testLogger),
+		NewResetPasswordHandler(mockDB, testLogger),
+	} {
+		rr := serveUsers(t, handler, http.MethodPost, "/api/v1/admin/users", "jane", "jane", `{}`)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Equal(t, problem.TypeForbidden, decodeProblem(t, rr).Type)
+	}
+}
+
+func TestListUsersHandler_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+	handler := NewListUsersHandler(mockDB, testLogger)
+
+	mockDB.EXPECT().ListUsers(gomock.Any()).Return([]database.User{newTestUser(t, "admin"), newTestUser(t, "jane")}, nil)
+
+	rr := serveUsers(t, handler, http.MethodGet, "/api/v1/admin/users", "", auth.AdminUsername, "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"username":"jane"`)
+	assert.NotContains(t, rr.Body.String(), "argon2id")
+}
+
+func TestUpdateUserHandler_Disable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+	handler := NewUpdateUserHandler(mockDB, testLogger)
+
+	user := newTestUser(t, "jane")
+	user.Disabled = true
+	mockDB.EXPECT().SetUserDisabled(gomock.Any(), "jane", true).Return(user, nil)
+
+	rr := serveUsers(t, handler, http.MethodPatch, "/api/v1/admin/users/jane", "jane", auth.AdminUsername, `{"disabled":true}`)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"disabled":true`)
+}
+
+func TestUpdateUserHandler_Roles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+	handler := NewUpdateUserHandler(mockDB, testLogger)
+
+	user := newTestUser(t, "jane")
+	user.Roles = []string{auth.RoleViewer}
+	mockDB.EXPECT().SetUserRoles(gomock.Any(), "jane", []string{auth.RoleViewer}).Return(user, nil)
+
+	rr := serveUsers(t, handler, http.MethodPatch, "/api/v1/admin/users/jane", "jane", auth.AdminUsername, `{"roles":["viewer"]}`)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"roles":["viewer"]`)
+}
+
+func TestUpdateUserHandler_UnknownRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+	handler := NewUpdateUserHandler(mockDB, testLogger)
+
+	rr := serveUsers(t, handler, http.MethodPatch, "/api/v1/admin/users/jane", "jane", auth.AdminUsername, `{"roles":["owner"]}`)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "roles", decodeProblem(t, rr).Errors[0].Field)
+}
+
+func TestUpdateUserHandler_MissingFlag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+	handler := NewUpdateUserHandler(mockDB, testLogger)
+
+	rr := serveUsers(t, handler, http.MethodPatch, "/api/v1/admin/users/jane", "jane", auth.AdminUsername, `{}`)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestUpdateUserHandler_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+	handler := NewUpdateUserHandler(mockDB, testLogger)
+
+	mockDB.EXPECT().SetUserDisabled(gomock.Any(), "nobody", false).Return(database.User{}, database.ErrNotFound)
+
+	rr := serveUsers(t, handler, http.MethodPatch, "/api/v1/admin/users/nobody", "nobody", auth.AdminUsername, `{"disabled":false}`)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "no user with this username", decodeProblem(t, rr).Detail)
+}
+
+func TestResetPasswordHandler_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+	handler := NewResetPasswordHandler(mockDB, testLogger)
+
+	mockDB.EXPECT().SetUserPassword(gomock.Any(), "jane", gomock.Any()).DoAndReturn(func(_ context.Context, username, hash string) (database.User, error) {
+		ok, err := auth.VerifyPassword(hash, "a brand new password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		return database.User{Username: username, PasswordHash: hash}, nil
+	})
+
+	rr := serveUsers(t, handler, http.MethodPut, "/api/v1/admin/users/jane/password", "jane", auth.AdminUsername, `{"password":"a brand new password"}`)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestResetPasswordHandler_TooShort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockusersDB(ctrl)
+	handler := NewResetPasswordHandler(mockDB, testLogger)
+
+	rr := serveUsers(t, handler, http.MethodPut, "/api/v1/admin/users/jane/password", "jane", auth.AdminUsername, `{"password":"short"}`)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "password", decodeProblem(t, rr).Errors[0].Field)
+}
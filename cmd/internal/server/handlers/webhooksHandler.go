@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/problem"
+	"companies/cmd/internal/structs"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	kWebhookSecretBytes     = 32
+	kDefaultDeliveriesLimit = 50
+	kMaxDeliveriesLimit     = 100
+)
+
+// webhookEventTypes are the events delivered to subscribers.
+var webhookEventTypes = []string{
+	structs.CompanyCreated,
+	structs.CompanyUpdated,
+	structs.CompanyDeleted,
+	structs.CompanyRestored,
+	structs.CompanyPurged,
+}
+
+//go:generate mockgen -source=webhooksHandler.go -destination=../../../tests/mocks/mock_webhooks.go -package=mocks
+type webhooksDB interface {
+	CreateWebhook(context.Context, database.WebhookSubscription) (database.WebhookSubscription, error)
+	GetWebhook(context.Context, uuid.UUID) (database.WebhookSubscription, error)
+	ListWebhooks(context.Context) ([]database.WebhookSubscription, error)
+	UpdateWebhook(context.Context, uuid.UUID, database.WebhookUpdate) (database.WebhookSubscription, error)
+	DeleteWebhook(context.Context, uuid.UUID) error
+	ListWebhookDeliveries(ctx context.Context, id uuid.UUID, limit int) ([]database.WebhookDelivery, error)
+}
+
+type createWebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+// createWebhookResponse is the only place the signing secret is returned.
+type createWebhookResponse struct {
+	database.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return errors.New("url must be an absolute URL")
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("url scheme must be http or https")
+	}
+
+	return nil
+}
+
+func validateEventTypes(eventTypes []string) error {
+	for _, t := range eventTypes {
+		if !slices.Contains(webhookEventTypes, t) {
+			return fmt.Errorf("unsupported event type %v", t)
+		}
+	}
+
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, kWebhookSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+func parseWebhookID(r *http.Request) (uuid.UUID, error) {
+	id := chi.URLParam(r, "id")
+	logging.AddAttrs(r.Context(), slog.String(logging.KeyWebhookID, id))
+
+	return uuid.Parse(id)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(v)
+}
+
+// @Summary      Create a webhook subscription
+// @Description  Registers an endpoint notified about company changes. An empty eventTypes list subscribes to all events. The signing secret is returned only once
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        webhook  body      createWebhookRequest   true  "Subscription to create"
+// @Success      201      {object}  createWebhookResponse  "Created subscription with its signing secret"
+// @Failure      400      {object}  problem.Problem        "Invalid input"
+// @Failure      403      {object}  problem.Problem        "Caller is not an admin"
+// @Failure      413      {object}  problem.Problem        "Body exceeds the size limit"
+// @Failure      500      {object}  problem.Problem        "Creation failed"
+// @Router       /api/v1/webhooks [post]
+func NewCreateWebhookHandler(db webhooksDB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "Create webhook")
+
+		var req createWebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			invalidBody(w, r, err, err.Error())
+			return
+		}
+
+		if err := validateWebhookURL(req.URL); err != nil {
+			logger.InfoContext(r.Context(), "Invalid webhook URL", logging.Err(err))
+			problem.Write(w, r, problem.Validation([]problem.FieldError{{Field: "url", Rule: "url", Message: err.Error()}}))
+			return
+		}
+
+		if err := validateEventTypes(req.EventTypes); err != nil {
+			logger.InfoContext(r.Context(), "Invalid webhook event types", logging.Err(err))
+			problem.Write(w, r, problem.Validation([]problem.FieldError{{Field: "eventTypes", Rule: "oneOf", Message: err.Error()}}))
+			return
+		}
+
+		secret, err := newWebhookSecret()
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		subscription := database.WebhookSubscription{
+			URL:        req.URL,
+			EventTypes: req.EventTypes,
+			Secret:     secret,
+			Enabled:    req.Enabled == nil || *req.Enabled,
+		}
+
+		created, err := db.CreateWebhook(r.Context(), subscription)
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		logging.AddAttrs(r.Context(), slog.String(logging.KeyWebhookID, created.ID.String()))
+		writeJSON(w, http.StatusCreated, createWebhookResponse{created, created.Secret})
+	}
+}
+
+// @Summary      List webhook subscriptions
+// @Tags         Webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {array}   database.WebhookSubscription  "Subscriptions"
+// @Failure      403  {object}  problem.Problem               "Caller is not an admin"
+// @Failure      500  {object}  problem.Problem               "Listing failed"
+// @Router       /api/v1/webhooks [get]
+func NewListWebhooksHandler(db webhooksDB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "List webhooks")
+
+		subscriptions, err := db.ListWebhooks(r.Context())
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, subscriptions)
+	}
+}
+
+// @Summary      Get a webhook subscription
+// @Tags         Webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string                        true  "Subscription UUID"
+// @Success      200  {object}  database.WebhookSubscription  "Subscription found"
+// @Failure      400  {object}  problem.Problem               "Invalid UUID"
+// @Failure      403  {object}  problem.Problem               "Caller is not an admin"
+// @Failure      404  {object}  problem.Problem               "Subscription not found"
+// @Router       /api/v1/webhooks/{id} [get]
+func NewGetWebhookHandler(db webhooksDB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "Get webhook")
+
+		id, err := parseWebhookID(r)
+		if err != nil {
+			logger.InfoContext(r.Context(), "Invalid id", logging.Err(err))
+			invalidID(w, r)
+			return
+		}
+
+		subscription, err := db.GetWebhook(r.Context(), id)
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, subscription)
+	}
+}
+
+// @Summary      Update a webhook subscription
+// @Description  Changes the URL, event types or enabled flag. Re-enabling a subscription resets its failure counter
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                        true  "Subscription UUID"
+// @Param        webhook  body      database.WebhookUpdate        true  "Fields to change"
+// @Success      200      {object}  database.WebhookSubscription  "Updated subscription"
+// @Failure      400      {object}  problem.Problem               "Invalid input"
+// @Failure      403      {object}  problem.Problem               "Caller is not an admin"
+// @Failure      404      {object}  problem.Problem               "Subscription not found"
+// @Failure      413      {object}  problem.Problem               "Body exceeds the size limit"
+// @Router       /api/v1/webhooks/{id} [patch]
+func NewUpdateWebhookHandler(db webhooksDB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "Update webhook")
+
+		id, err := parseWebhookID(r)
+		if err != nil {
+			logger.InfoContext(r.Context(), "Invalid id", logging.Err(err))
+			invalidID(w, r)
+			return
+		}
+
+		var update database.WebhookUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			logger.InfoContext(r.Context(), "Invalid body", logging.Err(err))
+			invalidBody(w, r, err, err.Error())
+			return
+		}
+
+		if update.URL != nil {
+			if err := validateWebhookURL(*update.URL); err != nil {
+				logger.InfoContext(r.Context(), "Invalid webhook URL", logging.Err(err))
+				problem.Write(w, r, problem.Validation([]problem.FieldError{{Field: "url", Rule: "url", Message: err.Error()}}))
+				return
+			}
+		}
+
+		if update.EventTypes != nil {
+			if err := validateEventTypes(*update.EventTypes); err != nil {
+				logger.InfoContext(r.Context(), "Invalid webhook event types", logging.Err(err))
+				problem.Write(w, r, problem.Validation([]problem.FieldError{{Field: "eventTypes", Rule: "oneOf", Message: err.Error()}}))
+				return
+			}
+		}
+
+		subscription, err := db.UpdateWebhook(r.Context(), id, update)
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, subscription)
+	}
+}
+
+// @Summary      Delete a webhook subscription
+// @Description  Deletes the subscription together with its delivery history
+// @Tags         Webhooks
+// @Security     BearerAuth
+// @Param        id   path      string           true  "Subscription UUID"
+// @Success      204  {string}  string           "Deleted"
+// @Failure      400  {object}  problem.Problem  "Invalid UUID"
+// @Failure      403  {object}  problem.Problem  "Caller is not an admin"
+// @Failure      404  {object}  problem.Problem  "Subscription not found"
+// @Failure      500  {object}  problem.Problem  "Deletion failed"
+// @Router       /api/v1/webhooks/{id} [delete]
+func NewDeleteWebhookHandler(db webhooksDB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "Delete webhook")
+
+		id, err := parseWebhookID(r)
+		if err != nil {
+			logger.InfoContext(r.Context(), "Invalid id", logging.Err(err))
+			invalidID(w, r)
+			return
+		}
+
+		if err := db.DeleteWebhook(r.Context(), id); err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// @Summary      List webhook deliveries
+// @Description  Returns the most recent delivery attempts of a subscription, newest first
+// @Tags         Webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path      string                    true   "Subscription UUID"
+// @Param        limit  query     int                       false  "Number of attempts (1-100, default 50)"
+// @Success      200    {array}   database.WebhookDelivery  "Delivery attempts"
+// @Failure      400    {object}  problem.Problem           "Invalid input"
+// @Failure      403    {object}  problem.Problem           "Caller is not an admin"
+// @Failure      404    {object}  problem.Problem           "Subscription not found"
+// @Failure      500    {object}  problem.Problem           "Listing failed"
+// @Router       /api/v1/webhooks/{id}/deliveries [get]
+func NewListWebhookDeliveriesHandler(db webhooksDB, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "List webhook deliveries")
+
+		id, err := parseWebhookID(r)
+		if err != nil {
+			logger.InfoContext(r.Context(), "Invalid id", logging.Err(err))
+			invalidID(w, r)
+			return
+		}
+
+		limit := kDefaultDeliveriesLimit
+		value, err := parseIntParam(r.URL.Query(), "limit")
+		if err != nil || (value != nil && (*value < 1 || *value > kMaxDeliveriesLimit)) {
+			logger.InfoContext(r.Context(), "Invalid limit")
+			problem.Write(w, r, problem.New(http.StatusBadRequest, problem.TypeInvalidQuery,
+				fmt.Sprintf("limit must be between 1 and %v", kMaxDeliveriesLimit)))
+			return
+		}
+		if value != nil {
+			limit = *value
+		}
+
+		if _, err := db.GetWebhook(r.Context(), id); err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		deliveries, err := db.ListWebhookDeliveries(r.Context(), id, limit)
+		if err != nil {
+			writeError(logger, w, r, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, deliveries)
+	}
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"bytes"
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/structs"
+	"companies/cmd/tests/mocks"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newWebhookRequest(method, target, id string, body []byte) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+
+	if id != "" {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+
+	return req
+}
+
+func TestCreateWebhookHandler_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockwebhooksDB(ctrl)
+	handler := NewCreateWebhookHandler(mockDB, testLogger)
+
+	id := uuid.New()
+	mockDB.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s database.WebhookSubscription) (database.WebhookSubscription, error) {
+		assert.Equal(t, "https://partner.example/hook", s.URL)
+		assert.Equal(t, []string{structs.CompanyCreated}, s.EventTypes)
+		assert.True(t, s.Enabled)
+		assert.Len(t, s.Secret, 2*kWebhookSecretBytes)
+		s.ID = &id
+		return s, nil
+	})
+
+	body, _ := json.Marshal(createWebhookRequest{URL: "https://partner.example/hook", EventTypes: []string{structs.CompanyCreated}})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, newWebhookRequest(http.MethodPost, "/api/v1/webhooks", "", body))
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var got map[string]any
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	assert.Equal(t, id.String(), got["id"])
+	assert.Len(t, got["secret"], 2*kWebhookSecretBytes)
+}
+
+func TestCreateWebhookHandler_InvalidInput(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockwebhooksDB(ctrl)
+	handler := NewCreateWebhookHandler(mockDB, testLogger)
+
+	requests := []createWebhookRequest{
+		{URL: "partner.example/hook"},
+		{URL: "ftp://partner.example/hook"},
+		{URL: "https://partner.example/hook", EventTypes: []string{structs.CompanyCreateFailed}},
+	}
+
+	for _, r := range requests {
+		body, _ := json.Marshal(r)
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newWebhookRequest(http.MethodPost, "/api/v1/webhooks", "", body))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, r)
+	}
+}
+
+func TestGetWebhookHandler_SecretHidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockwebhooksDB(ctrl)
+	handler := NewGetWebhookHandler(mockDB, testLogger)
+
+	id := uuid.New()
+	mockDB.EXPECT().GetWebhook(gomock.Any(), id).Return(database.WebhookSubscription{ID: &id, Secret: "secret"}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newWebhookRequest(http.MethodGet, "/api/v1/webhooks/"+id.String(), id.String(), nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "secret")
+}
+
+func TestGetWebhookHandler_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockwebhooksDB(ctrl)
+	handler := NewGetWebhookHandler(mockDB, testLogger)
+
+	id := uuid.New()
+	mockDB.EXPECT().GetWebhook(gomock.Any(), id).Return(database.WebhookSubscription{}, fmt.Errorf("GetWebhook error: %w", database.ErrNotFound))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newWebhookRequest(http.MethodGet, "/api/v1/webhooks/"+id.String(), id.String(), nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestUpdateWebhookHandler_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockwebhooksDB(ctrl)
+	handler := NewUpdateWebhookHandler(mockDB, testLogger)
+
+	id := uuid.New()
+	enabled := true
+	mockDB.EXPECT().UpdateWebhook(gomock.Any(), id, database.WebhookUpdate{Enabled: &enabled}).Return(database.WebhookSubscription{ID: &id, Enabled: true}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newWebhookRequest(http.MethodPatch, "/api/v1/webhooks/"+id.String(), id.String(), []byte(`{"enabled":true}`)))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestUpdateWebhookHandler_InvalidURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockwebhooksDB(ctrl)
+	handler := NewUpdateWebhookHandler(mockDB, testLogger)
+
+	id := uuid.New()
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newWebhookRequest(http.MethodPatch, "/api/v1/webhooks/"+id.String(), id.String(), []byte(`{"url":"not a url"}`)))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestDeleteWebhookHandler_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockwebhooksDB(ctrl)
+	handler := NewDeleteWebhookHandler(mockDB, testLogger)
+
+	id := uuid.New()
+	mockDB.EXPECT().DeleteWebhook(gomock.Any(), id).Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newWebhookRequest(http.MethodDelete, "/api/v1/webhooks/"+id.String(), id.String(), nil))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestListWebhookDeliveriesHandler_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockwebhooksDB(ctrl)
+	handler := NewListWebhookDeliveriesHandler(mockDB, testLogger)
+
+	id := uuid.New()
+	mockDB.EXPECT().GetWebhook(gomock.Any(), id).Return(database.WebhookSubscription{ID: &id}, nil)
+	mockDB.EXPECT().ListWebhookDeliveries(gomock.Any(), id, 10).Return([]database.WebhookDelivery{{SubscriptionID: id, Attempt: 1}}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newWebhookRequest(http.MethodGet, "/api/v1/webhooks/"+id.String()+"/deliveries?limit=10", id.String(), nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var got []database.WebhookDelivery
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	assert.Len(t, got, 1)
+}
+
+func TestListWebhookDeliveriesHandler_InvalidLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockDB := mocks.NewMockwebhooksDB(ctrl)
+	handler := NewListWebhookDeliveriesHandler(mockDB, testLogger)
+
+	id := uuid.New()
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newWebhookRequest(http.MethodGet, "/api/v1/webhooks/"+id.String()+"/deliveries?limit=1000", id.String(), nil))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -3,97 +3,234 @@ package server
 import (
 	"companies/cmd/internal/auth"
 	configparser "companies/cmd/internal/configParser"
-	"companies/cmd/internal/consts"
 	"companies/cmd/internal/database"
 	eventsender "companies/cmd/internal/eventSender"
+	"companies/cmd/internal/health"
+	"companies/cmd/internal/logging"
 	"companies/cmd/internal/metrics"
 	"companies/cmd/internal/server/handlers"
+	"companies/cmd/internal/tracing"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
-	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+const (
+	defaultReadTimeoutSeconds       = 15
+	defaultWriteTimeoutSeconds      = 15
+	defaultIdleTimeoutSeconds       = 60
+	defaultReadHeaderTimeoutSeconds = 5
+	defaultMaxHeaderBytes           = 1 << 20
+	defaultMaxBodyBytes             = 1 << 20
+	defaultMaxFailedLogins          = 5
+	defaultLockoutSeconds           = 900
+	defaultRefreshTokenTTLSeconds   = 30 * 24 * 60 * 60
+)
+
 type RESTfulServer struct {
 	router *chi.Mux
 	addr   string
 	port   string
 	srv    *http.Server
+	logger *slog.Logger
+	// tlsErr is a TLS misconfiguration, Serve returns it rather than falling
+	// back to plain HTTP.
+	tlsErr error
 }
 
 //go:generate mockgen -source=server.go -destination=../../tests/mocks/mock_rest_server.go -package=mocks
 type RESTServer interface {
-	Serve()
-	Shutdown() error
+	// Serve blocks until the server fails or Shutdown is called, after a
+	// Shutdown it returns nil.
+	Serve() error
+	// Shutdown stops accepting connections and waits for the in-flight
+	// requests until ctx ends.
+	Shutdown(ctx context.Context) error
 }
 
-func NewRESTfulServer(config configparser.HTTP, db database.Database, eventSender eventsender.EventSender) RESTServer {
+// NewRESTfulServer counts requests on m and serves gatherer, the registry m
+// is registered on, at /metrics.
+func NewRESTfulServer(config configparser.HTTP, authConfig configparser.Auth, db database.Database, webhooks database.WebhookStore, accounts database.AccountStore, revocations *auth.RevocationList, eventSender eventsender.EventSender, checker *health.Checker, m *metrics.Metrics, gatherer prometheus.Gatherer, logger *slog.Logger, level *slog.LevelVar) RESTServer {
 	addr := configparser.GetCfgValue("HTTP_HOST", config.Addr)
 	port := configparser.GetCfgValue("HTTP_PORT", config.Port)
 
-	server := &RESTfulServer{addr: addr, port: port}
+	server := &RESTfulServer{addr: addr, port: port, logger: logger}
 
 	server.router = chi.NewRouter()
 
-	metrics.Init()
+	server.router.Use(middleware.RequestID)
+	server.router.Use(tracing.Middleware)
+	server.router.Use(logging.Middleware(logger))
+	server.router.Use(m.Middleware)
 
-	server.router.Use(middleware.Logger)
-	server.router.Use(metrics.MetricsMiddleware)
+	writeTimeout := seconds(configparser.GetCfgValue("HTTP_WRITE_TIMEOUT_SECONDS", config.WriteTimeoutSeconds), defaultWriteTimeoutSeconds)
+	maxBodyBytes := positiveOr(configparser.GetCfgValue("HTTP_MAX_BODY_BYTES", config.MaxBodyBytes), defaultMaxBodyBytes)
+
+	server.router.Use(withDeadline(writeTimeout))
+	server.router.Use(limitBody(int64(maxBodyBytes)))
 
 	server.srv = &http.Server{
 		Addr:              fmt.Sprintf("%v:%v", addr, port),
 		Handler:           server.router,
-		ReadTimeout:       15 * time.Second,
-		WriteTimeout:      15 * time.Second,
-		IdleTimeout:       60 * time.Second,
-		ReadHeaderTimeout: 5 * time.Second,
-		MaxHeaderBytes:    1024 * 1024,
+		ReadTimeout:       seconds(configparser.GetCfgValue("HTTP_READ_TIMEOUT_SECONDS", config.ReadTimeoutSeconds), defaultReadTimeoutSeconds),
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       seconds(configparser.GetCfgValue("HTTP_IDLE_TIMEOUT_SECONDS", config.IdleTimeoutSeconds), defaultIdleTimeoutSeconds),
+		ReadHeaderTimeout: seconds(configparser.GetCfgValue("HTTP_READ_HEADER_TIMEOUT_SECONDS", config.ReadHeaderTimeoutSeconds), defaultReadHeaderTimeoutSeconds),
+		MaxHeaderBytes:    positiveOr(configparser.GetCfgValue("HTTP_MAX_HEADER_BYTES", config.MaxHeaderBytes), defaultMaxHeaderBytes),
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	server.srv.TLSConfig, server.tlsErr = newTLSConfig(config.TLS, logger)
+
+	if err := m.RegisterCompanies(db); err != nil {
+		logger.Warn("Failed to register the company metrics", logging.Err(err))
 	}
 
-	server.initHandlers(db, eventSender)
+	server.initHandlers(authConfig, db, webhooks, accounts, revocations, eventSender, checker, logger, level, gatherer)
 
 	return server
 }
 
-func (s *RESTfulServer) initHandlers(db database.Database, eventSender eventsender.EventSender) {
-	create := handlers.NewCreateRecordHandler(db, eventSender)
-	update := handlers.NewUpdateRecordHandler(db, eventSender)
-	get := handlers.NewGetRecordHandler(db)
-	delete := handlers.NewDeleteRecordHandler(db, eventSender)
+func positiveOr(value, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func seconds(value, fallback int) time.Duration {
+	return time.Duration(positiveOr(value, fallback)) * time.Second
+}
+
+// limitBody makes reading more than n bytes of a body fail, handlers answer
+// 413 then.
+func limitBody(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// withDeadline cancels the request context once the server stops waiting for
+// the response, so the queries and publishes of a slow handler stop as well.
+func withDeadline(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func (s *RESTfulServer) initHandlers(authConfig configparser.Auth, db database.Database, webhooks database.WebhookStore, accounts database.AccountStore, revocations *auth.RevocationList, eventSender eventsender.EventSender, checker *health.Checker, logger *slog.Logger, level *slog.LevelVar, gatherer prometheus.Gatherer) {
+	create := handlers.NewCreateRecordHandler(db, eventSender, logger)
+	update := handlers.NewUpdateRecordHandler(db, eventSender, logger)
+	replace := handlers.NewReplaceRecordHandler(db, eventSender, logger)
+	get := handlers.NewGetRecordHandler(db, logger)
+	list := handlers.NewListRecordsHandler(db, logger)
+	search := handlers.NewSearchRecordsHandler(db, logger)
+	delete := handlers.NewDeleteRecordHandler(db, eventSender, logger)
+	restore := handlers.NewRestoreRecordHandler(db, eventSender, logger)
+
+	// Hands out admin tokens without credentials, only for development.
+	if configparser.GetCfgValue("AUTH_DEV_MODE", authConfig.DevMode) {
+		logger.Warn("Auth dev mode is enabled, POST /api/v1/token issues admin tokens to anyone")
+		s.router.Post("/api/v1/token", auth.HandleFunc)
+	}
+
+	maxFailedLogins := positiveOr(configparser.GetCfgValue("AUTH_MAX_FAILED_LOGINS", authConfig.MaxFailedLogins), defaultMaxFailedLogins)
+	lockout := seconds(configparser.GetCfgValue("AUTH_LOCKOUT_SECONDS", authConfig.LockoutSeconds), defaultLockoutSeconds)
+	refreshTTL := seconds(configparser.GetCfgValue("AUTH_REFRESH_TOKEN_TTL_SECONDS", authConfig.RefreshTokenTTLSeconds), defaultRefreshTokenTTLSeconds)
 
-	// just for test
-	s.router.Post("/api/v1/token", auth.HandleFunc)
+	s.router.Get("/.well-known/jwks.json", handlers.NewJWKSHandler())
+
+	s.router.Route("/api/v1/auth", func(r chi.Router) {
+		r.Post("/login", handlers.NewLoginHandler(accounts, maxFailedLogins, lockout, refreshTTL, logger))
+		r.Post("/refresh", handlers.NewRefreshHandler(accounts, refreshTTL, logger))
+		r.With(auth.JWTMiddleware).Post("/logout", handlers.NewLogoutHandler(accounts, revocations, logger))
+	})
 
 	s.router.Get("/swagger/*", httpSwagger.WrapHandler)
 
-	s.router.Handle("/metrics", promhttp.Handler())
+	s.router.Get("/healthz", handlers.NewLivenessHandler())
+	s.router.Get("/readyz", handlers.NewReadinessHandler(checker, logger))
+
+	s.router.Handle("/metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
 
 	s.router.Route("/api/v1/companies", func(r chi.Router) {
-		r.With(auth.JWTMiddleware).Post("/", create)
-		r.With(auth.JWTMiddleware).Patch("/{id}", update)
-		r.With(auth.JWTMiddleware).Delete("/{id}", delete)
-		r.Get("/{id}", get)
+		writer := r.With(auth.JWTMiddleware, auth.RequireScope(auth.ScopeCompaniesWrite))
+		writer.Post("/", create)
+		writer.Patch("/{id}", update)
+		writer.Put("/{id}", replace)
+
+		// Purging additionally requires admin, the handler checks it.
+		deleter := r.With(auth.JWTMiddleware, auth.RequireScope(auth.ScopeCompaniesDelete))
+		deleter.Delete("/{id}", delete)
+		deleter.Post("/{id}/restore", restore)
+
+		reader := r.With(auth.JWTMiddleware, auth.RequireScope(auth.ScopeCompaniesRead))
+		reader.Get("/", list)
+		reader.Get("/search", search)
+		reader.Get("/{id}", get)
 	})
-}
 
-func (s *RESTfulServer) Serve() {
-	log.Println(consts.ApplicationPrefix, "Starting RESTful server")
-	s.srv.ListenAndServe()
+	s.router.Route("/api/v1/webhooks", func(r chi.Router) {
+		r.Use(auth.JWTMiddleware, auth.RequireScope(auth.ScopeAdmin))
+		r.Post("/", handlers.NewCreateWebhookHandler(webhooks, logger))
+		r.Get("/", handlers.NewListWebhooksHandler(webhooks, logger))
+		r.Get("/{id}", handlers.NewGetWebhookHandler(webhooks, logger))
+		r.Patch("/{id}", handlers.NewUpdateWebhookHandler(webhooks, logger))
+		r.Delete("/{id}", handlers.NewDeleteWebhookHandler(webhooks, logger))
+		r.Get("/{id}/deliveries", handlers.NewListWebhookDeliveriesHandler(webhooks, logger))
+	})
+
+	s.router.Route("/api/v1/admin", func(r chi.Router) {
+		r.Use(auth.JWTMiddleware, auth.RequireScope(auth.ScopeAdmin))
+		r.Get("/log-level", handlers.NewGetLogLevelHandler(level, logger))
+		r.Put("/log-level", handlers.NewSetLogLevelHandler(level, logger))
+		r.Post("/users", handlers.NewCreateUserHandler(accounts, logger))
+		r.Get("/users", handlers.NewListUsersHandler(accounts, logger))
+		r.Patch("/users/{username}", handlers.NewUpdateUserHandler(accounts, logger))
+		r.Put("/users/{username}/password", handlers.NewResetPasswordHandler(accounts, logger))
+	})
 }
 
-func (s *RESTfulServer) Shutdown() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+func (s *RESTfulServer) Serve() error {
+	if s.tlsErr != nil {
+		return fmt.Errorf("invalid TLS configuration: %w", s.tlsErr)
+	}
+
+	var err error
+	if s.srv.TLSConfig != nil {
+		s.logger.Info("Starting RESTful server", slog.String("addr", s.srv.Addr), slog.Bool("tls", true), slog.Bool("client_auth", s.srv.TLSConfig.ClientAuth != tls.NoClientCert))
+		err = s.srv.ListenAndServeTLS("", "")
+	} else {
+		s.logger.Info("Starting RESTful server", slog.String("addr", s.srv.Addr), slog.Bool("tls", false))
+		err = s.srv.ListenAndServe()
+	}
 
-	if err := s.srv.Shutdown(ctx); err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 
 	return nil
 }
+
+func (s *RESTfulServer) Shutdown(ctx context.Context) error {
+	s.logger.Info("Stopping RESTful server")
+	return s.srv.Shutdown(ctx)
+}
@@ -1,26 +1,193 @@
 package server
 
 import (
+	"companies/cmd/internal/auth"
 	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/health"
+	"companies/cmd/internal/metrics"
+	"companies/cmd/internal/problem"
 	"companies/cmd/tests/mocks"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestNewRESTfulServer_NotNil(t *testing.T) {
-	ctrl := gomock.NewController(t)
+var testHTTPConfig = configparser.HTTP{
+	Addr: "127.0.0.1",
+	Port: "8080",
+}
+
+func newTestServer(ctrl *gomock.Controller, httpCfg configparser.HTTP) (*RESTfulServer, *mocks.MockDatabase, *mocks.MockEventSender) {
+	return newTestServerWithAuth(ctrl, httpCfg, configparser.Auth{})
+}
 
+func newTestServerWithAuth(ctrl *gomock.Controller, httpCfg configparser.HTTP, authCfg configparser.Auth) (*RESTfulServer, *mocks.MockDatabase, *mocks.MockEventSender) {
 	mockDB := mocks.NewMockDatabase(ctrl)
+	mockWebhooks := mocks.NewMockWebhookStore(ctrl)
+	mockAccounts := mocks.NewMockAccountStore(ctrl)
 	mockEventSender := mocks.NewMockEventSender(ctrl)
 
-	httpCfg := configparser.HTTP{
-		Addr: "127.0.0.1",
-		Port: "8080",
+	// Every server counts on a registry of its own.
+	registry := prometheus.NewRegistry()
+	m, err := metrics.New(registry)
+	if err != nil {
+		ctrl.T.Fatalf("create metrics: %v", err)
 	}
 
-	srv := NewRESTfulServer(httpCfg, mockDB, mockEventSender)
+	srv := NewRESTfulServer(httpCfg, authCfg, mockDB, mockWebhooks, mockAccounts, auth.NewRevocationList(mockAccounts, time.Second), mockEventSender, health.NewChecker(0), m, registry, testLogger, new(slog.LevelVar))
+	return srv.(*RESTfulServer), mockDB, mockEventSender
+}
+
+func TestNewRESTfulServer_NotNil(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	srv, _, _ := newTestServer(ctrl, testHTTPConfig)
 
 	assert.NotNil(t, srv)
 }
+
+func TestNewRESTfulServer_Twice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	assert.NotPanics(t, func() {
+		newTestServer(ctrl, testHTTPConfig)
+		newTestServer(ctrl, testHTTPConfig)
+	})
+}
+
+func TestMetrics_LabelsRoutePattern(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	srv, mockDB, _ := newTestServer(ctrl, testHTTPConfig)
+	mockDB.EXPECT().CountCompanies(gomock.Any()).Return([]metrics.CompanyCount{{Type: 1, IsRegistered: true, Count: 3}}, nil)
+
+	token, err := auth.GenerateToken("analytics", []string{auth.RoleViewer})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies/not-a-uuid", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	srv.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	rr := httptest.NewRecorder()
+	srv.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `http_requests_total{method="GET",path="/api/v1/companies/{id}",status="400"}`)
+	assert.NotContains(t, rr.Body.String(), "not-a-uuid")
+	assert.Contains(t, rr.Body.String(), `companies_total{registered="true",type="1"} 3`)
+}
+
+func TestNewRESTfulServer_Limits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	srv, _, _ := newTestServer(ctrl, configparser.HTTP{ReadTimeoutSeconds: 7, IdleTimeoutSeconds: 90, MaxHeaderBytes: 4096})
+
+	assert.Equal(t, 7*time.Second, srv.srv.ReadTimeout)
+	assert.Equal(t, defaultWriteTimeoutSeconds*time.Second, srv.srv.WriteTimeout)
+	assert.Equal(t, 90*time.Second, srv.srv.IdleTimeout)
+	assert.Equal(t, 4096, srv.srv.MaxHeaderBytes)
+	assert.Nil(t, srv.srv.TLSConfig)
+}
+
+func TestNewRESTfulServer_BodyTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	srv, _, mockEventSender := newTestServer(ctrl, configparser.HTTP{MaxBodyBytes: 16})
+	mockEventSender.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	token, err := auth.GenerateToken(auth.AdminUsername, []string{auth.RoleAdmin})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/companies", strings.NewReader(`{"name":"`+strings.Repeat("a", 64)+`"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+	srv.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Contains(t, rr.Body.String(), problem.TypeBodyTooLarge)
+}
+
+func TestServe_InvalidTLS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	srv, _, _ := newTestServer(ctrl, configparser.HTTP{Addr: "127.0.0.1", Port: "0", TLS: configparser.TLS{CertFile: "server.crt"}})
+
+	assert.ErrorContains(t, srv.Serve(), "invalid TLS configuration")
+}
+
+func TestTokenEndpoint_OnlyInDevMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	srv, _, _ := newTestServer(ctrl, testHTTPConfig)
+	rr := httptest.NewRecorder()
+	srv.router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/token", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	srv, _, _ = newTestServerWithAuth(ctrl, testHTTPConfig, configparser.Auth{DevMode: true})
+	rr = httptest.NewRecorder()
+	srv.router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/token", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"token"`)
+}
+
+func TestCompanyRoutes_RequireScopes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	srv, _, _ := newTestServer(ctrl, testHTTPConfig)
+
+	// A read-only service account can neither delete nor restore companies,
+	// nor reach the admin routes.
+	token, err := auth.GenerateToken("analytics", []string{auth.RoleViewer})
+	require.NoError(t, err)
+
+	for _, target := range []struct{ method, path string }{
+		{http.MethodDelete, "/api/v1/companies/0b5f6d2e-7f43-4c8e-9d1c-3a0e8f0b9a11"},
+		{http.MethodPost, "/api/v1/companies/0b5f6d2e-7f43-4c8e-9d1c-3a0e8f0b9a11/restore"},
+		{http.MethodPost, "/api/v1/companies"},
+		{http.MethodGet, "/api/v1/webhooks"},
+		{http.MethodGet, "/api/v1/admin/users"},
+	} {
+		req := httptest.NewRequest(target.method, target.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rr := httptest.NewRecorder()
+		srv.router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code, target.path)
+		assert.Contains(t, rr.Body.String(), problem.TypeForbidden, target.path)
+	}
+}
+
+func TestCompanyRoutes_ReadsRequireToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	srv, _, _ := newTestServer(ctrl, testHTTPConfig)
+
+	noScopes, err := auth.GenerateToken("nobody", nil)
+	require.NoError(t, err)
+
+	for _, path := range []string{
+		"/api/v1/companies",
+		"/api/v1/companies/search?q=acme",
+		"/api/v1/companies/0b5f6d2e-7f43-4c8e-9d1c-3a0e8f0b9a11",
+	} {
+		rr := httptest.NewRecorder()
+		srv.router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, path)
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+noScopes)
+		rr = httptest.NewRecorder()
+		srv.router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code, path)
+	}
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/logging"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	ClientAuthNone    = "none"
+	ClientAuthRequest = "request"
+	ClientAuthRequire = "require"
+
+	defaultReloadInterval = 30 * time.Second
+)
+
+// newTLSConfig returns nil when no certificate is configured, the server then
+// serves plain HTTP.
+func newTLSConfig(config configparser.TLS, logger *slog.Logger) (*tls.Config, error) {
+	certFile := configparser.GetCfgValue("HTTP_TLS_CERT_FILE", config.CertFile)
+	keyFile := configparser.GetCfgValue("HTTP_TLS_KEY_FILE", config.KeyFile)
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both a TLS certificate and key are required")
+	}
+
+	interval := time.Duration(configparser.GetCfgValue("HTTP_TLS_RELOAD_INTERVAL_SECONDS", config.ReloadIntervalSeconds)) * time.Second
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+
+	reloader, err := newCertReloader(certFile, keyFile, interval, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	clientCAFile := configparser.GetCfgValue("HTTP_TLS_CLIENT_CA_FILE", config.ClientCAFile)
+	clientAuth := configparser.GetCfgValue("HTTP_TLS_CLIENT_AUTH", config.ClientAuth)
+	if clientAuth == "" {
+		clientAuth = ClientAuthNone
+		if clientCAFile != "" {
+			clientAuth = ClientAuthRequire
+		}
+	}
+
+	switch clientAuth {
+	case ClientAuthNone:
+		return tlsConfig, nil
+	case ClientAuthRequest:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unknown TLS client auth %q, use %s, %s or %s", clientAuth, ClientAuthNone, ClientAuthRequest, ClientAuthRequire)
+	}
+
+	if clientCAFile == "" {
+		return nil, fmt.Errorf("TLS client auth %q needs a client CA file", clientAuth)
+	}
+
+	pem, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client CA: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", clientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+
+	return tlsConfig, nil
+}
+
+// certReloader serves the certificate from disk and loads it again once the
+// files change, so rotated certificates are used without a restart. The
+// files are checked at most once per interval, during a handshake.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile string, interval time.Duration, logger *slog.Logger) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, interval: interval, logger: logger}
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < r.interval {
+		return r.cert, nil
+	}
+	r.checkedAt = time.Now()
+
+	// A failed check or reload keeps the current certificate, the files may
+	// be halfway through being replaced.
+	modTime, err := r.latestModTime()
+	if err != nil {
+		r.logger.Warn("Failed to check the TLS certificate", logging.Err(err))
+		return r.cert, nil
+	}
+
+	if modTime.Equal(r.modTime) {
+		return r.cert, nil
+	}
+
+	if err := r.load(modTime); err != nil {
+		r.logger.Warn("Failed to reload the TLS certificate", logging.Err(err))
+		return r.cert, nil
+	}
+
+	r.logger.Info("Reloaded the TLS certificate")
+	return r.cert, nil
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the TLS certificate: %w", err)
+	}
+
+	r.cert = &cert
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+
+	return nil
+}
+
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogger = slog.New(slog.DiscardHandler)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestCert issues a certificate for 127.0.0.1, self-signed when parent is
+// nil.
+func newTestCert(t *testing.T, name string, isCA bool, parent *testCert) testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return testCert{cert, key}
+}
+
+func (c testCert) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certFile, keyFile
+}
+
+func (c testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+// serveTLS serves a 200 handler with tlsConfig and returns its URL.
+func serveTLS(t *testing.T, tlsConfig *tls.Config) string {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), ErrorLog: slog.NewLogLogger(slog.DiscardHandler, slog.LevelError)}
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+
+	return "https://" + listener.Addr().String()
+}
+
+func clientFor(ca testCert, certs ...tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: certs}}}
+}
+
+func TestNewTLSConfig_Disabled(t *testing.T) {
+	tlsConfig, err := newTLSConfig(configparser.TLS{}, testLogger)
+
+	require.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+}
+
+func TestNewTLSConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := newTestCert(t, "server", false, nil).write(t, dir, "server")
+
+	for name, config := range map[string]configparser.TLS{
+		"key missing":         {CertFile: certFile},
+		"unknown client auth": {CertFile: certFile, KeyFile: keyFile, ClientAuth: "sometimes"},
+		"client CA missing":   {CertFile: certFile, KeyFile: keyFile, ClientAuth: ClientAuthRequire},
+		"unreadable key":      {CertFile: certFile, KeyFile: filepath.Join(dir, "missing.key")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newTLSConfig(config, testLogger)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewTLSConfig_ServesTLS(t *testing.T) {
+	server := newTestCert(t, "server", false, nil)
+	certFile, keyFile := server.write(t, t.TempDir(), "server")
+
+	tlsConfig, err := newTLSConfig(configparser.TLS{CertFile: certFile, KeyFile: keyFile}, testLogger)
+	require.NoError(t, err)
+
+	resp, err := clientFor(server).Get(serveTLS(t, tlsConfig))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestNewTLSConfig_RequiresClientCert(t *testing.T) {
+	dir := t.TempDir()
+	server := newTestCert(t, "server", false, nil)
+	certFile, keyFile := server.write(t, dir, "server")
+
+	ca := newTestCert(t, "partners", true, nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	partner := newTestCert(t, "partner", false, &ca)
+	stranger := newTestCert(t, "stranger", false, nil)
+
+	tlsConfig, err := newTLSConfig(configparser.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile}, testLogger)
+	require.NoError(t, err)
+	require.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+
+	url := serveTLS(t, tlsConfig)
+
+	resp, err := clientFor(server, partner.tlsCertificate()).Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = clientFor(server).Get(url)
+	assert.Error(t, err, "a client without a certificate must be refused")
+
+	_, err = clientFor(server, stranger.tlsCertificate()).Get(url)
+	assert.Error(t, err, "a certificate from another CA must be refused")
+}
+
+func TestCertReloader_PicksUpRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	first := newTestCert(t, "first", false, nil)
+	certFile, keyFile := first.write(t, dir, "server")
+
+	reloader, err := newCertReloader(certFile, keyFile, time.Nanosecond, testLogger)
+	require.NoError(t, err)
+
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.cert.Raw, cert.Certificate[0])
+
+	second := newTestCert(t, "second", false, nil)
+	second.write(t, dir, "server")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+
+	cert, err = reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, second.cert.Raw, cert.Certificate[0])
+}
+
+func TestCertReloader_KeepsCertificateOnBadRotation(t *testing.T) {
+	dir := t.TempDir()
+	first := newTestCert(t, "first", false, nil)
+	certFile, keyFile := first.write(t, dir, "server")
+
+	reloader, err := newCertReloader(certFile, keyFile, time.Nanosecond, testLogger)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0o600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.cert.Raw, cert.Certificate[0])
+}
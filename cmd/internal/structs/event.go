@@ -1,22 +1,91 @@
 package structs
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
 
+	"github.com/google/uuid"
+)
+
+// Events follow CloudEvents 1.0 in structured JSON mode. The version suffix of
+// the type changes whenever the data layout changes in a breaking way.
 const (
-	Created int = iota
-	Updated
-	Deleted
+	SpecVersion      = "1.0"
+	EventSource      = "/api/v1/companies"
+	JSONContentType  = "application/json"
+	ContentType      = "application/cloudevents+json; charset=UTF-8"
+	ChangeDataSchema = "urn:companies:schema:company-change:v1"
+	ErrorDataSchema  = "urn:companies:schema:company-error:v1"
 )
 
 const (
-	Success = iota
-	Failed
+	CompanyCreated       = "company.created.v1"
+	CompanyUpdated       = "company.updated.v1"
+	CompanyDeleted       = "company.deleted.v1"
+	CompanyRestored      = "company.restored.v1"
+	CompanyPurged        = "company.purged.v1"
+	CompanyCreateFailed  = "company.create_failed.v1"
+	CompanyUpdateFailed  = "company.update_failed.v1"
+	CompanyDeleteFailed  = "company.delete_failed.v1"
+	CompanyRestoreFailed = "company.restore_failed.v1"
 )
 
 type Event struct {
-	Type          int             `json:"type"`
-	Status        int             `json:"status"`
-	Data          json.RawMessage `json:"data,omitempty"`
-	ErrorMesssage string          `json:"errorMessage,omitempty"`
-	URL           string          `json:"url"`
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+
+	// Extension attributes, see the CloudEvents distributed tracing extension.
+	CorrelationID string `json:"correlationid,omitempty"`
+	TraceParent   string `json:"traceparent,omitempty"`
+	TraceState    string `json:"tracestate,omitempty"`
+}
+
+// ChangeData is the payload of successful change events. Before is nil for
+// created companies and After is nil for deleted ones.
+type ChangeData[T any] struct {
+	Before *T `json:"before"`
+	After  *T `json:"after"`
+}
+
+// ErrorData is the payload of failed change events.
+type ErrorData struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+func NewEvent(eventType, subject, dataSchema string, data any) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.NewString(),
+		Source:          EventSource,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: JSONContentType,
+		DataSchema:      dataSchema,
+		Data:            raw,
+	}, nil
+}
+
+func NewChangeEvent[T any](eventType, subject string, before, after *T) (Event, error) {
+	return NewEvent(eventType, subject, ChangeDataSchema, ChangeData[T]{Before: before, After: after})
+}
+
+// NewErrorEvent builds a failure event, subject is empty when the request
+// didn't identify a company.
+func NewErrorEvent(eventType, subject, url, message string) Event {
+	event, _ := NewEvent(eventType, subject, ErrorDataSchema, ErrorData{URL: url, Error: message})
+	return event
 }
@@ -0,0 +1,43 @@
+package structs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type company struct {
+	Name string `json:"name"`
+}
+
+func TestNewChangeEvent_CloudEventsEnvelope(t *testing.T) {
+	after := company{Name: "Acme"}
+
+	event, err := NewChangeEvent(CompanyCreated, "4b2c7d1e-0000-0000-0000-000000000000", nil, &after)
+	require.NoError(t, err)
+
+	var got map[string]any
+	raw, err := json.Marshal(event)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, &got))
+
+	assert.Equal(t, "1.0", got["specversion"])
+	assert.Equal(t, "company.created.v1", got["type"])
+	assert.Equal(t, EventSource, got["source"])
+	assert.Equal(t, "4b2c7d1e-0000-0000-0000-000000000000", got["subject"])
+	assert.Equal(t, ChangeDataSchema, got["dataschema"])
+	assert.NotEmpty(t, got["id"])
+	assert.NotEmpty(t, got["time"])
+	assert.Equal(t, map[string]any{"before": nil, "after": map[string]any{"name": "Acme"}}, got["data"])
+}
+
+func TestNewErrorEvent(t *testing.T) {
+	first := NewErrorEvent(CompanyDeleteFailed, "bad-id", "/api/v1/companies/bad-id", "invalid UUID length: 6")
+	second := NewErrorEvent(CompanyDeleteFailed, "bad-id", "/api/v1/companies/bad-id", "invalid UUID length: 6")
+
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Equal(t, ErrorDataSchema, first.DataSchema)
+	assert.JSONEq(t, `{"url":"/api/v1/companies/bad-id","error":"invalid UUID length: 6"}`, string(first.Data))
+}
@@ -0,0 +1,172 @@
+package tracing
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterNone   = "none"
+
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+
+	defaultServiceName = "companies"
+	shutdownTimeout    = 5 * time.Second
+)
+
+// propagator carries W3C trace context, it is used for HTTP requests and for
+// the traceparent of events alike.
+var propagator = propagation.TraceContext{}
+
+// Tracer returns a tracer of the global provider, so spans are dropped until
+// Init installs an exporter.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+type provider struct {
+	tp     *sdktrace.TracerProvider
+	closer io.Closer
+}
+
+// Close flushes the spans still buffered and stops the exporter.
+func (p *provider) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := p.tp.Shutdown(ctx)
+	if p.closer != nil {
+		err = errors.Join(err, p.closer.Close())
+	}
+
+	return err
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// Init installs the global tracer provider and propagator. With the none
+// exporter spans are not recorded but trace context is still propagated, the
+// same happens on error and the returned closer is usable either way.
+func Init(config configparser.Tracing, logger *slog.Logger) (io.Closer, error) {
+	otel.SetTextMapPropagator(propagator)
+
+	exporterName := configparser.GetCfgValue("TRACING_EXPORTER", config.Exporter)
+
+	var closer io.Closer
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch exporterName {
+	case ExporterNone, "":
+		return nopCloser{}, nil
+	case ExporterOTLP:
+		exporter, err = newOTLPExporter(config)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		var file *os.File
+		if file, err = openFile(configparser.GetCfgValue("TRACING_FILE_PATH", config.Path)); err == nil {
+			closer = file
+			exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+		}
+	default:
+		return nopCloser{}, fmt.Errorf("unknown tracing exporter %q", exporterName)
+	}
+	if err != nil {
+		return nopCloser{}, fmt.Errorf("failed to create %v exporter: %w", exporterName, err)
+	}
+
+	serviceName := configparser.GetCfgValue("TRACING_SERVICE_NAME", config.ServiceName)
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nopCloser{}, errors.Join(fmt.Errorf("failed to describe the service: %w", err), exporter.Shutdown(context.Background()))
+	}
+
+	ratio := configparser.GetCfgValue("TRACING_SAMPLE_RATIO", config.SampleRatio)
+	if ratio <= 0 {
+		ratio = 1
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	logger.Info("Exporting traces", slog.String("exporter", exporterName))
+
+	return &provider{tp: tp, closer: closer}, nil
+}
+
+func newOTLPExporter(config configparser.Tracing) (sdktrace.SpanExporter, error) {
+	var options []otlptracehttp.Option
+
+	if endpoint := configparser.GetCfgValue("TRACING_ENDPOINT", config.Endpoint); endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpoint(endpoint))
+	}
+	if configparser.GetCfgValue("TRACING_INSECURE", config.Insecure) {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	return otlptracehttp.New(context.Background(), options...)
+}
+
+func openFile(path string) (*os.File, error) {
+	if path == "" {
+		return nil, errors.New("file exporter requires a path")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+}
+
+// Inject returns the W3C traceparent and tracestate of the span in ctx, they
+// are empty if there is none.
+func Inject(ctx context.Context) (traceParent, traceState string) {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+
+	return carrier.Get(HeaderTraceParent), carrier.Get(HeaderTraceState)
+}
+
+// Extract returns ctx with the remote span described by traceParent and
+// traceState as its parent. An invalid traceParent leaves ctx unchanged.
+func Extract(ctx context.Context, traceParent, traceState string) context.Context {
+	return propagator.Extract(ctx, propagation.MapCarrier{
+		HeaderTraceParent: traceParent,
+		HeaderTraceState:  traceState,
+	})
+}
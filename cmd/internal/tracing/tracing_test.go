@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	return recorder
+}
+
+func TestInjectExtract(t *testing.T) {
+	ctx := Extract(context.Background(), testTraceParent, "vendor=1")
+
+	traceParent, traceState := Inject(ctx)
+	require.Equal(t, testTraceParent, traceParent)
+	require.Equal(t, "vendor=1", traceState)
+}
+
+func TestExtract_InvalidTraceParent(t *testing.T) {
+	ctx := Extract(context.Background(), "garbage", "")
+
+	require.False(t, trace.SpanContextFromContext(ctx).IsValid())
+
+	traceParent, _ := Inject(ctx)
+	require.Empty(t, traceParent)
+}
+
+func TestMiddleware_NamesSpanAfterRoute(t *testing.T) {
+	recorder := recordSpans(t)
+
+	router := chi.NewRouter()
+	router.Use(Middleware)
+	router.Get("/api/v1/companies/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/companies/42", nil)
+	req.Header.Set(HeaderTraceParent, testTraceParent)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	require.Equal(t, "GET /api/v1/companies/{id}", span.Name())
+	require.Equal(t, trace.SpanKindServer, span.SpanKind())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	require.Contains(t, span.Attributes(), semconv.HTTPRoute("/api/v1/companies/{id}"))
+	require.Contains(t, span.Attributes(), semconv.HTTPResponseStatusCode(http.StatusNotFound))
+	require.Equal(t, codes.Unset, span.Status().Code)
+}
+
+func TestMiddleware_ServerErrorMarksSpan(t *testing.T) {
+	recorder := recordSpans(t)
+
+	router := chi.NewRouter()
+	router.Use(Middleware)
+	router.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, trace.SpanFromContext(r.Context()).SpanContext().IsValid())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.False(t, spans[0].Parent().IsValid())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestInit_None(t *testing.T) {
+	closer, err := Init(configparser.Tracing{Exporter: ExporterNone}, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	require.NoError(t, closer.Close())
+}
+
+func TestInit_UnknownExporter(t *testing.T) {
+	closer, err := Init(configparser.Tracing{Exporter: "carrier-pigeon"}, slog.New(slog.DiscardHandler))
+	require.Error(t, err)
+	require.NoError(t, closer.Close())
+}
+
+func TestInit_FileExporter(t *testing.T) {
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	path := filepath.Join(t.TempDir(), "traces", "spans.ndjson")
+
+	closer, err := Init(configparser.Tracing{Exporter: ExporterFile, Path: path, ServiceName: "companies-test"}, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	_, span := Tracer("test").Start(context.Background(), "work")
+	span.End()
+
+	require.NoError(t, closer.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"Name":"work"`)
+	require.Contains(t, string(content), "companies-test")
+}
@@ -0,0 +1,333 @@
+package webhooks
+
+import (
+	"bytes"
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/consts"
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/logging"
+	"companies/cmd/internal/structs"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderEventID   = "X-Webhook-Id"
+
+	defaultWorkers              = 4
+	defaultQueueSize            = 1000
+	defaultTimeoutMs            = 5000
+	defaultMaxAttempts          = 5
+	defaultRetryBackoffMs       = 1000
+	defaultMaxRetryBackoffMs    = 60000
+	defaultDisableAfterFailures = 10
+)
+
+var (
+	ErrDispatcherClosed = errors.New("webhook dispatcher is closed")
+	ErrQueueFull        = errors.New("webhook queue is full")
+)
+
+// Sign returns the value of the signature header for a delivery. Receivers
+// recompute HMAC-SHA256 over "<timestamp>.<body>" with their secret and
+// compare it in constant time.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type job struct {
+	subscription database.WebhookSubscription
+	event        structs.Event
+	body         []byte
+	// result gets nil once the delivery succeeded or was given up on, and
+	// ErrDispatcherClosed when the dispatcher stopped before.
+	result chan<- error
+}
+
+// Dispatcher is an EventSender that fans change events out to the webhook
+// subscriptions stored in the database. Deliveries are retried with
+// exponential backoff and every attempt is recorded. PublishEvent returns
+// once every delivery of the event is finished, so the outbox relay keeps the
+// event pending until then.
+type Dispatcher struct {
+	store        database.WebhookStore
+	logger       *slog.Logger
+	client       *http.Client
+	workers      int
+	maxAttempts  int
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+	disableAfter int
+
+	mu        sync.Mutex
+	closed    bool
+	queue     chan job
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func positiveOr(value, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func NewDispatcher(config configparser.Webhooks, store database.WebhookStore, logger *slog.Logger) *Dispatcher {
+	timeoutMs := positiveOr(configparser.GetCfgValue("WEBHOOKS_TIMEOUT_MS", config.TimeoutMs), defaultTimeoutMs)
+
+	return &Dispatcher{
+		store:        store,
+		logger:       logger,
+		client:       &http.Client{Timeout: time.Duration(timeoutMs) * time.Millisecond},
+		workers:      positiveOr(configparser.GetCfgValue("WEBHOOKS_WORKERS", config.Workers), defaultWorkers),
+		maxAttempts:  positiveOr(configparser.GetCfgValue("WEBHOOKS_MAX_ATTEMPTS", config.MaxAttempts), defaultMaxAttempts),
+		retryBackoff: time.Duration(positiveOr(configparser.GetCfgValue("WEBHOOKS_RETRY_BACKOFF_MS", config.RetryBackoffMs), defaultRetryBackoffMs)) * time.Millisecond,
+		maxBackoff:   time.Duration(positiveOr(configparser.GetCfgValue("WEBHOOKS_MAX_RETRY_BACKOFF_MS", config.MaxRetryBackoffMs), defaultMaxRetryBackoffMs)) * time.Millisecond,
+		disableAfter: positiveOr(configparser.GetCfgValue("WEBHOOKS_DISABLE_AFTER_FAILURES", config.DisableAfterFailures), defaultDisableAfterFailures),
+		queue:        make(chan job, positiveOr(configparser.GetCfgValue("WEBHOOKS_QUEUE_SIZE", config.QueueSize), defaultQueueSize)),
+		stop:         make(chan struct{}),
+	}
+}
+
+func (d *Dispatcher) Start() {
+	d.logger.Info("Starting webhook dispatcher")
+
+	for range d.workers {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for j := range d.queue {
+				d.deliver(j)
+			}
+		}()
+	}
+}
+
+// PublishEvent queues a delivery for every enabled subscription interested in
+// the event type and waits until they are finished or ctx ends. When the
+// queue can't take all of them none is queued and ErrQueueFull is returned,
+// so a retry doesn't deliver twice to the subscriptions already queued.
+// Queued deliveries aren't cancelled with ctx.
+func (d *Dispatcher) PublishEvent(ctx context.Context, topic string, event structs.Event) error {
+	if topic != consts.DataChangedTopic {
+		return nil
+	}
+
+	subscriptions, err := d.store.ListEnabledWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var accepting []database.WebhookSubscription
+	for _, subscription := range subscriptions {
+		if subscription.Accepts(event.Type) {
+			accepting = append(accepting, subscription)
+		}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	results, err := d.enqueue(accepting, event, body)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for range accepting {
+		select {
+		case err := <-results:
+			errs = append(errs, err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// enqueue queues a job per subscription without blocking, all or none.
+func (d *Dispatcher) enqueue(subscriptions []database.WebhookSubscription, event structs.Event, body []byte) (<-chan error, error) {
+	// Only senders hold mu, workers just take jobs, so the room checked here
+	// can't shrink before the jobs are queued.
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil, ErrDispatcherClosed
+	}
+
+	if cap(d.queue)-len(d.queue) < len(subscriptions) {
+		return nil, ErrQueueFull
+	}
+
+	results := make(chan error, len(subscriptions))
+	for _, subscription := range subscriptions {
+		d.queue <- job{subscription: subscription, event: event, body: body, result: results}
+	}
+
+	return results, nil
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.retryBackoff << attempt
+	if delay <= 0 || delay > d.maxBackoff {
+		return d.maxBackoff
+	}
+	return delay
+}
+
+// wait sleeps through the backoff before the attempt and reports whether the
+// dispatcher is still running.
+func (d *Dispatcher) wait(attempt int) bool {
+	if attempt == 1 {
+		select {
+		case <-d.stop:
+			return false
+		default:
+			return true
+		}
+	}
+
+	select {
+	case <-d.stop:
+		return false
+	case <-time.After(d.backoff(attempt - 2)):
+		return true
+	}
+}
+
+func (d *Dispatcher) deliver(j job) {
+	j.result <- d.retry(j)
+}
+
+// retry attempts the delivery until it succeeds or maxAttempts are used up.
+// The subscription is read again before every attempt, so a disabled or
+// deleted subscription isn't delivered to anymore.
+func (d *Dispatcher) retry(j job) error {
+	id := *j.subscription.ID
+	logger := d.logger.With(slog.String(logging.KeyWebhookID, id.String()), slog.String("event_id", j.event.ID))
+
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		if !d.wait(attempt) {
+			logger.Warn("Webhook dispatcher stopped before delivering")
+			return ErrDispatcherClosed
+		}
+
+		subscription, err := d.store.GetWebhook(context.Background(), id)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			logger.Info("Webhook deleted, dropping delivery")
+			return nil
+		case err != nil:
+			logger.Error("Failed to reload webhook", logging.Err(err))
+			return err
+		case !subscription.Enabled:
+			logger.Info("Webhook disabled, dropping delivery")
+			return nil
+		}
+		j.subscription = subscription
+
+		delivery := d.attempt(j, attempt)
+		if err := d.store.RecordWebhookDelivery(context.Background(), delivery); err != nil {
+			logger.Error("Failed to record webhook delivery", logging.Err(err))
+		}
+
+		if delivery.Success {
+			if subscription.ConsecutiveFailures > 0 {
+				if err := d.store.MarkWebhookSucceeded(context.Background(), id); err != nil {
+					logger.Error("Failed to reset webhook failures", logging.Err(err))
+				}
+			}
+			return nil
+		}
+	}
+
+	logger.Warn("Webhook delivery failed", slog.Int("attempts", d.maxAttempts))
+
+	if err := d.store.MarkWebhookFailed(context.Background(), id, d.disableAfter); err != nil {
+		logger.Error("Failed to count webhook failure", logging.Err(err))
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) attempt(j job, attempt int) database.WebhookDelivery {
+	delivery := database.WebhookDelivery{
+		SubscriptionID: *j.subscription.ID,
+		EventID:        j.event.ID,
+		EventType:      j.event.Type,
+		Attempt:        attempt,
+	}
+
+	start := time.Now()
+
+	req, err := http.NewRequest(http.MethodPost, j.subscription.URL, bytes.NewReader(j.body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", structs.ContentType)
+	req.Header.Set(HeaderEventID, j.event.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(j.subscription.Secret, timestamp, j.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		delivery.Error = err.Error()
+		delivery.DurationMs = time.Since(start).Milliseconds()
+		return delivery
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	delivery.DurationMs = time.Since(start).Milliseconds()
+
+	if !delivery.Success {
+		delivery.Error = fmt.Sprintf("endpoint responded with %v", resp.Status)
+	}
+
+	return delivery
+}
+
+// Close stops accepting events, abandons pending retries and waits for the
+// in-flight deliveries to finish. The events of abandoned deliveries stay
+// pending in the outbox and are delivered again after a restart.
+func (d *Dispatcher) Close() error {
+	d.closeOnce.Do(func() {
+		d.mu.Lock()
+		d.closed = true
+		close(d.stop)
+		close(d.queue)
+		d.mu.Unlock()
+
+		d.wg.Wait()
+	})
+
+	return nil
+}
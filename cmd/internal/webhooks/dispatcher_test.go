@@ -0,0 +1,256 @@
+package webhooks
+
+import (
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/consts"
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/structs"
+	"companies/cmd/tests/mocks"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogger = slog.New(slog.DiscardHandler)
+
+var testConfig = configparser.Webhooks{
+	Workers:              1,
+	QueueSize:            10,
+	TimeoutMs:            1000,
+	MaxAttempts:          3,
+	RetryBackoffMs:       1,
+	MaxRetryBackoffMs:    5,
+	DisableAfterFailures: 4,
+}
+
+func newSubscription(url string, eventTypes ...string) database.WebhookSubscription {
+	id := uuid.New()
+	return database.WebhookSubscription{ID: &id, URL: url, EventTypes: eventTypes, Secret: "secret", Enabled: true}
+}
+
+func newEvent(t *testing.T) structs.Event {
+	event, err := structs.NewEvent(structs.CompanyCreated, uuid.NewString(), structs.ChangeDataSchema, map[string]string{})
+	require.NoError(t, err)
+	return event
+}
+
+func TestDispatcher_SignsDelivery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockWebhookStore(ctrl)
+
+	event := newEvent(t)
+
+	var verified atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		require.NoError(t, err)
+
+		verified.Store(r.Header.Get(HeaderSignature) == Sign("secret", timestamp, body) &&
+			r.Header.Get(HeaderEventID) == event.ID)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	subscription := newSubscription(srv.URL)
+
+	store.EXPECT().ListEnabledWebhooks(gomock.Any()).Return([]database.WebhookSubscription{subscription}, nil)
+	store.EXPECT().GetWebhook(gomock.Any(), *subscription.ID).Return(subscription, nil)
+	store.EXPECT().RecordWebhookDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, delivery database.WebhookDelivery) error {
+		require.True(t, delivery.Success)
+		require.Equal(t, http.StatusNoContent, delivery.StatusCode)
+		require.Equal(t, 1, delivery.Attempt)
+		require.Equal(t, *subscription.ID, delivery.SubscriptionID)
+		return nil
+	})
+
+	dispatcher := NewDispatcher(testConfig, store, testLogger)
+	dispatcher.Start()
+
+	require.NoError(t, dispatcher.PublishEvent(context.Background(), consts.DataChangedTopic, event))
+	require.NoError(t, dispatcher.Close())
+	require.True(t, verified.Load())
+}
+
+func TestDispatcher_RetriesAndMarksFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockWebhookStore(ctrl)
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	subscription := newSubscription(srv.URL)
+
+	store.EXPECT().ListEnabledWebhooks(gomock.Any()).Return([]database.WebhookSubscription{subscription}, nil)
+	store.EXPECT().GetWebhook(gomock.Any(), *subscription.ID).Return(subscription, nil).Times(testConfig.MaxAttempts)
+	store.EXPECT().RecordWebhookDelivery(gomock.Any(), gomock.Any()).Return(nil).Times(testConfig.MaxAttempts)
+	store.EXPECT().MarkWebhookFailed(gomock.Any(), *subscription.ID, testConfig.DisableAfterFailures).Return(nil)
+
+	dispatcher := NewDispatcher(testConfig, store, testLogger)
+	dispatcher.Start()
+
+	// Giving up finishes the delivery, so the outbox marks the event sent.
+	require.NoError(t, dispatcher.PublishEvent(context.Background(), consts.DataChangedTopic, newEvent(t)))
+	require.NoError(t, dispatcher.Close())
+	require.EqualValues(t, testConfig.MaxAttempts, calls.Load())
+}
+
+func TestDispatcher_RecoveryResetsFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockWebhookStore(ctrl)
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	subscription := newSubscription(srv.URL)
+	subscription.ConsecutiveFailures = 2
+
+	store.EXPECT().ListEnabledWebhooks(gomock.Any()).Return([]database.WebhookSubscription{subscription}, nil)
+	store.EXPECT().GetWebhook(gomock.Any(), *subscription.ID).Return(subscription, nil).Times(2)
+	store.EXPECT().RecordWebhookDelivery(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	store.EXPECT().MarkWebhookSucceeded(gomock.Any(), *subscription.ID).Return(nil)
+
+	dispatcher := NewDispatcher(testConfig, store, testLogger)
+	dispatcher.Start()
+
+	require.NoError(t, dispatcher.PublishEvent(context.Background(), consts.DataChangedTopic, newEvent(t)))
+	require.NoError(t, dispatcher.Close())
+}
+
+func TestDispatcher_FiltersEventTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockWebhookStore(ctrl)
+
+	subscription := newSubscription("http://127.0.0.1:0", structs.CompanyDeleted)
+
+	store.EXPECT().ListEnabledWebhooks(gomock.Any()).Return([]database.WebhookSubscription{subscription}, nil)
+
+	dispatcher := NewDispatcher(testConfig, store, testLogger)
+	dispatcher.Start()
+
+	require.NoError(t, dispatcher.PublishEvent(context.Background(), consts.DataChangedTopic, newEvent(t)))
+	require.NoError(t, dispatcher.PublishEvent(context.Background(), "other-topic", newEvent(t)))
+	require.NoError(t, dispatcher.Close())
+}
+
+func TestDispatcher_Closed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockWebhookStore(ctrl)
+
+	store.EXPECT().ListEnabledWebhooks(gomock.Any()).Return(nil, nil)
+
+	dispatcher := NewDispatcher(testConfig, store, testLogger)
+	dispatcher.Start()
+	require.NoError(t, dispatcher.Close())
+
+	require.ErrorIs(t, dispatcher.PublishEvent(context.Background(), consts.DataChangedTopic, newEvent(t)), ErrDispatcherClosed)
+}
+
+func TestDispatcher_SkipsDisabledSubscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockWebhookStore(ctrl)
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	subscription := newSubscription(srv.URL)
+	disabled := subscription
+	disabled.Enabled = false
+
+	store.EXPECT().ListEnabledWebhooks(gomock.Any()).Return([]database.WebhookSubscription{subscription}, nil)
+	gomock.InOrder(
+		store.EXPECT().GetWebhook(gomock.Any(), *subscription.ID).Return(subscription, nil),
+		store.EXPECT().GetWebhook(gomock.Any(), *subscription.ID).Return(disabled, nil),
+	)
+	store.EXPECT().RecordWebhookDelivery(gomock.Any(), gomock.Any()).Return(nil)
+
+	dispatcher := NewDispatcher(testConfig, store, testLogger)
+	dispatcher.Start()
+
+	require.NoError(t, dispatcher.PublishEvent(context.Background(), consts.DataChangedTopic, newEvent(t)))
+	require.NoError(t, dispatcher.Close())
+	require.EqualValues(t, 1, calls.Load())
+}
+
+func TestDispatcher_QueueFull(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockWebhookStore(ctrl)
+
+	config := testConfig
+	config.QueueSize = 1
+
+	store.EXPECT().ListEnabledWebhooks(gomock.Any()).Return([]database.WebhookSubscription{
+		newSubscription("http://127.0.0.1:0"),
+		newSubscription("http://127.0.0.1:0"),
+	}, nil)
+
+	// Not started, nothing may be queued.
+	dispatcher := NewDispatcher(config, store, testLogger)
+
+	require.ErrorIs(t, dispatcher.PublishEvent(context.Background(), consts.DataChangedTopic, newEvent(t)), ErrQueueFull)
+	require.Zero(t, len(dispatcher.queue))
+	require.NoError(t, dispatcher.Close())
+}
+
+func TestDispatcher_CloseAbandonsRetries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockWebhookStore(ctrl)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	config := testConfig
+	config.RetryBackoffMs = int(time.Hour.Milliseconds())
+	config.MaxRetryBackoffMs = int(time.Hour.Milliseconds())
+
+	subscription := newSubscription(srv.URL)
+	attempted := make(chan struct{})
+
+	store.EXPECT().ListEnabledWebhooks(gomock.Any()).Return([]database.WebhookSubscription{subscription}, nil)
+	store.EXPECT().GetWebhook(gomock.Any(), *subscription.ID).Return(subscription, nil)
+	store.EXPECT().RecordWebhookDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, database.WebhookDelivery) error {
+		close(attempted)
+		return nil
+	})
+
+	dispatcher := NewDispatcher(config, store, testLogger)
+	dispatcher.Start()
+
+	published := make(chan error, 1)
+	go func() {
+		published <- dispatcher.PublishEvent(context.Background(), consts.DataChangedTopic, newEvent(t))
+	}()
+
+	<-attempted
+	require.NoError(t, dispatcher.Close())
+
+	// The event must stay pending in the outbox.
+	require.ErrorIs(t, <-published, ErrDispatcherClosed)
+}
@@ -1,5 +1,7 @@
 package main
 
+import "os"
+
 // @title Company API
 // @version 1.0
 // @description REST API for managing companies
@@ -9,6 +11,18 @@ package main
 // @name Authorization
 // @BasePath /
 func main() {
-	app := NewApp("./cmd/cfg/config.yml")
-	app.Run()
+	const configPath = "./cmd/cfg/config.yml"
+
+	if len(os.Args) > 1 && os.Args[1] == "user" {
+		os.Exit(runUserCLI(configPath, os.Args[2:]))
+	}
+
+	app, err := NewApp(configPath)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	if err := app.Run(); err != nil {
+		os.Exit(1)
+	}
 }
@@ -6,6 +6,7 @@ package mocks
 
 import (
 	database "companies/cmd/internal/database"
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -36,30 +37,30 @@ func (m *MockcreateRecordDB) EXPECT() *MockcreateRecordDBMockRecorder {
 }
 
 // CreateRecord mocks base method.
-func (m *MockcreateRecordDB) CreateRecord(arg0 database.CompanyInfo) (uuid.UUID, error) {
+func (m *MockcreateRecordDB) CreateRecord(arg0 context.Context, arg1 database.CompanyInfo) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateRecord", arg0)
+	ret := m.ctrl.Call(m, "CreateRecord", arg0, arg1)
 	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateRecord indicates an expected call of CreateRecord.
-func (mr *MockcreateRecordDBMockRecorder) CreateRecord(arg0 interface{}) *gomock.Call {
+func (mr *MockcreateRecordDBMockRecorder) CreateRecord(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecord", reflect.TypeOf((*MockcreateRecordDB)(nil).CreateRecord), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecord", reflect.TypeOf((*MockcreateRecordDB)(nil).CreateRecord), arg0, arg1)
 }
 
 // IsRecordExists mocks base method.
-func (m *MockcreateRecordDB) IsRecordExists(arg0 string) bool {
+func (m *MockcreateRecordDB) IsRecordExists(arg0 context.Context, arg1 string) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsRecordExists", arg0)
+	ret := m.ctrl.Call(m, "IsRecordExists", arg0, arg1)
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
 // IsRecordExists indicates an expected call of IsRecordExists.
-func (mr *MockcreateRecordDBMockRecorder) IsRecordExists(arg0 interface{}) *gomock.Call {
+func (mr *MockcreateRecordDBMockRecorder) IsRecordExists(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRecordExists", reflect.TypeOf((*MockcreateRecordDB)(nil).IsRecordExists), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRecordExists", reflect.TypeOf((*MockcreateRecordDB)(nil).IsRecordExists), arg0, arg1)
 }
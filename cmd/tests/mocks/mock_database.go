@@ -6,12 +6,53 @@ package mocks
 
 import (
 	database "companies/cmd/internal/database"
+	metrics "companies/cmd/internal/metrics"
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
 )
 
+// MockSearcher is a mock of Searcher interface.
+type MockSearcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockSearcherMockRecorder
+}
+
+// MockSearcherMockRecorder is the mock recorder for MockSearcher.
+type MockSearcherMockRecorder struct {
+	mock *MockSearcher
+}
+
+// NewMockSearcher creates a new mock instance.
+func NewMockSearcher(ctrl *gomock.Controller) *MockSearcher {
+	mock := &MockSearcher{ctrl: ctrl}
+	mock.recorder = &MockSearcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSearcher) EXPECT() *MockSearcherMockRecorder {
+	return m.recorder
+}
+
+// Search mocks base method.
+func (m *MockSearcher) Search(ctx context.Context, query string, limit int) ([]database.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query, limit)
+	ret0, _ := ret[0].([]database.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockSearcherMockRecorder) Search(ctx, query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockSearcher)(nil).Search), ctx, query, limit)
+}
+
 // MockDatabase is a mock of Database interface.
 type MockDatabase struct {
 	ctrl     *gomock.Controller
@@ -35,183 +76,1529 @@ func (m *MockDatabase) EXPECT() *MockDatabaseMockRecorder {
 	return m.recorder
 }
 
+// CountCompanies mocks base method.
+func (m *MockDatabase) CountCompanies(arg0 context.Context) ([]metrics.CompanyCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountCompanies", arg0)
+	ret0, _ := ret[0].([]metrics.CompanyCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountCompanies indicates an expected call of CountCompanies.
+func (mr *MockDatabaseMockRecorder) CountCompanies(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountCompanies", reflect.TypeOf((*MockDatabase)(nil).CountCompanies), arg0)
+}
+
 // CreateRecord mocks base method.
-func (m *MockDatabase) CreateRecord(arg0 database.CompanyInfo) (uuid.UUID, error) {
+func (m *MockDatabase) CreateRecord(arg0 context.Context, arg1 database.CompanyInfo) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateRecord", arg0)
+	ret := m.ctrl.Call(m, "CreateRecord", arg0, arg1)
 	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateRecord indicates an expected call of CreateRecord.
-func (mr *MockDatabaseMockRecorder) CreateRecord(arg0 interface{}) *gomock.Call {
+func (mr *MockDatabaseMockRecorder) CreateRecord(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecord", reflect.TypeOf((*MockDatabase)(nil).CreateRecord), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecord", reflect.TypeOf((*MockDatabase)(nil).CreateRecord), arg0, arg1)
 }
 
 // DeleteRecord mocks base method.
-func (m *MockDatabase) DeleteRecord(arg0 uuid.UUID) error {
+func (m *MockDatabase) DeleteRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteRecord", arg0)
+	ret := m.ctrl.Call(m, "DeleteRecord", ctx, id, ifMatch)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteRecord indicates an expected call of DeleteRecord.
-func (mr *MockDatabaseMockRecorder) DeleteRecord(arg0 interface{}) *gomock.Call {
+func (mr *MockDatabaseMockRecorder) DeleteRecord(ctx, id, ifMatch interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecord", reflect.TypeOf((*MockDatabase)(nil).DeleteRecord), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecord", reflect.TypeOf((*MockDatabase)(nil).DeleteRecord), ctx, id, ifMatch)
 }
 
 // GetRecord mocks base method.
-func (m *MockDatabase) GetRecord(arg0 uuid.UUID) (database.CompanyInfo, error) {
+func (m *MockDatabase) GetRecord(ctx context.Context, id uuid.UUID, includeDeleted bool) (database.CompanyInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRecord", arg0)
+	ret := m.ctrl.Call(m, "GetRecord", ctx, id, includeDeleted)
 	ret0, _ := ret[0].(database.CompanyInfo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetRecord indicates an expected call of GetRecord.
-func (mr *MockDatabaseMockRecorder) GetRecord(arg0 interface{}) *gomock.Call {
+func (mr *MockDatabaseMockRecorder) GetRecord(ctx, id, includeDeleted interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecord", reflect.TypeOf((*MockDatabase)(nil).GetRecord), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecord", reflect.TypeOf((*MockDatabase)(nil).GetRecord), ctx, id, includeDeleted)
 }
 
 // IsRecordExists mocks base method.
-func (m *MockDatabase) IsRecordExists(arg0 string) bool {
+func (m *MockDatabase) IsRecordExists(arg0 context.Context, arg1 string) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsRecordExists", arg0)
+	ret := m.ctrl.Call(m, "IsRecordExists", arg0, arg1)
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
 // IsRecordExists indicates an expected call of IsRecordExists.
-func (mr *MockDatabaseMockRecorder) IsRecordExists(arg0 interface{}) *gomock.Call {
+func (mr *MockDatabaseMockRecorder) IsRecordExists(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRecordExists", reflect.TypeOf((*MockDatabase)(nil).IsRecordExists), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRecordExists", reflect.TypeOf((*MockDatabase)(nil).IsRecordExists), arg0, arg1)
 }
 
-// UpdateRecord mocks base method.
-func (m *MockDatabase) UpdateRecord(arg0 database.CompanyInfo, arg1 uuid.UUID) error {
+// ListRecords mocks base method.
+func (m *MockDatabase) ListRecords(arg0 context.Context, arg1 database.ListQuery) (database.ListPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRecords", arg0, arg1)
+	ret0, _ := ret[0].(database.ListPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRecords indicates an expected call of ListRecords.
+func (mr *MockDatabaseMockRecorder) ListRecords(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRecords", reflect.TypeOf((*MockDatabase)(nil).ListRecords), arg0, arg1)
+}
+
+// PurgeRecord mocks base method.
+func (m *MockDatabase) PurgeRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateRecord", arg0, arg1)
+	ret := m.ctrl.Call(m, "PurgeRecord", ctx, id, ifMatch)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// PurgeRecord indicates an expected call of PurgeRecord.
+func (mr *MockDatabaseMockRecorder) PurgeRecord(ctx, id, ifMatch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeRecord", reflect.TypeOf((*MockDatabase)(nil).PurgeRecord), ctx, id, ifMatch)
+}
+
+// RestoreRecord mocks base method.
+func (m *MockDatabase) RestoreRecord(arg0 context.Context, arg1 uuid.UUID) (database.CompanyInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreRecord", arg0, arg1)
+	ret0, _ := ret[0].(database.CompanyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreRecord indicates an expected call of RestoreRecord.
+func (mr *MockDatabaseMockRecorder) RestoreRecord(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRecord", reflect.TypeOf((*MockDatabase)(nil).RestoreRecord), arg0, arg1)
+}
+
+// Search mocks base method.
+func (m *MockDatabase) Search(ctx context.Context, query string, limit int) ([]database.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query, limit)
+	ret0, _ := ret[0].([]database.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockDatabaseMockRecorder) Search(ctx, query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockDatabase)(nil).Search), ctx, query, limit)
+}
+
+// UpdateRecord mocks base method.
+func (m *MockDatabase) UpdateRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64, update database.UpdateFunc) (database.CompanyInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRecord", ctx, id, ifMatch, update)
+	ret0, _ := ret[0].(database.CompanyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // UpdateRecord indicates an expected call of UpdateRecord.
-func (mr *MockDatabaseMockRecorder) UpdateRecord(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockDatabaseMockRecorder) UpdateRecord(ctx, id, ifMatch, update interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecord", reflect.TypeOf((*MockDatabase)(nil).UpdateRecord), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecord", reflect.TypeOf((*MockDatabase)(nil).UpdateRecord), ctx, id, ifMatch, update)
 }
 
-// MockStorage is a mock of Storage interface.
-type MockStorage struct {
+// MockOutboxStore is a mock of OutboxStore interface.
+type MockOutboxStore struct {
 	ctrl     *gomock.Controller
-	recorder *MockStorageMockRecorder
+	recorder *MockOutboxStoreMockRecorder
 }
 
-// MockStorageMockRecorder is the mock recorder for MockStorage.
-type MockStorageMockRecorder struct {
-	mock *MockStorage
+// MockOutboxStoreMockRecorder is the mock recorder for MockOutboxStore.
+type MockOutboxStoreMockRecorder struct {
+	mock *MockOutboxStore
 }
 
-// NewMockStorage creates a new mock instance.
-func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
-	mock := &MockStorage{ctrl: ctrl}
-	mock.recorder = &MockStorageMockRecorder{mock}
+// NewMockOutboxStore creates a new mock instance.
+func NewMockOutboxStore(ctrl *gomock.Controller) *MockOutboxStore {
+	mock := &MockOutboxStore{ctrl: ctrl}
+	mock.recorder = &MockOutboxStoreMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
+func (m *MockOutboxStore) EXPECT() *MockOutboxStoreMockRecorder {
 	return m.recorder
 }
 
-// Close mocks base method.
-func (m *MockStorage) Close() error {
+// ClaimOutbox mocks base method.
+func (m *MockOutboxStore) ClaimOutbox(ctx context.Context, sink string, limit int, lease time.Duration) ([]database.OutboxMessage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Close")
+	ret := m.ctrl.Call(m, "ClaimOutbox", ctx, sink, limit, lease)
+	ret0, _ := ret[0].([]database.OutboxMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimOutbox indicates an expected call of ClaimOutbox.
+func (mr *MockOutboxStoreMockRecorder) ClaimOutbox(ctx, sink, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimOutbox", reflect.TypeOf((*MockOutboxStore)(nil).ClaimOutbox), ctx, sink, limit, lease)
+}
+
+// MarkOutboxFailed mocks base method.
+func (m *MockOutboxStore) MarkOutboxFailed(ctx context.Context, id uint64, cause error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxFailed", ctx, id, cause)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Close indicates an expected call of Close.
-func (mr *MockStorageMockRecorder) Close() *gomock.Call {
+// MarkOutboxFailed indicates an expected call of MarkOutboxFailed.
+func (mr *MockOutboxStoreMockRecorder) MarkOutboxFailed(ctx, id, cause interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxFailed", reflect.TypeOf((*MockOutboxStore)(nil).MarkOutboxFailed), ctx, id, cause)
 }
 
-// CreateRecord mocks base method.
-func (m *MockStorage) CreateRecord(arg0 database.CompanyInfo) (uuid.UUID, error) {
+// MarkOutboxSent mocks base method.
+func (m *MockOutboxStore) MarkOutboxSent(ctx context.Context, id uint64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateRecord", arg0)
-	ret0, _ := ret[0].(uuid.UUID)
+	ret := m.ctrl.Call(m, "MarkOutboxSent", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxSent indicates an expected call of MarkOutboxSent.
+func (mr *MockOutboxStoreMockRecorder) MarkOutboxSent(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxSent", reflect.TypeOf((*MockOutboxStore)(nil).MarkOutboxSent), ctx, id)
+}
+
+// OutboxBacklog mocks base method.
+func (m *MockOutboxStore) OutboxBacklog(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OutboxBacklog", arg0)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateRecord indicates an expected call of CreateRecord.
-func (mr *MockStorageMockRecorder) CreateRecord(arg0 interface{}) *gomock.Call {
+// OutboxBacklog indicates an expected call of OutboxBacklog.
+func (mr *MockOutboxStoreMockRecorder) OutboxBacklog(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecord", reflect.TypeOf((*MockStorage)(nil).CreateRecord), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutboxBacklog", reflect.TypeOf((*MockOutboxStore)(nil).OutboxBacklog), arg0)
 }
 
-// DeleteRecord mocks base method.
-func (m *MockStorage) DeleteRecord(arg0 uuid.UUID) error {
+// ParkOutbox mocks base method.
+func (m *MockOutboxStore) ParkOutbox(ctx context.Context, id uint64, cause error) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteRecord", arg0)
+	ret := m.ctrl.Call(m, "ParkOutbox", ctx, id, cause)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteRecord indicates an expected call of DeleteRecord.
-func (mr *MockStorageMockRecorder) DeleteRecord(arg0 interface{}) *gomock.Call {
+// ParkOutbox indicates an expected call of ParkOutbox.
+func (mr *MockOutboxStoreMockRecorder) ParkOutbox(ctx, id, cause interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecord", reflect.TypeOf((*MockStorage)(nil).DeleteRecord), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParkOutbox", reflect.TypeOf((*MockOutboxStore)(nil).ParkOutbox), ctx, id, cause)
 }
 
-// GetRecord mocks base method.
-func (m *MockStorage) GetRecord(arg0 uuid.UUID) (database.CompanyInfo, error) {
+// PruneOutbox mocks base method.
+func (m *MockOutboxStore) PruneOutbox(ctx context.Context, sentBefore time.Time, limit int) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRecord", arg0)
-	ret0, _ := ret[0].(database.CompanyInfo)
+	ret := m.ctrl.Call(m, "PruneOutbox", ctx, sentBefore, limit)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetRecord indicates an expected call of GetRecord.
-func (mr *MockStorageMockRecorder) GetRecord(arg0 interface{}) *gomock.Call {
+// PruneOutbox indicates an expected call of PruneOutbox.
+func (mr *MockOutboxStoreMockRecorder) PruneOutbox(ctx, sentBefore, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecord", reflect.TypeOf((*MockStorage)(nil).GetRecord), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneOutbox", reflect.TypeOf((*MockOutboxStore)(nil).PruneOutbox), ctx, sentBefore, limit)
 }
 
-// IsRecordExists mocks base method.
-func (m *MockStorage) IsRecordExists(arg0 string) bool {
+// ReleaseOutbox mocks base method.
+func (m *MockOutboxStore) ReleaseOutbox(ctx context.Context, ids []uint64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsRecordExists", arg0)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "ReleaseOutbox", ctx, ids)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// IsRecordExists indicates an expected call of IsRecordExists.
-func (mr *MockStorageMockRecorder) IsRecordExists(arg0 interface{}) *gomock.Call {
+// ReleaseOutbox indicates an expected call of ReleaseOutbox.
+func (mr *MockOutboxStoreMockRecorder) ReleaseOutbox(ctx, ids interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRecordExists", reflect.TypeOf((*MockStorage)(nil).IsRecordExists), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseOutbox", reflect.TypeOf((*MockOutboxStore)(nil).ReleaseOutbox), ctx, ids)
 }
 
-// UpdateRecord mocks base method.
-func (m *MockStorage) UpdateRecord(arg0 database.CompanyInfo, arg1 uuid.UUID) error {
+// MockWebhookStore is a mock of WebhookStore interface.
+type MockWebhookStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookStoreMockRecorder
+}
+
+// MockWebhookStoreMockRecorder is the mock recorder for MockWebhookStore.
+type MockWebhookStoreMockRecorder struct {
+	mock *MockWebhookStore
+}
+
+// NewMockWebhookStore creates a new mock instance.
+func NewMockWebhookStore(ctrl *gomock.Controller) *MockWebhookStore {
+	mock := &MockWebhookStore{ctrl: ctrl}
+	mock.recorder = &MockWebhookStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookStore) EXPECT() *MockWebhookStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateWebhook mocks base method.
+func (m *MockWebhookStore) CreateWebhook(arg0 context.Context, arg1 database.WebhookSubscription) (database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockWebhookStoreMockRecorder) CreateWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockWebhookStore)(nil).CreateWebhook), arg0, arg1)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockWebhookStore) DeleteWebhook(arg0 context.Context, arg1 uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateRecord", arg0, arg1)
+	ret := m.ctrl.Call(m, "DeleteWebhook", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateRecord indicates an expected call of UpdateRecord.
-func (mr *MockStorageMockRecorder) UpdateRecord(arg0, arg1 interface{}) *gomock.Call {
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockWebhookStoreMockRecorder) DeleteWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockWebhookStore)(nil).DeleteWebhook), arg0, arg1)
+}
+
+// GetWebhook mocks base method.
+func (m *MockWebhookStore) GetWebhook(arg0 context.Context, arg1 uuid.UUID) (database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", arg0, arg1)
+	ret0, _ := ret[0].(database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockWebhookStoreMockRecorder) GetWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockWebhookStore)(nil).GetWebhook), arg0, arg1)
+}
+
+// ListEnabledWebhooks mocks base method.
+func (m *MockWebhookStore) ListEnabledWebhooks(arg0 context.Context) ([]database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEnabledWebhooks", arg0)
+	ret0, _ := ret[0].([]database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEnabledWebhooks indicates an expected call of ListEnabledWebhooks.
+func (mr *MockWebhookStoreMockRecorder) ListEnabledWebhooks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEnabledWebhooks", reflect.TypeOf((*MockWebhookStore)(nil).ListEnabledWebhooks), arg0)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockWebhookStore) ListWebhookDeliveries(ctx context.Context, id uuid.UUID, limit int) ([]database.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, id, limit)
+	ret0, _ := ret[0].([]database.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockWebhookStoreMockRecorder) ListWebhookDeliveries(ctx, id, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockWebhookStore)(nil).ListWebhookDeliveries), ctx, id, limit)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockWebhookStore) ListWebhooks(arg0 context.Context) ([]database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks", arg0)
+	ret0, _ := ret[0].([]database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockWebhookStoreMockRecorder) ListWebhooks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockWebhookStore)(nil).ListWebhooks), arg0)
+}
+
+// MarkWebhookFailed mocks base method.
+func (m *MockWebhookStore) MarkWebhookFailed(ctx context.Context, id uuid.UUID, disableAfter int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookFailed", ctx, id, disableAfter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWebhookFailed indicates an expected call of MarkWebhookFailed.
+func (mr *MockWebhookStoreMockRecorder) MarkWebhookFailed(ctx, id, disableAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookFailed", reflect.TypeOf((*MockWebhookStore)(nil).MarkWebhookFailed), ctx, id, disableAfter)
+}
+
+// MarkWebhookSucceeded mocks base method.
+func (m *MockWebhookStore) MarkWebhookSucceeded(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookSucceeded", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWebhookSucceeded indicates an expected call of MarkWebhookSucceeded.
+func (mr *MockWebhookStoreMockRecorder) MarkWebhookSucceeded(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookSucceeded", reflect.TypeOf((*MockWebhookStore)(nil).MarkWebhookSucceeded), arg0, arg1)
+}
+
+// RecordWebhookDelivery mocks base method.
+func (m *MockWebhookStore) RecordWebhookDelivery(arg0 context.Context, arg1 database.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordWebhookDelivery indicates an expected call of RecordWebhookDelivery.
+func (mr *MockWebhookStoreMockRecorder) RecordWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWebhookDelivery", reflect.TypeOf((*MockWebhookStore)(nil).RecordWebhookDelivery), arg0, arg1)
+}
+
+// UpdateWebhook mocks base method.
+func (m *MockWebhookStore) UpdateWebhook(arg0 context.Context, arg1 uuid.UUID, arg2 database.WebhookUpdate) (database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhook", arg0, arg1, arg2)
+	ret0, _ := ret[0].(database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook.
+func (mr *MockWebhookStoreMockRecorder) UpdateWebhook(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhook", reflect.TypeOf((*MockWebhookStore)(nil).UpdateWebhook), arg0, arg1, arg2)
+}
+
+// MockUserStore is a mock of UserStore interface.
+type MockUserStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserStoreMockRecorder
+}
+
+// MockUserStoreMockRecorder is the mock recorder for MockUserStore.
+type MockUserStoreMockRecorder struct {
+	mock *MockUserStore
+}
+
+// NewMockUserStore creates a new mock instance.
+func NewMockUserStore(ctrl *gomock.Controller) *MockUserStore {
+	mock := &MockUserStore{ctrl: ctrl}
+	mock.recorder = &MockUserStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserStore) EXPECT() *MockUserStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateUser mocks base method.
+func (m *MockUserStore) CreateUser(arg0 context.Context, arg1 database.User) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockUserStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserStore)(nil).CreateUser), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockUserStore) GetUser(ctx context.Context, username string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUserStoreMockRecorder) GetUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUserStore)(nil).GetUser), ctx, username)
+}
+
+// ListUsers mocks base method.
+func (m *MockUserStore) ListUsers(arg0 context.Context) ([]database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0)
+	ret0, _ := ret[0].([]database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockUserStoreMockRecorder) ListUsers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserStore)(nil).ListUsers), arg0)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockUserStore) RecordLoginFailure(ctx context.Context, username string, maxFailures int, lockout time.Duration) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, username, maxFailures, lockout)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockUserStoreMockRecorder) RecordLoginFailure(ctx, username, maxFailures, lockout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockUserStore)(nil).RecordLoginFailure), ctx, username, maxFailures, lockout)
+}
+
+// RecordLoginSuccess mocks base method.
+func (m *MockUserStore) RecordLoginSuccess(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginSuccess", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordLoginSuccess indicates an expected call of RecordLoginSuccess.
+func (mr *MockUserStoreMockRecorder) RecordLoginSuccess(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginSuccess", reflect.TypeOf((*MockUserStore)(nil).RecordLoginSuccess), ctx, username)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockUserStore) SetUserDisabled(ctx context.Context, username string, disabled bool) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, username, disabled)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockUserStoreMockRecorder) SetUserDisabled(ctx, username, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockUserStore)(nil).SetUserDisabled), ctx, username, disabled)
+}
+
+// SetUserPassword mocks base method.
+func (m *MockUserStore) SetUserPassword(ctx context.Context, username, passwordHash string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPassword", ctx, username, passwordHash)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserPassword indicates an expected call of SetUserPassword.
+func (mr *MockUserStoreMockRecorder) SetUserPassword(ctx, username, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPassword", reflect.TypeOf((*MockUserStore)(nil).SetUserPassword), ctx, username, passwordHash)
+}
+
+// SetUserRoles mocks base method.
+func (m *MockUserStore) SetUserRoles(ctx context.Context, username string, roles []string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRoles", ctx, username, roles)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockUserStoreMockRecorder) SetUserRoles(ctx, username, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockUserStore)(nil).SetUserRoles), ctx, username, roles)
+}
+
+// MockTokenStore is a mock of TokenStore interface.
+type MockTokenStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenStoreMockRecorder
+}
+
+// MockTokenStoreMockRecorder is the mock recorder for MockTokenStore.
+type MockTokenStoreMockRecorder struct {
+	mock *MockTokenStore
+}
+
+// NewMockTokenStore creates a new mock instance.
+func NewMockTokenStore(ctrl *gomock.Controller) *MockTokenStore {
+	mock := &MockTokenStore{ctrl: ctrl}
+	mock.recorder = &MockTokenStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenStore) EXPECT() *MockTokenStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockTokenStore) CreateRefreshToken(arg0 context.Context, arg1 database.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockTokenStoreMockRecorder) CreateRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockTokenStore)(nil).CreateRefreshToken), arg0, arg1)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockTokenStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", ctx, jti)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockTokenStoreMockRecorder) IsTokenRevoked(ctx, jti interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockTokenStore)(nil).IsTokenRevoked), ctx, jti)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *MockTokenStore) RevokeRefreshToken(ctx context.Context, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", ctx, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MockTokenStoreMockRecorder) RevokeRefreshToken(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MockTokenStore)(nil).RevokeRefreshToken), ctx, hash)
+}
+
+// RevokeToken mocks base method.
+func (m *MockTokenStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, jti, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockTokenStoreMockRecorder) RevokeToken(ctx, jti, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockTokenStore)(nil).RevokeToken), ctx, jti, expiresAt)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockTokenStore) RotateRefreshToken(ctx context.Context, hash, nextHash string, expiresAt time.Time) (database.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, hash, nextHash, expiresAt)
+	ret0, _ := ret[0].(database.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockTokenStoreMockRecorder) RotateRefreshToken(ctx, hash, nextHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockTokenStore)(nil).RotateRefreshToken), ctx, hash, nextHash, expiresAt)
+}
+
+// MockAccountStore is a mock of AccountStore interface.
+type MockAccountStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountStoreMockRecorder
+}
+
+// MockAccountStoreMockRecorder is the mock recorder for MockAccountStore.
+type MockAccountStoreMockRecorder struct {
+	mock *MockAccountStore
+}
+
+// NewMockAccountStore creates a new mock instance.
+func NewMockAccountStore(ctrl *gomock.Controller) *MockAccountStore {
+	mock := &MockAccountStore{ctrl: ctrl}
+	mock.recorder = &MockAccountStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountStore) EXPECT() *MockAccountStoreMockRecorder {
+	return m.recorder
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockAccountStore) CreateRefreshToken(arg0 context.Context, arg1 database.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockAccountStoreMockRecorder) CreateRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockAccountStore)(nil).CreateRefreshToken), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockAccountStore) CreateUser(arg0 context.Context, arg1 database.User) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockAccountStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAccountStore)(nil).CreateUser), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockAccountStore) GetUser(ctx context.Context, username string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockAccountStoreMockRecorder) GetUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAccountStore)(nil).GetUser), ctx, username)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockAccountStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", ctx, jti)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockAccountStoreMockRecorder) IsTokenRevoked(ctx, jti interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockAccountStore)(nil).IsTokenRevoked), ctx, jti)
+}
+
+// ListUsers mocks base method.
+func (m *MockAccountStore) ListUsers(arg0 context.Context) ([]database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0)
+	ret0, _ := ret[0].([]database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockAccountStoreMockRecorder) ListUsers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockAccountStore)(nil).ListUsers), arg0)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockAccountStore) RecordLoginFailure(ctx context.Context, username string, maxFailures int, lockout time.Duration) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, username, maxFailures, lockout)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockAccountStoreMockRecorder) RecordLoginFailure(ctx, username, maxFailures, lockout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockAccountStore)(nil).RecordLoginFailure), ctx, username, maxFailures, lockout)
+}
+
+// RecordLoginSuccess mocks base method.
+func (m *MockAccountStore) RecordLoginSuccess(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginSuccess", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordLoginSuccess indicates an expected call of RecordLoginSuccess.
+func (mr *MockAccountStoreMockRecorder) RecordLoginSuccess(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginSuccess", reflect.TypeOf((*MockAccountStore)(nil).RecordLoginSuccess), ctx, username)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *MockAccountStore) RevokeRefreshToken(ctx context.Context, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", ctx, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MockAccountStoreMockRecorder) RevokeRefreshToken(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MockAccountStore)(nil).RevokeRefreshToken), ctx, hash)
+}
+
+// RevokeToken mocks base method.
+func (m *MockAccountStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, jti, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockAccountStoreMockRecorder) RevokeToken(ctx, jti, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockAccountStore)(nil).RevokeToken), ctx, jti, expiresAt)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockAccountStore) RotateRefreshToken(ctx context.Context, hash, nextHash string, expiresAt time.Time) (database.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, hash, nextHash, expiresAt)
+	ret0, _ := ret[0].(database.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockAccountStoreMockRecorder) RotateRefreshToken(ctx, hash, nextHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockAccountStore)(nil).RotateRefreshToken), ctx, hash, nextHash, expiresAt)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockAccountStore) SetUserDisabled(ctx context.Context, username string, disabled bool) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, username, disabled)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockAccountStoreMockRecorder) SetUserDisabled(ctx, username, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockAccountStore)(nil).SetUserDisabled), ctx, username, disabled)
+}
+
+// SetUserPassword mocks base method.
+func (m *MockAccountStore) SetUserPassword(ctx context.Context, username, passwordHash string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPassword", ctx, username, passwordHash)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserPassword indicates an expected call of SetUserPassword.
+func (mr *MockAccountStoreMockRecorder) SetUserPassword(ctx, username, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPassword", reflect.TypeOf((*MockAccountStore)(nil).SetUserPassword), ctx, username, passwordHash)
+}
+
+// SetUserRoles mocks base method.
+func (m *MockAccountStore) SetUserRoles(ctx context.Context, username string, roles []string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRoles", ctx, username, roles)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockAccountStoreMockRecorder) SetUserRoles(ctx, username, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockAccountStore)(nil).SetUserRoles), ctx, username, roles)
+}
+
+// MockStorage is a mock of Storage interface.
+type MockStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageMockRecorder
+}
+
+// MockStorageMockRecorder is the mock recorder for MockStorage.
+type MockStorageMockRecorder struct {
+	mock *MockStorage
+}
+
+// NewMockStorage creates a new mock instance.
+func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
+	mock := &MockStorage{ctrl: ctrl}
+	mock.recorder = &MockStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
+	return m.recorder
+}
+
+// ClaimOutbox mocks base method.
+func (m *MockStorage) ClaimOutbox(ctx context.Context, sink string, limit int, lease time.Duration) ([]database.OutboxMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimOutbox", ctx, sink, limit, lease)
+	ret0, _ := ret[0].([]database.OutboxMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimOutbox indicates an expected call of ClaimOutbox.
+func (mr *MockStorageMockRecorder) ClaimOutbox(ctx, sink, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimOutbox", reflect.TypeOf((*MockStorage)(nil).ClaimOutbox), ctx, sink, limit, lease)
+}
+
+// Close mocks base method.
+func (m *MockStorage) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockStorageMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close))
+}
+
+// CountCompanies mocks base method.
+func (m *MockStorage) CountCompanies(arg0 context.Context) ([]metrics.CompanyCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountCompanies", arg0)
+	ret0, _ := ret[0].([]metrics.CompanyCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountCompanies indicates an expected call of CountCompanies.
+func (mr *MockStorageMockRecorder) CountCompanies(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountCompanies", reflect.TypeOf((*MockStorage)(nil).CountCompanies), arg0)
+}
+
+// CreateRecord mocks base method.
+func (m *MockStorage) CreateRecord(arg0 context.Context, arg1 database.CompanyInfo) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRecord", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRecord indicates an expected call of CreateRecord.
+func (mr *MockStorageMockRecorder) CreateRecord(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecord", reflect.TypeOf((*MockStorage)(nil).CreateRecord), arg0, arg1)
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockStorage) CreateRefreshToken(arg0 context.Context, arg1 database.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockStorageMockRecorder) CreateRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockStorage)(nil).CreateRefreshToken), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockStorage) CreateUser(arg0 context.Context, arg1 database.User) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStorageMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStorage)(nil).CreateUser), arg0, arg1)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockStorage) CreateWebhook(arg0 context.Context, arg1 database.WebhookSubscription) (database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockStorageMockRecorder) CreateWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockStorage)(nil).CreateWebhook), arg0, arg1)
+}
+
+// DeleteRecord mocks base method.
+func (m *MockStorage) DeleteRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecord", ctx, id, ifMatch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecord indicates an expected call of DeleteRecord.
+func (mr *MockStorageMockRecorder) DeleteRecord(ctx, id, ifMatch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecord", reflect.TypeOf((*MockStorage)(nil).DeleteRecord), ctx, id, ifMatch)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockStorage) DeleteWebhook(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockStorageMockRecorder) DeleteWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockStorage)(nil).DeleteWebhook), arg0, arg1)
+}
+
+// GetRecord mocks base method.
+func (m *MockStorage) GetRecord(ctx context.Context, id uuid.UUID, includeDeleted bool) (database.CompanyInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecord", ctx, id, includeDeleted)
+	ret0, _ := ret[0].(database.CompanyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecord indicates an expected call of GetRecord.
+func (mr *MockStorageMockRecorder) GetRecord(ctx, id, includeDeleted interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecord", reflect.TypeOf((*MockStorage)(nil).GetRecord), ctx, id, includeDeleted)
+}
+
+// GetUser mocks base method.
+func (m *MockStorage) GetUser(ctx context.Context, username string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStorageMockRecorder) GetUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStorage)(nil).GetUser), ctx, username)
+}
+
+// GetWebhook mocks base method.
+func (m *MockStorage) GetWebhook(arg0 context.Context, arg1 uuid.UUID) (database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", arg0, arg1)
+	ret0, _ := ret[0].(database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockStorageMockRecorder) GetWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockStorage)(nil).GetWebhook), arg0, arg1)
+}
+
+// IsRecordExists mocks base method.
+func (m *MockStorage) IsRecordExists(arg0 context.Context, arg1 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRecordExists", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsRecordExists indicates an expected call of IsRecordExists.
+func (mr *MockStorageMockRecorder) IsRecordExists(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRecordExists", reflect.TypeOf((*MockStorage)(nil).IsRecordExists), arg0, arg1)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockStorage) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", ctx, jti)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockStorageMockRecorder) IsTokenRevoked(ctx, jti interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockStorage)(nil).IsTokenRevoked), ctx, jti)
+}
+
+// ListEnabledWebhooks mocks base method.
+func (m *MockStorage) ListEnabledWebhooks(arg0 context.Context) ([]database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEnabledWebhooks", arg0)
+	ret0, _ := ret[0].([]database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEnabledWebhooks indicates an expected call of ListEnabledWebhooks.
+func (mr *MockStorageMockRecorder) ListEnabledWebhooks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEnabledWebhooks", reflect.TypeOf((*MockStorage)(nil).ListEnabledWebhooks), arg0)
+}
+
+// ListRecords mocks base method.
+func (m *MockStorage) ListRecords(arg0 context.Context, arg1 database.ListQuery) (database.ListPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRecords", arg0, arg1)
+	ret0, _ := ret[0].(database.ListPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRecords indicates an expected call of ListRecords.
+func (mr *MockStorageMockRecorder) ListRecords(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRecords", reflect.TypeOf((*MockStorage)(nil).ListRecords), arg0, arg1)
+}
+
+// ListUsers mocks base method.
+func (m *MockStorage) ListUsers(arg0 context.Context) ([]database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0)
+	ret0, _ := ret[0].([]database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockStorageMockRecorder) ListUsers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStorage)(nil).ListUsers), arg0)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockStorage) ListWebhookDeliveries(ctx context.Context, id uuid.UUID, limit int) ([]database.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, id, limit)
+	ret0, _ := ret[0].([]database.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockStorageMockRecorder) ListWebhookDeliveries(ctx, id, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockStorage)(nil).ListWebhookDeliveries), ctx, id, limit)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockStorage) ListWebhooks(arg0 context.Context) ([]database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks", arg0)
+	ret0, _ := ret[0].([]database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockStorageMockRecorder) ListWebhooks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockStorage)(nil).ListWebhooks), arg0)
+}
+
+// MarkOutboxFailed mocks base method.
+func (m *MockStorage) MarkOutboxFailed(ctx context.Context, id uint64, cause error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxFailed", ctx, id, cause)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxFailed indicates an expected call of MarkOutboxFailed.
+func (mr *MockStorageMockRecorder) MarkOutboxFailed(ctx, id, cause interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxFailed", reflect.TypeOf((*MockStorage)(nil).MarkOutboxFailed), ctx, id, cause)
+}
+
+// MarkOutboxSent mocks base method.
+func (m *MockStorage) MarkOutboxSent(ctx context.Context, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxSent", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxSent indicates an expected call of MarkOutboxSent.
+func (mr *MockStorageMockRecorder) MarkOutboxSent(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxSent", reflect.TypeOf((*MockStorage)(nil).MarkOutboxSent), ctx, id)
+}
+
+// MarkWebhookFailed mocks base method.
+func (m *MockStorage) MarkWebhookFailed(ctx context.Context, id uuid.UUID, disableAfter int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookFailed", ctx, id, disableAfter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWebhookFailed indicates an expected call of MarkWebhookFailed.
+func (mr *MockStorageMockRecorder) MarkWebhookFailed(ctx, id, disableAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookFailed", reflect.TypeOf((*MockStorage)(nil).MarkWebhookFailed), ctx, id, disableAfter)
+}
+
+// MarkWebhookSucceeded mocks base method.
+func (m *MockStorage) MarkWebhookSucceeded(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookSucceeded", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWebhookSucceeded indicates an expected call of MarkWebhookSucceeded.
+func (mr *MockStorageMockRecorder) MarkWebhookSucceeded(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookSucceeded", reflect.TypeOf((*MockStorage)(nil).MarkWebhookSucceeded), arg0, arg1)
+}
+
+// OutboxBacklog mocks base method.
+func (m *MockStorage) OutboxBacklog(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OutboxBacklog", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OutboxBacklog indicates an expected call of OutboxBacklog.
+func (mr *MockStorageMockRecorder) OutboxBacklog(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OutboxBacklog", reflect.TypeOf((*MockStorage)(nil).OutboxBacklog), arg0)
+}
+
+// ParkOutbox mocks base method.
+func (m *MockStorage) ParkOutbox(ctx context.Context, id uint64, cause error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParkOutbox", ctx, id, cause)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ParkOutbox indicates an expected call of ParkOutbox.
+func (mr *MockStorageMockRecorder) ParkOutbox(ctx, id, cause interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParkOutbox", reflect.TypeOf((*MockStorage)(nil).ParkOutbox), ctx, id, cause)
+}
+
+// Ping mocks base method.
+func (m *MockStorage) Ping(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStorageMockRecorder) Ping(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStorage)(nil).Ping), arg0)
+}
+
+// PruneOutbox mocks base method.
+func (m *MockStorage) PruneOutbox(ctx context.Context, sentBefore time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneOutbox", ctx, sentBefore, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneOutbox indicates an expected call of PruneOutbox.
+func (mr *MockStorageMockRecorder) PruneOutbox(ctx, sentBefore, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneOutbox", reflect.TypeOf((*MockStorage)(nil).PruneOutbox), ctx, sentBefore, limit)
+}
+
+// PurgeRecord mocks base method.
+func (m *MockStorage) PurgeRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeRecord", ctx, id, ifMatch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeRecord indicates an expected call of PurgeRecord.
+func (mr *MockStorageMockRecorder) PurgeRecord(ctx, id, ifMatch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeRecord", reflect.TypeOf((*MockStorage)(nil).PurgeRecord), ctx, id, ifMatch)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockStorage) RecordLoginFailure(ctx context.Context, username string, maxFailures int, lockout time.Duration) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, username, maxFailures, lockout)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockStorageMockRecorder) RecordLoginFailure(ctx, username, maxFailures, lockout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockStorage)(nil).RecordLoginFailure), ctx, username, maxFailures, lockout)
+}
+
+// RecordLoginSuccess mocks base method.
+func (m *MockStorage) RecordLoginSuccess(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginSuccess", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordLoginSuccess indicates an expected call of RecordLoginSuccess.
+func (mr *MockStorageMockRecorder) RecordLoginSuccess(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginSuccess", reflect.TypeOf((*MockStorage)(nil).RecordLoginSuccess), ctx, username)
+}
+
+// RecordWebhookDelivery mocks base method.
+func (m *MockStorage) RecordWebhookDelivery(arg0 context.Context, arg1 database.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordWebhookDelivery indicates an expected call of RecordWebhookDelivery.
+func (mr *MockStorageMockRecorder) RecordWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWebhookDelivery", reflect.TypeOf((*MockStorage)(nil).RecordWebhookDelivery), arg0, arg1)
+}
+
+// ReleaseOutbox mocks base method.
+func (m *MockStorage) ReleaseOutbox(ctx context.Context, ids []uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseOutbox", ctx, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseOutbox indicates an expected call of ReleaseOutbox.
+func (mr *MockStorageMockRecorder) ReleaseOutbox(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseOutbox", reflect.TypeOf((*MockStorage)(nil).ReleaseOutbox), ctx, ids)
+}
+
+// RestoreRecord mocks base method.
+func (m *MockStorage) RestoreRecord(arg0 context.Context, arg1 uuid.UUID) (database.CompanyInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreRecord", arg0, arg1)
+	ret0, _ := ret[0].(database.CompanyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreRecord indicates an expected call of RestoreRecord.
+func (mr *MockStorageMockRecorder) RestoreRecord(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRecord", reflect.TypeOf((*MockStorage)(nil).RestoreRecord), arg0, arg1)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *MockStorage) RevokeRefreshToken(ctx context.Context, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", ctx, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MockStorageMockRecorder) RevokeRefreshToken(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MockStorage)(nil).RevokeRefreshToken), ctx, hash)
+}
+
+// RevokeToken mocks base method.
+func (m *MockStorage) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", ctx, jti, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockStorageMockRecorder) RevokeToken(ctx, jti, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockStorage)(nil).RevokeToken), ctx, jti, expiresAt)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockStorage) RotateRefreshToken(ctx context.Context, hash, nextHash string, expiresAt time.Time) (database.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, hash, nextHash, expiresAt)
+	ret0, _ := ret[0].(database.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockStorageMockRecorder) RotateRefreshToken(ctx, hash, nextHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockStorage)(nil).RotateRefreshToken), ctx, hash, nextHash, expiresAt)
+}
+
+// Search mocks base method.
+func (m *MockStorage) Search(ctx context.Context, query string, limit int) ([]database.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query, limit)
+	ret0, _ := ret[0].([]database.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockStorageMockRecorder) Search(ctx, query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockStorage)(nil).Search), ctx, query, limit)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockStorage) SetUserDisabled(ctx context.Context, username string, disabled bool) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, username, disabled)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockStorageMockRecorder) SetUserDisabled(ctx, username, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockStorage)(nil).SetUserDisabled), ctx, username, disabled)
+}
+
+// SetUserPassword mocks base method.
+func (m *MockStorage) SetUserPassword(ctx context.Context, username, passwordHash string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPassword", ctx, username, passwordHash)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserPassword indicates an expected call of SetUserPassword.
+func (mr *MockStorageMockRecorder) SetUserPassword(ctx, username, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPassword", reflect.TypeOf((*MockStorage)(nil).SetUserPassword), ctx, username, passwordHash)
+}
+
+// SetUserRoles mocks base method.
+func (m *MockStorage) SetUserRoles(ctx context.Context, username string, roles []string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRoles", ctx, username, roles)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockStorageMockRecorder) SetUserRoles(ctx, username, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockStorage)(nil).SetUserRoles), ctx, username, roles)
+}
+
+// UpdateRecord mocks base method.
+func (m *MockStorage) UpdateRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64, update database.UpdateFunc) (database.CompanyInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRecord", ctx, id, ifMatch, update)
+	ret0, _ := ret[0].(database.CompanyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRecord indicates an expected call of UpdateRecord.
+func (mr *MockStorageMockRecorder) UpdateRecord(ctx, id, ifMatch, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecord", reflect.TypeOf((*MockStorage)(nil).UpdateRecord), ctx, id, ifMatch, update)
+}
+
+// UpdateWebhook mocks base method.
+func (m *MockStorage) UpdateWebhook(arg0 context.Context, arg1 uuid.UUID, arg2 database.WebhookUpdate) (database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhook", arg0, arg1, arg2)
+	ret0, _ := ret[0].(database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook.
+func (mr *MockStorageMockRecorder) UpdateWebhook(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecord", reflect.TypeOf((*MockStorage)(nil).UpdateRecord), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhook", reflect.TypeOf((*MockStorage)(nil).UpdateWebhook), arg0, arg1, arg2)
 }
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,15 +36,29 @@ func (m *MockdeleteRecordDB) EXPECT() *MockdeleteRecordDBMockRecorder {
 }
 
 // DeleteRecord mocks base method.
-func (m *MockdeleteRecordDB) DeleteRecord(arg0 uuid.UUID) error {
+func (m *MockdeleteRecordDB) DeleteRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteRecord", arg0)
+	ret := m.ctrl.Call(m, "DeleteRecord", ctx, id, ifMatch)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteRecord indicates an expected call of DeleteRecord.
-func (mr *MockdeleteRecordDBMockRecorder) DeleteRecord(arg0 interface{}) *gomock.Call {
+func (mr *MockdeleteRecordDBMockRecorder) DeleteRecord(ctx, id, ifMatch interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecord", reflect.TypeOf((*MockdeleteRecordDB)(nil).DeleteRecord), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecord", reflect.TypeOf((*MockdeleteRecordDB)(nil).DeleteRecord), ctx, id, ifMatch)
+}
+
+// PurgeRecord mocks base method.
+func (m *MockdeleteRecordDB) PurgeRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeRecord", ctx, id, ifMatch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeRecord indicates an expected call of PurgeRecord.
+func (mr *MockdeleteRecordDBMockRecorder) PurgeRecord(ctx, id, ifMatch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeRecord", reflect.TypeOf((*MockdeleteRecordDB)(nil).PurgeRecord), ctx, id, ifMatch)
 }
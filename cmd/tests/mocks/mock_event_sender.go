@@ -6,6 +6,7 @@ package mocks
 
 import (
 	structs "companies/cmd/internal/structs"
+	context "context"
 	reflect "reflect"
 
 	kafka "github.com/confluentinc/confluent-kafka-go/v2/kafka"
@@ -36,17 +37,68 @@ func (m *MockEventSender) EXPECT() *MockEventSenderMockRecorder {
 }
 
 // PublishEvent mocks base method.
-func (m *MockEventSender) PublishEvent(arg0 string, arg1 structs.Event) error {
+func (m *MockEventSender) PublishEvent(arg0 context.Context, arg1 string, arg2 structs.Event) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PublishEvent", arg0, arg1)
+	ret := m.ctrl.Call(m, "PublishEvent", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // PublishEvent indicates an expected call of PublishEvent.
-func (mr *MockEventSenderMockRecorder) PublishEvent(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockEventSenderMockRecorder) PublishEvent(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishEvent", reflect.TypeOf((*MockEventSender)(nil).PublishEvent), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishEvent", reflect.TypeOf((*MockEventSender)(nil).PublishEvent), arg0, arg1, arg2)
+}
+
+// MockTransport is a mock of Transport interface.
+type MockTransport struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransportMockRecorder
+}
+
+// MockTransportMockRecorder is the mock recorder for MockTransport.
+type MockTransportMockRecorder struct {
+	mock *MockTransport
+}
+
+// NewMockTransport creates a new mock instance.
+func NewMockTransport(ctrl *gomock.Controller) *MockTransport {
+	mock := &MockTransport{ctrl: ctrl}
+	mock.recorder = &MockTransportMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransport) EXPECT() *MockTransportMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockTransport) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockTransportMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockTransport)(nil).Close))
+}
+
+// PublishEvent mocks base method.
+func (m *MockTransport) PublishEvent(arg0 context.Context, arg1 string, arg2 structs.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishEvent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishEvent indicates an expected call of PublishEvent.
+func (mr *MockTransportMockRecorder) PublishEvent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishEvent", reflect.TypeOf((*MockTransport)(nil).PublishEvent), arg0, arg1, arg2)
 }
 
 // MockProducer is a mock of Producer interface.
@@ -84,6 +136,20 @@ func (mr *MockProducerMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockProducer)(nil).Close))
 }
 
+// Flush mocks base method.
+func (m *MockProducer) Flush(timeoutMs int) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush", timeoutMs)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockProducerMockRecorder) Flush(timeoutMs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockProducer)(nil).Flush), timeoutMs)
+}
+
 // GetMetadata mocks base method.
 func (m *MockProducer) GetMetadata(topic *string, allTopics bool, timeoutMs int) (*kafka.Metadata, error) {
 	m.ctrl.T.Helper()
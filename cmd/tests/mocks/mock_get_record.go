@@ -6,6 +6,7 @@ package mocks
 
 import (
 	database "companies/cmd/internal/database"
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -36,16 +37,16 @@ func (m *MockgetRecordDB) EXPECT() *MockgetRecordDBMockRecorder {
 }
 
 // GetRecord mocks base method.
-func (m *MockgetRecordDB) GetRecord(arg0 uuid.UUID) (database.CompanyInfo, error) {
+func (m *MockgetRecordDB) GetRecord(ctx context.Context, id uuid.UUID, includeDeleted bool) (database.CompanyInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRecord", arg0)
+	ret := m.ctrl.Call(m, "GetRecord", ctx, id, includeDeleted)
 	ret0, _ := ret[0].(database.CompanyInfo)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetRecord indicates an expected call of GetRecord.
-func (mr *MockgetRecordDBMockRecorder) GetRecord(arg0 interface{}) *gomock.Call {
+func (mr *MockgetRecordDBMockRecorder) GetRecord(ctx, id, includeDeleted interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecord", reflect.TypeOf((*MockgetRecordDB)(nil).GetRecord), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecord", reflect.TypeOf((*MockgetRecordDB)(nil).GetRecord), ctx, id, includeDeleted)
 }
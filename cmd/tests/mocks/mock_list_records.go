@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: listRecordsHandler.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	database "companies/cmd/internal/database"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MocklistRecordsDB is a mock of listRecordsDB interface.
+type MocklistRecordsDB struct {
+	ctrl     *gomock.Controller
+	recorder *MocklistRecordsDBMockRecorder
+}
+
+// MocklistRecordsDBMockRecorder is the mock recorder for MocklistRecordsDB.
+type MocklistRecordsDBMockRecorder struct {
+	mock *MocklistRecordsDB
+}
+
+// NewMocklistRecordsDB creates a new mock instance.
+func NewMocklistRecordsDB(ctrl *gomock.Controller) *MocklistRecordsDB {
+	mock := &MocklistRecordsDB{ctrl: ctrl}
+	mock.recorder = &MocklistRecordsDBMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocklistRecordsDB) EXPECT() *MocklistRecordsDBMockRecorder {
+	return m.recorder
+}
+
+// ListRecords mocks base method.
+func (m *MocklistRecordsDB) ListRecords(arg0 context.Context, arg1 database.ListQuery) (database.ListPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRecords", arg0, arg1)
+	ret0, _ := ret[0].(database.ListPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRecords indicates an expected call of ListRecords.
+func (mr *MocklistRecordsDBMockRecorder) ListRecords(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRecords", reflect.TypeOf((*MocklistRecordsDB)(nil).ListRecords), arg0, arg1)
+}
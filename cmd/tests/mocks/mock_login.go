@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: loginHandler.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	database "companies/cmd/internal/database"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockloginDB is a mock of loginDB interface.
+type MockloginDB struct {
+	ctrl     *gomock.Controller
+	recorder *MockloginDBMockRecorder
+}
+
+// MockloginDBMockRecorder is the mock recorder for MockloginDB.
+type MockloginDBMockRecorder struct {
+	mock *MockloginDB
+}
+
+// NewMockloginDB creates a new mock instance.
+func NewMockloginDB(ctrl *gomock.Controller) *MockloginDB {
+	mock := &MockloginDB{ctrl: ctrl}
+	mock.recorder = &MockloginDBMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockloginDB) EXPECT() *MockloginDBMockRecorder {
+	return m.recorder
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockloginDB) CreateRefreshToken(arg0 context.Context, arg1 database.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockloginDBMockRecorder) CreateRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockloginDB)(nil).CreateRefreshToken), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockloginDB) GetUser(ctx context.Context, username string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockloginDBMockRecorder) GetUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockloginDB)(nil).GetUser), ctx, username)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockloginDB) RecordLoginFailure(ctx context.Context, username string, maxFailures int, lockout time.Duration) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, username, maxFailures, lockout)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockloginDBMockRecorder) RecordLoginFailure(ctx, username, maxFailures, lockout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockloginDB)(nil).RecordLoginFailure), ctx, username, maxFailures, lockout)
+}
+
+// RecordLoginSuccess mocks base method.
+func (m *MockloginDB) RecordLoginSuccess(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginSuccess", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordLoginSuccess indicates an expected call of RecordLoginSuccess.
+func (mr *MockloginDBMockRecorder) RecordLoginSuccess(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginSuccess", reflect.TypeOf((*MockloginDB)(nil).RecordLoginSuccess), ctx, username)
+}
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,9 +35,11 @@ func (m *MockRESTServer) EXPECT() *MockRESTServerMockRecorder {
 }
 
 // Serve mocks base method.
-func (m *MockRESTServer) Serve() {
+func (m *MockRESTServer) Serve() error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Serve")
+	ret := m.ctrl.Call(m, "Serve")
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // Serve indicates an expected call of Serve.
@@ -46,15 +49,15 @@ func (mr *MockRESTServerMockRecorder) Serve() *gomock.Call {
 }
 
 // Shutdown mocks base method.
-func (m *MockRESTServer) Shutdown() error {
+func (m *MockRESTServer) Shutdown(ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Shutdown")
+	ret := m.ctrl.Call(m, "Shutdown", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Shutdown indicates an expected call of Shutdown.
-func (mr *MockRESTServerMockRecorder) Shutdown() *gomock.Call {
+func (mr *MockRESTServerMockRecorder) Shutdown(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockRESTServer)(nil).Shutdown))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockRESTServer)(nil).Shutdown), ctx)
 }
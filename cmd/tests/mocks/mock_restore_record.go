@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: restoreRecordHandler.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	database "companies/cmd/internal/database"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockrestoreRecordDB is a mock of restoreRecordDB interface.
+type MockrestoreRecordDB struct {
+	ctrl     *gomock.Controller
+	recorder *MockrestoreRecordDBMockRecorder
+}
+
+// MockrestoreRecordDBMockRecorder is the mock recorder for MockrestoreRecordDB.
+type MockrestoreRecordDBMockRecorder struct {
+	mock *MockrestoreRecordDB
+}
+
+// NewMockrestoreRecordDB creates a new mock instance.
+func NewMockrestoreRecordDB(ctrl *gomock.Controller) *MockrestoreRecordDB {
+	mock := &MockrestoreRecordDB{ctrl: ctrl}
+	mock.recorder = &MockrestoreRecordDBMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrestoreRecordDB) EXPECT() *MockrestoreRecordDBMockRecorder {
+	return m.recorder
+}
+
+// RestoreRecord mocks base method.
+func (m *MockrestoreRecordDB) RestoreRecord(arg0 context.Context, arg1 uuid.UUID) (database.CompanyInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreRecord", arg0, arg1)
+	ret0, _ := ret[0].(database.CompanyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreRecord indicates an expected call of RestoreRecord.
+func (mr *MockrestoreRecordDBMockRecorder) RestoreRecord(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRecord", reflect.TypeOf((*MockrestoreRecordDB)(nil).RestoreRecord), arg0, arg1)
+}
@@ -0,0 +1,118 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: tokenHandler.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	database "companies/cmd/internal/database"
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MocktokensDB is a mock of tokensDB interface.
+type MocktokensDB struct {
+	ctrl     *gomock.Controller
+	recorder *MocktokensDBMockRecorder
+}
+
+// MocktokensDBMockRecorder is the mock recorder for MocktokensDB.
+type MocktokensDBMockRecorder struct {
+	mock *MocktokensDB
+}
+
+// NewMocktokensDB creates a new mock instance.
+func NewMocktokensDB(ctrl *gomock.Controller) *MocktokensDB {
+	mock := &MocktokensDB{ctrl: ctrl}
+	mock.recorder = &MocktokensDBMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktokensDB) EXPECT() *MocktokensDBMockRecorder {
+	return m.recorder
+}
+
+// GetUser mocks base method.
+func (m *MocktokensDB) GetUser(ctx context.Context, username string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MocktokensDBMockRecorder) GetUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MocktokensDB)(nil).GetUser), ctx, username)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *MocktokensDB) RevokeRefreshToken(ctx context.Context, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", ctx, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MocktokensDBMockRecorder) RevokeRefreshToken(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MocktokensDB)(nil).RevokeRefreshToken), ctx, hash)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MocktokensDB) RotateRefreshToken(ctx context.Context, hash, nextHash string, expiresAt time.Time) (database.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, hash, nextHash, expiresAt)
+	ret0, _ := ret[0].(database.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MocktokensDBMockRecorder) RotateRefreshToken(ctx, hash, nextHash, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MocktokensDB)(nil).RotateRefreshToken), ctx, hash, nextHash, expiresAt)
+}
+
+// MocktokenRevoker is a mock of tokenRevoker interface.
+type MocktokenRevoker struct {
+	ctrl     *gomock.Controller
+	recorder *MocktokenRevokerMockRecorder
+}
+
+// MocktokenRevokerMockRecorder is the mock recorder for MocktokenRevoker.
+type MocktokenRevokerMockRecorder struct {
+	mock *MocktokenRevoker
+}
+
+// NewMocktokenRevoker creates a new mock instance.
+func NewMocktokenRevoker(ctrl *gomock.Controller) *MocktokenRevoker {
+	mock := &MocktokenRevoker{ctrl: ctrl}
+	mock.recorder = &MocktokenRevokerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktokenRevoker) EXPECT() *MocktokenRevokerMockRecorder {
+	return m.recorder
+}
+
+// Revoke mocks base method.
+func (m *MocktokenRevoker) Revoke(ctx context.Context, jti string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, jti, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MocktokenRevokerMockRecorder) Revoke(ctx, jti, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MocktokenRevoker)(nil).Revoke), ctx, jti, until)
+}
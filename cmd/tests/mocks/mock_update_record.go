@@ -6,6 +6,7 @@ package mocks
 
 import (
 	database "companies/cmd/internal/database"
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -36,15 +37,16 @@ func (m *MockupdateRecordDB) EXPECT() *MockupdateRecordDBMockRecorder {
 }
 
 // UpdateRecord mocks base method.
-func (m *MockupdateRecordDB) UpdateRecord(arg0 database.CompanyInfo, arg1 uuid.UUID) error {
+func (m *MockupdateRecordDB) UpdateRecord(ctx context.Context, id uuid.UUID, ifMatch []uint64, update database.UpdateFunc) (database.CompanyInfo, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateRecord", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "UpdateRecord", ctx, id, ifMatch, update)
+	ret0, _ := ret[0].(database.CompanyInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // UpdateRecord indicates an expected call of UpdateRecord.
-func (mr *MockupdateRecordDBMockRecorder) UpdateRecord(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockupdateRecordDBMockRecorder) UpdateRecord(ctx, id, ifMatch, update interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecord", reflect.TypeOf((*MockupdateRecordDB)(nil).UpdateRecord), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecord", reflect.TypeOf((*MockupdateRecordDB)(nil).UpdateRecord), ctx, id, ifMatch, update)
 }
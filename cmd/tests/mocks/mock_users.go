@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usersHandler.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	database "companies/cmd/internal/database"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockusersDB is a mock of usersDB interface.
+type MockusersDB struct {
+	ctrl     *gomock.Controller
+	recorder *MockusersDBMockRecorder
+}
+
+// MockusersDBMockRecorder is the mock recorder for MockusersDB.
+type MockusersDBMockRecorder struct {
+	mock *MockusersDB
+}
+
+// NewMockusersDB creates a new mock instance.
+func NewMockusersDB(ctrl *gomock.Controller) *MockusersDB {
+	mock := &MockusersDB{ctrl: ctrl}
+	mock.recorder = &MockusersDBMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockusersDB) EXPECT() *MockusersDBMockRecorder {
+	return m.recorder
+}
+
+// CreateUser mocks base method.
+func (m *MockusersDB) CreateUser(arg0 context.Context, arg1 database.User) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockusersDBMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockusersDB)(nil).CreateUser), arg0, arg1)
+}
+
+// ListUsers mocks base method.
+func (m *MockusersDB) ListUsers(arg0 context.Context) ([]database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0)
+	ret0, _ := ret[0].([]database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockusersDBMockRecorder) ListUsers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockusersDB)(nil).ListUsers), arg0)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockusersDB) SetUserDisabled(ctx context.Context, username string, disabled bool) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, username, disabled)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockusersDBMockRecorder) SetUserDisabled(ctx, username, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockusersDB)(nil).SetUserDisabled), ctx, username, disabled)
+}
+
+// SetUserPassword mocks base method.
+func (m *MockusersDB) SetUserPassword(ctx context.Context, username, passwordHash string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPassword", ctx, username, passwordHash)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserPassword indicates an expected call of SetUserPassword.
+func (mr *MockusersDBMockRecorder) SetUserPassword(ctx, username, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPassword", reflect.TypeOf((*MockusersDB)(nil).SetUserPassword), ctx, username, passwordHash)
+}
+
+// SetUserRoles mocks base method.
+func (m *MockusersDB) SetUserRoles(ctx context.Context, username string, roles []string) (database.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRoles", ctx, username, roles)
+	ret0, _ := ret[0].(database.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockusersDBMockRecorder) SetUserRoles(ctx, username, roles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockusersDB)(nil).SetUserRoles), ctx, username, roles)
+}
@@ -0,0 +1,126 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: webhooksHandler.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	database "companies/cmd/internal/database"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockwebhooksDB is a mock of webhooksDB interface.
+type MockwebhooksDB struct {
+	ctrl     *gomock.Controller
+	recorder *MockwebhooksDBMockRecorder
+}
+
+// MockwebhooksDBMockRecorder is the mock recorder for MockwebhooksDB.
+type MockwebhooksDBMockRecorder struct {
+	mock *MockwebhooksDB
+}
+
+// NewMockwebhooksDB creates a new mock instance.
+func NewMockwebhooksDB(ctrl *gomock.Controller) *MockwebhooksDB {
+	mock := &MockwebhooksDB{ctrl: ctrl}
+	mock.recorder = &MockwebhooksDBMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockwebhooksDB) EXPECT() *MockwebhooksDBMockRecorder {
+	return m.recorder
+}
+
+// CreateWebhook mocks base method.
+func (m *MockwebhooksDB) CreateWebhook(arg0 context.Context, arg1 database.WebhookSubscription) (database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockwebhooksDBMockRecorder) CreateWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockwebhooksDB)(nil).CreateWebhook), arg0, arg1)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockwebhooksDB) DeleteWebhook(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockwebhooksDBMockRecorder) DeleteWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockwebhooksDB)(nil).DeleteWebhook), arg0, arg1)
+}
+
+// GetWebhook mocks base method.
+func (m *MockwebhooksDB) GetWebhook(arg0 context.Context, arg1 uuid.UUID) (database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", arg0, arg1)
+	ret0, _ := ret[0].(database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockwebhooksDBMockRecorder) GetWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockwebhooksDB)(nil).GetWebhook), arg0, arg1)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockwebhooksDB) ListWebhookDeliveries(ctx context.Context, id uuid.UUID, limit int) ([]database.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, id, limit)
+	ret0, _ := ret[0].([]database.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockwebhooksDBMockRecorder) ListWebhookDeliveries(ctx, id, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockwebhooksDB)(nil).ListWebhookDeliveries), ctx, id, limit)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockwebhooksDB) ListWebhooks(arg0 context.Context) ([]database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks", arg0)
+	ret0, _ := ret[0].([]database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockwebhooksDBMockRecorder) ListWebhooks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockwebhooksDB)(nil).ListWebhooks), arg0)
+}
+
+// UpdateWebhook mocks base method.
+func (m *MockwebhooksDB) UpdateWebhook(arg0 context.Context, arg1 uuid.UUID, arg2 database.WebhookUpdate) (database.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhook", arg0, arg1, arg2)
+	ret0, _ := ret[0].(database.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook.
+func (mr *MockwebhooksDBMockRecorder) UpdateWebhook(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhook", reflect.TypeOf((*MockwebhooksDB)(nil).UpdateWebhook), arg0, arg1, arg2)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"companies/cmd/internal/auth"
+	configparser "companies/cmd/internal/configParser"
+	"companies/cmd/internal/database"
+	"companies/cmd/internal/logging"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+const userUsage = `usage: main user <command> <username> [role...]
+
+commands:
+  create          create a user with the given roles, the password is read from stdin
+  roles           replace the roles of a user, no roles leaves read access to public data only
+  reset-password  replace the password and lift a lockout, the password is read from stdin
+  disable         refuse the user's logins
+  enable          allow the user's logins again and lift a lockout
+  list            list the users, takes no username
+
+roles: viewer, editor, admin`
+
+const userCommandTimeout = 30 * time.Second
+
+// runUserCLI manages users from the command line, so the first admin can be
+// created before anyone is able to log in. It returns the exit code.
+func runUserCLI(configPath string, args []string) int {
+	logger := logging.New(os.Stderr, slog.LevelWarn)
+
+	config, err := configparser.LoadConfig(configPath)
+	if err != nil {
+		logger.Warn("Failed to load config, using the environment only", logging.Err(err))
+	}
+
+	// Nothing scrapes the command, so statements aren't timed.
+	db := database.NewMySQLDB(config.DB, nil, logger)
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), userCommandTimeout)
+	defer cancel()
+
+	if err := runUserCommand(ctx, args, db, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func runUserCommand(ctx context.Context, args []string, users database.UserStore, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 1 && args[0] == "list" {
+		list, err := users.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, u := range list {
+			state := "enabled"
+			if u.Disabled {
+				state = "disabled"
+			}
+			if u.Locked(time.Now()) {
+				state += ", locked"
+			}
+			fmt.Fprintf(stdout, "%s\t%s\t%s\n", u.Username, strings.Join(u.Roles, ","), state)
+		}
+		return nil
+	}
+
+	if len(args) < 2 {
+		return errors.New(userUsage)
+	}
+
+	command, username, roles := args[0], args[1], args[2:]
+
+	// Only create and roles take roles.
+	if len(roles) > 0 && command != "create" && command != "roles" {
+		return errors.New(userUsage)
+	}
+
+	switch command {
+	case "create":
+		if err := auth.ValidateUsername(username); err != nil {
+			return err
+		}
+
+		if err := auth.ValidateRoles(roles); err != nil {
+			return err
+		}
+
+		hash, err := readPasswordHash(stdin)
+		if err != nil {
+			return err
+		}
+
+		if _, err := users.CreateUser(ctx, database.User{Username: username, PasswordHash: hash, Roles: roles}); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "created %s\n", username)
+
+	case "roles":
+		if err := auth.ValidateRoles(roles); err != nil {
+			return err
+		}
+
+		if _, err := users.SetUserRoles(ctx, username, roles); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "set the roles of %s to %s\n", username, strings.Join(roles, ","))
+
+	case "reset-password":
+		hash, err := readPasswordHash(stdin)
+		if err != nil {
+			return err
+		}
+
+		if _, err := users.SetUserPassword(ctx, username, hash); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "reset the password of %s\n", username)
+
+	case "disable", "enable":
+		if _, err := users.SetUserDisabled(ctx, username, command == "disable"); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "%sd %s\n", command, username)
+
+	default:
+		return errors.New(userUsage)
+	}
+
+	return nil
+}
+
+// readPasswordHash hashes the first line of stdin, it is read rather than
+// passed as an argument so it doesn't end up in the shell history.
+func readPasswordHash(stdin io.Reader) (string, error) {
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if err := auth.ValidatePassword(password); err != nil {
+		return "", err
+	}
+
+	return auth.HashPassword(password)
+}
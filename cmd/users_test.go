@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"companies/cmd/internal/auth"
+	"companies/cmd/internal/database"
+	"companies/cmd/tests/mocks"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunUserCommand_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	users := mocks.NewMockUserStore(ctrl)
+	users.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u database.User) (database.User, error) {
+		assert.Equal(t, "admin", u.Username)
+		assert.Equal(t, []string{auth.RoleAdmin}, u.Roles)
+		ok, err := auth.VerifyPassword(u.PasswordHash, "correct horse battery staple")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		return u, nil
+	})
+
+	var out bytes.Buffer
+	err := runUserCommand(context.Background(), []string{"create", "admin", "admin"}, users, strings.NewReader("correct horse battery staple\n"), &out)
+
+	require.NoError(t, err)
+	assert.Equal(t, "created admin\n", out.String())
+}
+
+func TestRunUserCommand_WeakPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	users := mocks.NewMockUserStore(ctrl)
+
+	err := runUserCommand(context.Background(), []string{"reset-password", "admin"}, users, strings.NewReader("admin\n"), &bytes.Buffer{})
+
+	assert.ErrorContains(t, err, "password must be")
+}
+
+func TestRunUserCommand_Disable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	users := mocks.NewMockUserStore(ctrl)
+	users.EXPECT().SetUserDisabled(gomock.Any(), "jane", true).Return(database.User{}, nil)
+
+	var out bytes.Buffer
+	err := runUserCommand(context.Background(), []string{"disable", "jane"}, users, nil, &out)
+
+	require.NoError(t, err)
+	assert.Equal(t, "disabled jane\n", out.String())
+}
+
+func TestRunUserCommand_Roles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	users := mocks.NewMockUserStore(ctrl)
+	users.EXPECT().SetUserRoles(gomock.Any(), "jane", []string{auth.RoleViewer}).Return(database.User{}, nil)
+
+	var out bytes.Buffer
+	err := runUserCommand(context.Background(), []string{"roles", "jane", "viewer"}, users, nil, &out)
+
+	require.NoError(t, err)
+	assert.Equal(t, "set the roles of jane to viewer\n", out.String())
+}
+
+func TestRunUserCommand_UnknownRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	users := mocks.NewMockUserStore(ctrl)
+
+	err := runUserCommand(context.Background(), []string{"create", "jane", "owner"}, users, strings.NewReader("correct horse battery staple\n"), &bytes.Buffer{})
+
+	assert.ErrorContains(t, err, "unknown role owner")
+}
+
+func TestRunUserCommand_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	users := mocks.NewMockUserStore(ctrl)
+	users.EXPECT().ListUsers(gomock.Any()).Return([]database.User{
+		{Username: "admin", Roles: []string{auth.RoleAdmin}},
+		{Username: "jane", Roles: []string{auth.RoleViewer, auth.RoleEditor}, Disabled: true, LockedUntil: &lockedUntil},
+	}, nil)
+
+	var out bytes.Buffer
+	err := runUserCommand(context.Background(), []string{"list"}, users, nil, &out)
+
+	require.NoError(t, err)
+	assert.Equal(t, "admin\tadmin\tenabled\njane\tviewer,editor\tdisabled, locked\n", out.String())
+}
+
+func TestRunUserCommand_Usage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	users := mocks.NewMockUserStore(ctrl)
+
+	for _, args := range [][]string{nil, {"create"}, {"rename", "jane"}, {"disable", "jane", "admin"}} {
+		err := runUserCommand(context.Background(), args, users, nil, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "usage:", args)
+	}
+}
@@ -16,10 +16,13 @@ import (
 )
 
 type TokenResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
 }
 
-func getToken(appURL string) string {
+// waitForReady polls the readiness probe until MySQL and Kafka are reachable
+// from the app.
+func waitForReady(appURL string) bool {
 	timeout := time.After(60 * time.Second)
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
@@ -27,39 +30,170 @@ func getToken(appURL string) string {
 	for {
 		select {
 		case <-timeout:
-			fmt.Println("Timeout reached. Token not received.")
-			return ""
+			fmt.Println("Timeout reached. Service is not ready.")
+			return false
 		case <-ticker.C:
-			req, err := http.NewRequest("POST", appURL+"/api/v1/token", nil)
+			resp, err := http.Get(appURL + "/readyz")
 			if err != nil {
-				log.Println("Failed to create HTTP request:", err)
+				log.Println("Readiness request failed:", err)
 				continue
 			}
+			resp.Body.Close()
 
-			req.Header.Set("Content-Type", "application/json")
-
-			client := &http.Client{}
-			resp, err := client.Do(req)
-			if err != nil {
-				log.Println("HTTP POST request failed:", err)
-				continue
+			if resp.StatusCode == http.StatusOK {
+				return true
 			}
 
-			defer resp.Body.Close()
+			log.Println("Waiting for service to be ready, status:", resp.StatusCode)
+		}
+	}
+}
 
-			var tokenData TokenResponse
-			if err := json.NewDecoder(resp.Body).Decode(&tokenData); err != nil {
-				log.Println("Failed to parse JSON:", err)
-				continue
-			}
+func getToken(appURL string) string {
+	req, err := http.NewRequest("POST", appURL+"/api/v1/token", nil)
+	if err != nil {
+		log.Println("Failed to create HTTP request:", err)
+		return ""
+	}
 
-			if tokenData.Token != "" {
-				log.Println("Token received:", tokenData.Token)
-				return tokenData.Token
-			}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Println("HTTP POST request failed:", err)
+		return ""
+	}
+	defer resp.Body.Close()
+
+	var tokenData TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenData); err != nil {
+		log.Println("Failed to parse JSON:", err)
+		return ""
+	}
+
+	return tokenData.Token
+}
+
+func postJSON(t *testing.T, url, token string, body any) *http.Response {
+	jsonBytes, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("failed to marshal body: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonBytes))
+	if err != nil {
+		t.Fatalf("Failed to create HTTP request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("HTTP POST request failed: %v", err)
+	}
+
+	return resp
+}
+
+func TestIntegration_Login(t *testing.T) {
+	appHost := getEnv("APP_HOST", "app")
+	appPort := getEnv("APP_PORT", "8080")
+	appURL := fmt.Sprintf("http://%s:%s", appHost, appPort)
+
+	if !waitForReady(appURL) {
+		t.Fatal("Service is not ready")
+	}
+
+	adminToken := getToken(appURL)
+	if len(adminToken) == 0 {
+		t.Fatal("Can not reach service")
+	}
+
+	username := fmt.Sprintf("it-%d", time.Now().UnixNano())
+	password := "integration test password"
+
+	resp := postJSON(t, appURL+"/api/v1/admin/users", adminToken, map[string]any{"username": username, "password": password, "roles": []string{"viewer"}})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201 creating the user, got %d", resp.StatusCode)
+	}
+
+	resp = postJSON(t, appURL+"/api/v1/auth/login", "", map[string]string{"username": username, "password": "wrong password"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong password, got %d", resp.StatusCode)
+	}
+
+	resp = postJSON(t, appURL+"/api/v1/auth/login", "", map[string]string{"username": username, "password": password})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 logging in, got %d", resp.StatusCode)
+	}
+
+	var tokenData TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenData); err != nil || tokenData.Token == "" {
+		t.Fatalf("expected a token, got error %v", err)
+	}
 
-			log.Println("Waiting for token...")
+	// A viewer may read companies but not change them, anonymous callers may
+	// not even read them.
+	for token, want := range map[string]int{tokenData.Token: http.StatusOK, "": http.StatusUnauthorized} {
+		req, err := http.NewRequest(http.MethodGet, appURL+"/api/v1/companies", nil)
+		if err != nil {
+			t.Fatalf("Failed to create HTTP request: %v", err)
 		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		list, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("HTTP GET request failed: %v", err)
+		}
+		list.Body.Close()
+		if list.StatusCode != want {
+			t.Fatalf("expected %d listing companies, got %d", want, list.StatusCode)
+		}
+	}
+
+	forbidden := postJSON(t, appURL+"/api/v1/companies", tokenData.Token, map[string]string{"name": "Forbidden Co"})
+	forbidden.Body.Close()
+	if forbidden.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 creating a company as a viewer, got %d", forbidden.StatusCode)
+	}
+
+	resp = postJSON(t, appURL+"/api/v1/auth/refresh", "", map[string]string{"refreshToken": tokenData.RefreshToken})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 refreshing, got %d", resp.StatusCode)
+	}
+
+	var refreshed TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&refreshed); err != nil || refreshed.RefreshToken == "" {
+		t.Fatalf("expected a refresh token, got error %v", err)
+	}
+
+	// The first refresh token was rotated away, using it again ends the login.
+	reuse := postJSON(t, appURL+"/api/v1/auth/refresh", "", map[string]string{"refreshToken": tokenData.RefreshToken})
+	reuse.Body.Close()
+	if reuse.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 reusing a refresh token, got %d", reuse.StatusCode)
+	}
+
+	logout := postJSON(t, appURL+"/api/v1/auth/logout", refreshed.Token, map[string]string{})
+	logout.Body.Close()
+	if logout.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected 204 logging out, got %d", logout.StatusCode)
+	}
+
+	again := postJSON(t, appURL+"/api/v1/auth/logout", refreshed.Token, map[string]string{})
+	again.Body.Close()
+	if again.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with a revoked token, got %d", again.StatusCode)
 	}
 }
 
@@ -72,6 +206,10 @@ func TestIntegration_CreateRecord(t *testing.T) {
 	kafkaTopic := "data-changed"
 
 	t.Log("Waiting for services to be ready...")
+	if !waitForReady(appURL) {
+		t.Fatal("Service is not ready")
+	}
+
 	jwtToken := getToken(appURL)
 
 	if len(jwtToken) == 0 {